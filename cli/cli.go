@@ -0,0 +1,37 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"fmt"
+	"io"
+)
+
+// Command is a subcommand of the webhook binary, which runs with the remaining arguments and
+// returns the exit code of the process.
+type Command func(args []string, stdout, stderr io.Writer) int
+
+//nolint:gochecknoglobals
+var commands = map[string]Command{
+	"explain":   Explain,
+	"manifests": Manifests,
+	"scan":      Scan,
+	"test":      Test,
+}
+
+// Lookup returns the subcommand with a given name, or false if the name is not a subcommand, in
+// which case the webhook server is started.
+func Lookup(name string) (Command, bool) {
+	command, ok := commands[name]
+
+	return command, ok
+}
+
+// usage writes the usage of a subcommand along with its flags.
+func usage(stderr io.Writer, usage string, flags interface{ PrintDefaults() }) func() {
+	return func() {
+		fmt.Fprintf(stderr, "usage: webhook %s\n", usage)
+		flags.PrintDefaults()
+	}
+}
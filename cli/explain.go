@@ -0,0 +1,192 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/nukleros/pod-security-webhook/resources"
+	"github.com/nukleros/pod-security-webhook/scan"
+	"github.com/nukleros/pod-security-webhook/validate"
+	"github.com/nukleros/pod-security-webhook/webhook"
+)
+
+const (
+	explainFormatText = "text"
+	explainFormatJSON = "json"
+)
+
+// Explain explains, for each workload within the manifest files or directories given as arguments,
+// which validations ran, which were skipped and why, their results and the settings which change
+// them.
+func Explain(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("explain", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = usage(stderr, "explain [flags] <file or dir>...", flags)
+
+	format := flags.String("format", explainFormatText, "output format, one of text or json")
+	policyPath := flags.String("policy", "", "path to the policy configuration file, defaults to the "+validate.PolicyConfigEnv+" environment variable")
+	username := flags.String("user", "", "username of the requester, used to explain user exemptions")
+	groups := flags.String("groups", "", "comma-separated groups of the requester, used to explain user exemptions")
+	verbose := flags.Bool("v", false, "print the settings which control passing validations as well as failing and skipped validations")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() == 0 || (*format != explainFormatText && *format != explainFormatJSON) {
+		flags.Usage()
+
+		return 2
+	}
+
+	explanations, err := runExplain(*policyPath, *username, *groups, flags.Args())
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %s\n", err)
+
+		return 1
+	}
+
+	if *format == explainFormatJSON {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+
+		if err := encoder.Encode(explanations); err != nil {
+			fmt.Fprintf(stderr, "error: %s - unable to write explanation\n", err)
+
+			return 1
+		}
+
+		return 0
+	}
+
+	for _, explanation := range explanations {
+		writeExplanation(stdout, explanation, *verbose)
+	}
+
+	return 0
+}
+
+// runExplain loads the policy configuration and manifests, and explains each workload.
+func runExplain(policyPath, username, groups string, paths []string) ([]*webhook.ExplainResponse, error) {
+	var policy *validate.PolicyConfig
+
+	var err error
+
+	if policyPath != "" {
+		policy, err = validate.LoadPolicyConfig(policyPath)
+	} else {
+		policy, err = validate.PolicyConfigFromEnv()
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w - error loading policy configuration", err)
+	}
+
+	defer policy.Close()
+
+	manifests, err := scan.Load(paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces, err := scan.Namespaces(manifests)
+	if err != nil {
+		return nil, err
+	}
+
+	hook, err := webhook.NewOfflineWebhook(policy, namespaces...)
+	if err != nil {
+		return nil, err
+	}
+
+	explanations := []*webhook.ExplainResponse{}
+
+	for _, manifest := range manifests {
+		raw, err := manifest.Object.MarshalJSON()
+		if err != nil {
+			return nil, fmt.Errorf("%w - unable to marshal object at line [%d] of [%s]", err, manifest.Line, manifest.File)
+		}
+
+		review, err := webhook.NewExplainReview(raw)
+		if err != nil {
+			return nil, err
+		}
+
+		review.Request.UserInfo.Username = username
+		if groups != "" {
+			review.Request.UserInfo.Groups = strings.Split(groups, ",")
+		}
+
+		explanation, err := hook.Explain(context.Background(), review)
+		if err != nil {
+			if errors.Is(err, resources.ErrValidatingKind) {
+				continue
+			}
+
+			return nil, fmt.Errorf("%w - unable to explain object at line [%d] of [%s]", err, manifest.Line, manifest.File)
+		}
+
+		explanations = append(explanations, explanation)
+	}
+
+	return explanations, nil
+}
+
+// writeExplanation writes an explanation as human readable text.  The settings which control a
+// validation are only written for validations which did not pass, unless verbose.
+func writeExplanation(w io.Writer, explanation *webhook.ExplainResponse, verbose bool) {
+	decision := "allowed"
+	if !explanation.Allowed {
+		decision = "denied"
+	}
+
+	fmt.Fprintf(w, "%s: %s\n", explanation.Resource, decision)
+
+	for _, validation := range explanation.Validations {
+		mode := string(validation.Action)
+		if !validation.Enabled {
+			mode = "disabled"
+		}
+
+		fmt.Fprintf(w, "  %-5s %s (%s, %s)\n", strings.ToUpper(string(validation.Status)), validation.Validation, validation.Source, mode)
+
+		if validation.Message != "" {
+			if validation.SkipReason != "" {
+				fmt.Fprintf(w, "        skipped by %s: %s\n", validation.SkipReason, validation.Message)
+			} else {
+				fmt.Fprintf(w, "        %s\n", validation.Message)
+			}
+		}
+
+		if validation.ExemptedBy != "" && validation.SkipReason == "" {
+			fmt.Fprintf(w, "        exempted by %s\n", validation.ExemptedBy)
+		}
+
+		if validation.Remediation != "" {
+			fmt.Fprintf(w, "        remediation: %s\n", validation.Remediation)
+		}
+
+		if validation.Status == validate.ResultPass && !verbose {
+			continue
+		}
+
+		for _, control := range validation.Controls {
+			current := ""
+			if control.Current != "" {
+				current = fmt.Sprintf(" (currently %q)", control.Current)
+			}
+
+			fmt.Fprintf(w, "        %s %s=%s%s: %s\n", control.Type, control.Key, control.Value, current, control.Effect)
+		}
+	}
+
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,104 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+
+	"github.com/nukleros/pod-security-webhook/generate"
+)
+
+// Manifests writes the manifests to deploy the webhook, rendered from the kinds and validations
+// which are known to the webhook.
+func Manifests(args []string, stdout, stderr io.Writer) int {
+	defaults := generate.DefaultOptions()
+
+	flags := flag.NewFlagSet("manifests", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = usage(stderr, "manifests [flags]", flags)
+
+	namespace := flags.String("namespace", defaults.Namespace, "namespace to deploy the webhook into")
+	image := flags.String("image", defaults.Image, "image of the webhook")
+	replicas := flags.Int("replicas", int(defaults.Replicas), "number of replicas of the webhook")
+	failurePolicy := flags.String("failure-policy", string(defaults.FailurePolicy), "failure policy of the webhooks, one of Fail or Ignore")
+	timeout := flags.Int("timeout", int(defaults.TimeoutSeconds), "timeout of the webhooks in seconds")
+	exclude := flags.String("exclude-namespaces", strings.Join(defaults.ExcludeNamespaces, ","), "comma-separated namespaces which are not sent to the webhooks")
+	mirrors := flags.Bool("image-mirrors", defaults.ImageMirrors, "register the mutating webhook which rewrites images to the image mirrors of the policy")
+	certMode := flags.String("cert-mode", string(defaults.CertMode), "how the serving certificate is provided, one of cert-manager or self-signed")
+	issuer := flags.String("issuer", defaults.Issuer, "cert-manager cluster issuer of the serving certificate")
+	secret := flags.String("secret", defaults.SecretName, "secret holding the serving certificate")
+	validity := flags.Duration("cert-validity", defaults.CertValidity, "validity of the serving certificate")
+	output := flags.String("output", "", "file to write the manifests to, defaults to stdout")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() != 0 {
+		flags.Usage()
+
+		return 2
+	}
+
+	options := &generate.Options{
+		Namespace:         *namespace,
+		Image:             *image,
+		Replicas:          int32(*replicas),
+		FailurePolicy:     admissionregistrationv1.FailurePolicyType(*failurePolicy),
+		TimeoutSeconds:    int32(*timeout),
+		ExcludeNamespaces: splitList(*exclude),
+		ImageMirrors:      *mirrors,
+		CertMode:          generate.CertMode(*certMode),
+		Issuer:            *issuer,
+		SecretName:        *secret,
+		CertValidity:      *validity,
+	}
+
+	if err := options.Validate(); err != nil {
+		fmt.Fprintf(stderr, "error: %s\n", err)
+
+		return 2
+	}
+
+	manifests := stdout
+
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: %s - unable to create manifests [%s]\n", err, *output)
+
+			return 1
+		}
+		defer file.Close()
+
+		manifests = file
+	}
+
+	if err := generate.Manifests(manifests, options); err != nil {
+		fmt.Fprintf(stderr, "error: %s\n", err)
+
+		return 1
+	}
+
+	return 0
+}
+
+// splitList splits a comma-separated list, ignoring empty items.
+func splitList(list string) []string {
+	items := []string{}
+
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
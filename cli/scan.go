@@ -0,0 +1,103 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/nukleros/pod-security-webhook/scan"
+	"github.com/nukleros/pod-security-webhook/validate"
+)
+
+// Scan runs all validations against the workloads within each of the manifest files or directories
+// given as arguments, and writes a report of the findings as text, SARIF or JUnit XML.  It exits
+// non-zero if any workload fails validation.
+func Scan(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("scan", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = usage(stderr, "scan [flags] <file or dir>...", flags)
+
+	format := flags.String("format", string(scan.FormatText), "report format, one of text, sarif or junit")
+	output := flags.String("output", "", "file to write the report to, defaults to stdout")
+	policyPath := flags.String("policy", "", "path to the policy configuration file, defaults to the "+validate.PolicyConfigEnv+" environment variable")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+
+		return 2
+	}
+
+	reportFormat, err := scan.ParseFormat(*format)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %s\n", err)
+
+		return 2
+	}
+
+	findings, err := runScan(*policyPath, flags.Args())
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %s\n", err)
+
+		return 1
+	}
+
+	report := stdout
+
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: %s - unable to create report [%s]\n", err, *output)
+
+			return 1
+		}
+		defer file.Close()
+
+		report = file
+	}
+
+	if err := scan.Write(report, reportFormat, findings); err != nil {
+		fmt.Fprintf(stderr, "error: %s\n", err)
+
+		return 1
+	}
+
+	if scan.Failed(findings) {
+		return 1
+	}
+
+	return 0
+}
+
+// runScan loads the policy configuration and manifests, and scans the manifests.
+func runScan(policyPath string, paths []string) ([]*scan.Finding, error) {
+	var policy *validate.PolicyConfig
+
+	var err error
+
+	if policyPath != "" {
+		policy, err = validate.LoadPolicyConfig(policyPath)
+	} else {
+		policy, err = validate.PolicyConfigFromEnv()
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w - error loading policy configuration", err)
+	}
+
+	defer policy.Close()
+
+	manifests, err := scan.Load(paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scan.Scan(policy, manifests)
+}
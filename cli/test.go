@@ -0,0 +1,80 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/nukleros/pod-security-webhook/policytest"
+)
+
+// Test runs the policy test fixtures within each of the directories given as arguments, printing
+// the outcome of each case and a diff for each expectation which was not met.  It exits non-zero
+// if any case fails.
+func Test(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = usage(stderr, "test [flags] <dir>...", flags)
+
+	verbose := flags.Bool("v", false, "print passing cases as well as failing cases")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+
+		return 2
+	}
+
+	fixtures := []*policytest.Fixture{}
+
+	for _, dir := range flags.Args() {
+		loaded, err := policytest.Load(dir)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: %s\n", err)
+
+			return 1
+		}
+
+		fixtures = append(fixtures, loaded...)
+	}
+
+	var passed, failed int
+
+	for _, result := range policytest.RunAll(fixtures) {
+		if result.Passed() {
+			passed++
+
+			if *verbose {
+				fmt.Fprintf(stdout, "PASS  %s: %s\n", result.Fixture, result.Case)
+			}
+
+			continue
+		}
+
+		failed++
+
+		fmt.Fprintf(stdout, "FAIL  %s: %s\n", result.Fixture, result.Case)
+
+		if result.Err != nil {
+			fmt.Fprintf(stdout, "      error: %s\n", result.Err)
+		}
+
+		for _, diff := range result.Diffs {
+			fmt.Fprintf(stdout, "      %s\n", diff)
+		}
+	}
+
+	fmt.Fprintf(stdout, "\n%d passed, %d failed\n", passed, failed)
+
+	if failed > 0 {
+		return 1
+	}
+
+	return 0
+}
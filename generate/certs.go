@@ -0,0 +1,98 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package generate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"time"
+)
+
+const serialNumberBits = 128
+
+// certificates are the pem encoded certificate authority, and the serving certificate and key
+// which it signed.
+type certificates struct {
+	CA   []byte
+	Cert []byte
+	Key  []byte
+}
+
+// selfSigned generates a certificate authority and a serving certificate for the dns names of the
+// webhook service, both of which are valid for the given duration.
+func selfSigned(commonName string, dnsNames []string, validity time.Duration) (*certificates, error) {
+	now := time.Now()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("%w - unable to generate certificate authority key", err)
+	}
+
+	caTemplate, err := certificateTemplate(commonName+"-ca", now, validity)
+	if err != nil {
+		return nil, err
+	}
+
+	caTemplate.IsCA = true
+	caTemplate.BasicConstraintsValid = true
+	caTemplate.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w - unable to create certificate authority", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("%w - unable to generate serving key", err)
+	}
+
+	template, err := certificateTemplate(commonName, now, validity)
+	if err != nil {
+		return nil, err
+	}
+
+	template.DNSNames = dnsNames
+	template.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+	template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, caTemplate, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w - unable to create serving certificate", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w - unable to marshal serving key", err)
+	}
+
+	return &certificates{
+		CA:   pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}),
+		Cert: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		Key:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}, nil
+}
+
+// certificateTemplate returns the template of a certificate with a random serial number.
+func certificateTemplate(commonName string, notBefore time.Time, validity time.Duration) (*x509.Certificate, error) {
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), serialNumberBits))
+	if err != nil {
+		return nil, fmt.Errorf("%w - unable to generate serial number", err)
+	}
+
+	return &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    notBefore,
+		NotAfter:     notBefore.Add(validity),
+	}, nil
+}
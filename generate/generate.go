@@ -0,0 +1,174 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package generate
+
+import (
+	"embed"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/nukleros/pod-security-webhook/resources"
+	"github.com/nukleros/pod-security-webhook/webhook"
+)
+
+// Name is the name of each of the resources of the webhook, as well as the name of its service.
+const Name = "pod-security-webhook"
+
+// renewBeforeRatio is the fraction of the validity of a cert-manager certificate which remains when
+// it is renewed.
+const renewBeforeRatio = 6
+
+//go:embed templates/*.yaml
+var templates embed.FS
+
+//nolint:gochecknoglobals
+var funcs = template.FuncMap{
+	"labels": labels,
+	"renewBefore": func(validity time.Duration) time.Duration {
+		return validity / renewBeforeRatio
+	},
+}
+
+// Rule is the group of resources within an api group, which is used for both the webhook
+// configuration rules and the permissions of the webhook.
+type Rule struct {
+	Group     string
+	Versions  []string
+	Resources []string
+}
+
+// data is the data which the manifests are rendered from.
+type data struct {
+	*Options
+
+	Name        string
+	DNSNames    []string
+	Rules       []Rule
+	Permissions []Rule
+	Validations []string
+
+	// CABundle, Cert and Key are the base64 encoded certificates in self-signed mode.
+	CABundle string
+	Cert     string
+	Key      string
+}
+
+// Manifests writes the manifests of the webhook, rendered from the kinds and validations which
+// are known to this webhook, so that they cannot drift from the code.
+func Manifests(w io.Writer, options *Options) error {
+	if err := options.Validate(); err != nil {
+		return err
+	}
+
+	manifests, err := template.New("").Funcs(funcs).ParseFS(templates, "templates/*.yaml")
+	if err != nil {
+		return fmt.Errorf("%w - unable to parse manifest templates", err)
+	}
+
+	input := &data{
+		Options:     options,
+		Name:        Name,
+		DNSNames:    DNSNames(options.Namespace),
+		Rules:       Rules(),
+		Permissions: permissions(),
+	}
+
+	for _, validation := range webhook.Validations(nil) {
+		input.Validations = append(input.Validations, validation.EnvironmetVariableOverride())
+	}
+
+	if options.CertMode == CertModeSelfSigned {
+		certs, err := selfSigned(input.DNSNames[0], input.DNSNames, options.CertValidity)
+		if err != nil {
+			return err
+		}
+
+		input.CABundle = base64.StdEncoding.EncodeToString(certs.CA)
+		input.Cert = base64.StdEncoding.EncodeToString(certs.Cert)
+		input.Key = base64.StdEncoding.EncodeToString(certs.Key)
+	}
+
+	if err := manifests.ExecuteTemplate(w, "pod-security-webhook.yaml", input); err != nil {
+		return fmt.Errorf("%w - unable to render manifests", err)
+	}
+
+	return nil
+}
+
+// Rules returns the resources which are sent to the webhook, grouped by api group in the order of
+// the kind registry.
+func Rules() []Rule {
+	rules := []Rule{}
+	index := map[string]int{}
+
+	for _, kind := range resources.Kinds() {
+		i, ok := index[kind.Group]
+		if !ok {
+			i = len(rules)
+			index[kind.Group] = i
+			rules = append(rules, Rule{Group: kind.Group})
+		}
+
+		if !contains(rules[i].Versions, kind.Version) {
+			rules[i].Versions = append(rules[i].Versions, kind.Version)
+		}
+
+		rules[i].Resources = append(rules[i].Resources, kind.Resource)
+	}
+
+	return rules
+}
+
+// DNSNames returns the dns names of the webhook service within a namespace, the first of which is
+// the name which the api server uses to call the webhook.
+func DNSNames(namespace string) []string {
+	return []string{
+		fmt.Sprintf("%s.%s.svc", Name, namespace),
+		fmt.Sprintf("%s.%s.svc.cluster.local", Name, namespace),
+		fmt.Sprintf("%s.%s", Name, namespace),
+		Name,
+		"localhost",
+	}
+}
+
+// permissions returns the resources which the webhook needs to read, which are the resources sent
+// to the webhook as well as namespaces, which hold the per-namespace settings.
+func permissions() []Rule {
+	rules := Rules()
+
+	for i := range rules {
+		if rules[i].Group == "" {
+			rules[i].Resources = append(rules[i].Resources, "namespaces")
+		}
+	}
+
+	return rules
+}
+
+// labels returns the labels which are common to each of the resources of the webhook, indented
+// for the template.
+func labels(indent int) string {
+	lines := []string{}
+
+	for _, label := range []string{"name", "instance", "component"} {
+		lines = append(lines, fmt.Sprintf("%sapp.kubernetes.io/%s: %s", strings.Repeat(" ", indent), label, Name))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// contains returns whether a value is within a list of values.
+func contains(values []string, value string) bool {
+	for i := range values {
+		if values[i] == value {
+			return true
+		}
+	}
+
+	return false
+}
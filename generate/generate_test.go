@@ -0,0 +1,249 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package generate
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/nukleros/pod-security-webhook/resources"
+)
+
+const checkedInManifests = "../manifests/pod-security-webhook.yaml"
+
+// render renders the manifests and returns each of the documents.
+func render(t *testing.T, options *Options) []string {
+	t.Helper()
+
+	buffer := &bytes.Buffer{}
+	if err := Manifests(buffer, options); err != nil {
+		t.Fatalf("Manifests() error = %v", err)
+	}
+
+	return strings.Split(buffer.String(), "\n---\n")
+}
+
+// document returns the document of a kind from the rendered manifests.
+func document(t *testing.T, documents []string, kind string) string {
+	t.Helper()
+
+	for _, document := range documents {
+		if strings.Contains(document, "\nkind: "+kind+"\n") {
+			return document
+		}
+	}
+
+	t.Fatalf("no document of kind [%s] found", kind)
+
+	return ""
+}
+
+func TestManifestsDrift(t *testing.T) {
+	t.Parallel()
+
+	want, err := os.ReadFile(checkedInManifests)
+	if err != nil {
+		t.Fatalf("unable to read checked in manifests: %v", err)
+	}
+
+	got := &bytes.Buffer{}
+	if err := Manifests(got, DefaultOptions()); err != nil {
+		t.Fatalf("Manifests() error = %v", err)
+	}
+
+	if got.String() != string(want) {
+		t.Errorf("%s is out of date with the code; run 'make manifests' to regenerate it", checkedInManifests)
+	}
+}
+
+func TestManifestsRules(t *testing.T) {
+	t.Parallel()
+
+	webhooks := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+	if err := yaml.UnmarshalStrict([]byte(document(t, render(t, DefaultOptions()), "ValidatingWebhookConfiguration")), webhooks); err != nil {
+		t.Fatalf("unable to parse validating webhook configuration: %v", err)
+	}
+
+	for _, kind := range resources.Kinds() {
+		found := false
+
+		for _, rule := range webhooks.Webhooks[0].Rules {
+			if rule.APIGroups[0] == kind.Group && contains(rule.APIVersions, kind.Version) && contains(rule.Resources, kind.Resource) {
+				found = true
+			}
+		}
+
+		if !found {
+			t.Errorf("no webhook rule found for kind [%s]", kind.GroupVersionKind)
+		}
+	}
+}
+
+func TestManifestsSelfSigned(t *testing.T) {
+	t.Parallel()
+
+	options := DefaultOptions()
+	options.CertMode = CertModeSelfSigned
+	options.Namespace = "security"
+	options.SecretName = "webhook-tls"
+
+	documents := render(t, options)
+
+	secret := &corev1.Secret{}
+	if err := yaml.UnmarshalStrict([]byte(document(t, documents, "Secret")), secret); err != nil {
+		t.Fatalf("unable to parse secret: %v", err)
+	}
+
+	if secret.Name != options.SecretName || secret.Namespace != options.Namespace {
+		t.Errorf("secret = %s/%s, want %s/%s", secret.Namespace, secret.Name, options.Namespace, options.SecretName)
+	}
+
+	webhooks := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+	if err := yaml.UnmarshalStrict([]byte(document(t, documents, "ValidatingWebhookConfiguration")), webhooks); err != nil {
+		t.Fatalf("unable to parse validating webhook configuration: %v", err)
+	}
+
+	if len(webhooks.Annotations) != 0 {
+		t.Errorf("annotations = %v, want none in self-signed mode", webhooks.Annotations)
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(webhooks.Webhooks[0].ClientConfig.CABundle) {
+		t.Fatalf("unable to parse ca bundle")
+	}
+
+	block, _ := pem.Decode(secret.Data[corev1.TLSCertKey])
+	if block == nil {
+		t.Fatalf("unable to decode serving certificate")
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("unable to parse serving certificate: %v", err)
+	}
+
+	// the api server calls the webhook at the dns name of its service
+	if _, err := cert.Verify(x509.VerifyOptions{DNSName: "pod-security-webhook.security.svc", Roots: roots}); err != nil {
+		t.Errorf("serving certificate does not verify against the ca bundle: %v", err)
+	}
+
+	if strings.Contains(strings.Join(documents, ""), "cert-manager") {
+		t.Errorf("manifests reference cert-manager in self-signed mode")
+	}
+
+	if !bytes.Equal(secret.Data["ca.crt"], webhooks.Webhooks[0].ClientConfig.CABundle) {
+		t.Errorf("ca.crt of the secret does not match the ca bundle of the webhook")
+	}
+}
+
+func TestManifestsImageMirrors(t *testing.T) {
+	t.Parallel()
+
+	// the mutating webhook is only registered when image mirrors are enabled
+	if strings.Contains(strings.Join(render(t, DefaultOptions()), ""), "MutatingWebhookConfiguration") {
+		t.Errorf("manifests register the mutating webhook without image mirrors")
+	}
+
+	options := DefaultOptions()
+	options.ImageMirrors = true
+
+	webhooks := &admissionregistrationv1.MutatingWebhookConfiguration{}
+	if err := yaml.UnmarshalStrict([]byte(document(t, render(t, options), "MutatingWebhookConfiguration")), webhooks); err != nil {
+		t.Fatalf("unable to parse mutating webhook configuration: %v", err)
+	}
+
+	if len(webhooks.Webhooks) != 1 || *webhooks.Webhooks[0].ClientConfig.Service.Path != "/mutate" {
+		t.Errorf("mutating webhooks = %+v, want a webhook for /mutate", webhooks.Webhooks)
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		mutate  func(*Options)
+		wantErr bool
+	}{
+		{
+			name:    "ensure the default options are valid",
+			mutate:  func(options *Options) {},
+			wantErr: false,
+		},
+		{
+			name:    "ensure self-signed mode without an issuer is valid",
+			mutate:  func(options *Options) { options.CertMode = CertModeSelfSigned; options.Issuer = "" },
+			wantErr: false,
+		},
+		{
+			name:    "ensure no namespace exclusions are valid",
+			mutate:  func(options *Options) { options.ExcludeNamespaces = nil },
+			wantErr: false,
+		},
+		{
+			name:    "ensure an empty namespace is invalid",
+			mutate:  func(options *Options) { options.Namespace = "" },
+			wantErr: true,
+		},
+		{
+			name:    "ensure zero replicas is invalid",
+			mutate:  func(options *Options) { options.Replicas = 0 },
+			wantErr: true,
+		},
+		{
+			name:    "ensure an unknown failure policy is invalid",
+			mutate:  func(options *Options) { options.FailurePolicy = "Retry" },
+			wantErr: true,
+		},
+		{
+			name:    "ensure a timeout above the api server maximum is invalid",
+			mutate:  func(options *Options) { options.TimeoutSeconds = 31 },
+			wantErr: true,
+		},
+		{
+			name:    "ensure cert-manager mode without an issuer is invalid",
+			mutate:  func(options *Options) { options.Issuer = "" },
+			wantErr: true,
+		},
+		{
+			name:    "ensure an unknown certificate mode is invalid",
+			mutate:  func(options *Options) { options.CertMode = "manual" },
+			wantErr: true,
+		},
+		{
+			name:    "ensure a negative certificate validity is invalid",
+			mutate:  func(options *Options) { options.CertValidity = -1 },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			options := DefaultOptions()
+			tt.mutate(options)
+
+			err := options.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil && !errors.Is(err, ErrOptionsInvalid) {
+				t.Errorf("Validate() error = %v, want %v", err, ErrOptionsInvalid)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package generate
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+)
+
+// CertMode is how the serving certificate of the webhook is provided.
+type CertMode string
+
+const (
+	// CertModeCertManager requests the certificate from a cert-manager cluster issuer, which also
+	// injects its certificate authority into the webhook configurations.
+	CertModeCertManager CertMode = "cert-manager"
+
+	// CertModeSelfSigned generates a certificate authority and serving certificate when rendering
+	// the manifests.
+	CertModeSelfSigned CertMode = "self-signed"
+)
+
+const (
+	defaultNamespace    = "nukleros-admission-system"
+	defaultImage        = "ghcr.io/nukleros/pod-security-webhook:latest"
+	defaultReplicas     = 2
+	defaultTimeout      = 10
+	defaultIssuer       = "root-ca"
+	defaultSecretName   = "pod-security-webhook-cert"
+	defaultCertValidity = 90 * 24 * time.Hour
+	defaultExcludedName = "kube-system"
+
+	// the api server only accepts webhook timeouts within this range
+	minTimeout = 1
+	maxTimeout = 30
+)
+
+var ErrOptionsInvalid = errors.New("invalid manifest options")
+
+// Options are the options for rendering the manifests of the webhook.
+type Options struct {
+	Namespace string
+	Image     string
+	Replicas  int32
+
+	// FailurePolicy and TimeoutSeconds apply to both the validating and mutating webhooks.
+	FailurePolicy  admissionregistrationv1.FailurePolicyType
+	TimeoutSeconds int32
+
+	// ExcludeNamespaces are the namespaces which are not sent to the webhook.
+	ExcludeNamespaces []string
+
+	// ImageMirrors registers the mutating webhook, which rewrites images to the mirrors in the
+	// policy configuration.  Without it, image mirrors in the policy configuration have no effect.
+	ImageMirrors bool
+
+	// CertMode is how the serving certificate is provided.  Issuer is the cert-manager cluster
+	// issuer and is only used in cert-manager mode.  The certificate is stored in the secret
+	// SecretName in either mode.
+	CertMode     CertMode
+	Issuer       string
+	SecretName   string
+	CertValidity time.Duration
+}
+
+// DefaultOptions returns the options for the manifests which are checked into the repository.
+func DefaultOptions() *Options {
+	return &Options{
+		Namespace:         defaultNamespace,
+		Image:             defaultImage,
+		Replicas:          defaultReplicas,
+		FailurePolicy:     admissionregistrationv1.Fail,
+		TimeoutSeconds:    defaultTimeout,
+		ExcludeNamespaces: []string{defaultExcludedName},
+		CertMode:          CertModeCertManager,
+		Issuer:            defaultIssuer,
+		SecretName:        defaultSecretName,
+		CertValidity:      defaultCertValidity,
+	}
+}
+
+// Validate returns an error if the options would render manifests which are rejected by the
+// cluster.
+func (options *Options) Validate() error {
+	if options.Namespace == "" {
+		return fmt.Errorf("%w - namespace must be set", ErrOptionsInvalid)
+	}
+
+	if options.Image == "" {
+		return fmt.Errorf("%w - image must be set", ErrOptionsInvalid)
+	}
+
+	if options.Replicas < 1 {
+		return fmt.Errorf("%w - replicas must be at least 1, found [%d]", ErrOptionsInvalid, options.Replicas)
+	}
+
+	switch options.FailurePolicy {
+	case admissionregistrationv1.Fail, admissionregistrationv1.Ignore:
+	default:
+		return fmt.Errorf("%w - failure policy must be one of [%s, %s], found [%s]",
+			ErrOptionsInvalid, admissionregistrationv1.Fail, admissionregistrationv1.Ignore, options.FailurePolicy)
+	}
+
+	if options.TimeoutSeconds < minTimeout || options.TimeoutSeconds > maxTimeout {
+		return fmt.Errorf("%w - timeout must be between [%d] and [%d] seconds, found [%d]",
+			ErrOptionsInvalid, minTimeout, maxTimeout, options.TimeoutSeconds)
+	}
+
+	switch options.CertMode {
+	case CertModeCertManager:
+		if options.Issuer == "" {
+			return fmt.Errorf("%w - issuer must be set in [%s] mode", ErrOptionsInvalid, CertModeCertManager)
+		}
+	case CertModeSelfSigned:
+	default:
+		return fmt.Errorf("%w - certificate mode must be one of [%s, %s], found [%s]",
+			ErrOptionsInvalid, CertModeCertManager, CertModeSelfSigned, options.CertMode)
+	}
+
+	if options.SecretName == "" {
+		return fmt.Errorf("%w - secret must be set", ErrOptionsInvalid)
+	}
+
+	if options.CertValidity <= 0 {
+		return fmt.Errorf("%w - certificate validity must be positive, found [%s]", ErrOptionsInvalid, options.CertValidity)
+	}
+
+	return nil
+}
@@ -0,0 +1,286 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package logging
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrInvalidLevel  = errors.New("invalid log level")
+	ErrInvalidFormat = errors.New("invalid log format")
+)
+
+// Level is the severity of a log message.
+type Level int32
+
+const (
+	DebugLevel Level = iota
+	InfoLevel
+	WarningLevel
+	ErrorLevel
+)
+
+// String returns the string representation of a log level.
+func (level Level) String() string {
+	switch level {
+	case DebugLevel:
+		return "debug"
+	case InfoLevel:
+		return "info"
+	case WarningLevel:
+		return "warning"
+	case ErrorLevel:
+		return "error"
+	default:
+		return fmt.Sprintf("level(%d)", level)
+	}
+}
+
+// ParseLevel parses a log level from a string.
+func ParseLevel(level string) (Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return DebugLevel, nil
+	case "info":
+		return InfoLevel, nil
+	case "warn", "warning":
+		return WarningLevel, nil
+	case "error":
+		return ErrorLevel, nil
+	default:
+		return InfoLevel, fmt.Errorf("%w - [%s]", ErrInvalidLevel, level)
+	}
+}
+
+// Format is the output format of log messages.
+type Format string
+
+const (
+	FormatJSON   Format = "json"
+	FormatLogfmt Format = "logfmt"
+)
+
+// ParseFormat parses a log format from a string.
+func ParseFormat(format string) (Format, error) {
+	switch Format(strings.ToLower(format)) {
+	case FormatJSON:
+		return FormatJSON, nil
+	case FormatLogfmt:
+		return FormatLogfmt, nil
+	default:
+		return FormatJSON, fmt.Errorf("%w - [%s]", ErrInvalidFormat, format)
+	}
+}
+
+// Logger is a leveled, structured logger.  Loggers derived from another logger using With
+// share the level of the parent logger, so that the level may be changed at runtime.
+//
+// Logger keeps the printf style methods of the logger which it replaced, so that call sites did
+// not change.  It is implemented here rather than on zap or logr as neither of those, in the
+// versions required by our dependencies, is able to write logfmt.
+type Logger struct {
+	name   string
+	format Format
+	level  *int32
+	out    io.Writer
+	mutex  *sync.Mutex
+	fields []interface{}
+
+	// now returns the current time and exists for testing
+	now func() time.Time
+}
+
+// New returns a new logger.
+func New(name string, format Format, level Level, out io.Writer) *Logger {
+	levelValue := int32(level)
+
+	return &Logger{
+		name:   name,
+		format: format,
+		level:  &levelValue,
+		out:    out,
+		mutex:  &sync.Mutex{},
+		now:    time.Now,
+	}
+}
+
+// With returns a logger which includes the given key value pairs on each log message.
+func (logger *Logger) With(keysAndValues ...interface{}) *Logger {
+	child := *logger
+	child.fields = append(append([]interface{}{}, logger.fields...), keysAndValues...)
+
+	return &child
+}
+
+// SetLevel sets the level of the logger, and all loggers derived from it.
+func (logger *Logger) SetLevel(level Level) {
+	atomic.StoreInt32(logger.level, int32(level))
+}
+
+// Level returns the level of the logger.
+func (logger *Logger) Level() Level {
+	return Level(atomic.LoadInt32(logger.level))
+}
+
+// Enabled returns whether messages at a given level are logged.
+func (logger *Logger) Enabled(level Level) bool {
+	return level >= logger.Level()
+}
+
+// Debug logs a message at the debug level.
+func (logger *Logger) Debug(message string) { logger.log(DebugLevel, message) }
+
+// Debugf logs a formatted message at the debug level.
+func (logger *Logger) Debugf(format string, a ...interface{}) {
+	logger.log(DebugLevel, fmt.Sprintf(format, a...))
+}
+
+// Info logs a message at the info level.
+func (logger *Logger) Info(message string) { logger.log(InfoLevel, message) }
+
+// Infof logs a formatted message at the info level.
+func (logger *Logger) Infof(format string, a ...interface{}) {
+	logger.log(InfoLevel, fmt.Sprintf(format, a...))
+}
+
+// Warningf logs a formatted message at the warning level.
+func (logger *Logger) Warningf(format string, a ...interface{}) {
+	logger.log(WarningLevel, fmt.Sprintf(format, a...))
+}
+
+// Error logs a message at the error level.
+func (logger *Logger) Error(message string) { logger.log(ErrorLevel, message) }
+
+// Errorf logs a formatted message at the error level.
+func (logger *Logger) Errorf(format string, a ...interface{}) {
+	logger.log(ErrorLevel, fmt.Sprintf(format, a...))
+}
+
+// Fatalf logs a formatted message at the error level and exits the process.
+func (logger *Logger) Fatalf(format string, a ...interface{}) {
+	logger.log(ErrorLevel, fmt.Sprintf(format, a...))
+	os.Exit(1)
+}
+
+// log writes a message at a given level.
+func (logger *Logger) log(level Level, message string) {
+	if !logger.Enabled(level) {
+		return
+	}
+
+	entry := logger.entry(level, message)
+
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+
+	//nolint:errcheck
+	_, _ = logger.out.Write(entry)
+}
+
+// entry encodes a log message in the format of the logger.
+func (logger *Logger) entry(level Level, message string) []byte {
+	keysAndValues := append([]interface{}{
+		"time", logger.now().UTC().Format(time.RFC3339Nano),
+		"level", level.String(),
+		"logger", logger.name,
+		"msg", message,
+	}, logger.fields...)
+
+	if logger.format == FormatLogfmt {
+		return encodeLogfmt(keysAndValues)
+	}
+
+	return encodeJSON(keysAndValues)
+}
+
+// encodeJSON encodes key value pairs as a single line JSON object.  Key order is preserved.
+func encodeJSON(keysAndValues []interface{}) []byte {
+	var builder strings.Builder
+
+	builder.WriteString("{")
+
+	for i := 0; i < len(keysAndValues); i += 2 {
+		if i > 0 {
+			builder.WriteString(",")
+		}
+
+		key, _ := json.Marshal(fmt.Sprint(keysAndValues[i]))
+		builder.Write(key)
+		builder.WriteString(":")
+
+		value, err := json.Marshal(valueAt(keysAndValues, i+1))
+		if err != nil {
+			value, _ = json.Marshal(fmt.Sprint(valueAt(keysAndValues, i+1)))
+		}
+
+		builder.Write(value)
+	}
+
+	builder.WriteString("}\n")
+
+	return []byte(builder.String())
+}
+
+// encodeLogfmt encodes key value pairs as a single logfmt line.
+func encodeLogfmt(keysAndValues []interface{}) []byte {
+	var builder strings.Builder
+
+	for i := 0; i < len(keysAndValues); i += 2 {
+		if i > 0 {
+			builder.WriteString(" ")
+		}
+
+		builder.WriteString(fmt.Sprint(keysAndValues[i]))
+		builder.WriteString("=")
+		builder.WriteString(logfmtValue(valueAt(keysAndValues, i+1)))
+	}
+
+	builder.WriteString("\n")
+
+	return []byte(builder.String())
+}
+
+// logfmtValue returns the logfmt representation of a value, quoting the value if needed.
+func logfmtValue(value interface{}) string {
+	var formatted string
+
+	switch typed := value.(type) {
+	case string:
+		formatted = typed
+	case []string:
+		formatted = strings.Join(typed, ",")
+	default:
+		formatted = fmt.Sprint(typed)
+	}
+
+	if formatted == "" || strings.ContainsAny(formatted, " =\"\t\n") {
+		quoted, _ := json.Marshal(formatted)
+
+		return string(quoted)
+	}
+
+	return formatted
+}
+
+// valueAt returns the value at an index, or a placeholder if the value is missing.
+func valueAt(keysAndValues []interface{}, i int) interface{} {
+	if i >= len(keysAndValues) {
+		return "(MISSING)"
+	}
+
+	if err, ok := keysAndValues[i].(error); ok {
+		return err.Error()
+	}
+
+	return keysAndValues[i]
+}
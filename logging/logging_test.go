@@ -0,0 +1,139 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testLogger(format Format, level Level) (*Logger, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	logger := New("test", format, level, out)
+	logger.now = func() time.Time { return time.Date(2022, time.June, 1, 0, 0, 0, 0, time.UTC) }
+
+	return logger, out
+}
+
+func TestLogger(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		format Format
+		level  Level
+		log    func(*Logger)
+		want   string
+	}{
+		{
+			name:   "ensure json messages include fields in order",
+			format: FormatJSON,
+			level:  InfoLevel,
+			log: func(logger *Logger) {
+				logger.With("uid", "1234", "violations", []string{"host-pid"}).Infof("admission %s", "decision")
+			},
+			want: `{"time":"2022-06-01T00:00:00Z","level":"info","logger":"test","msg":"admission decision",` +
+				`"uid":"1234","violations":["host-pid"]}` + "\n",
+		},
+		{
+			name:   "ensure logfmt messages quote values when needed",
+			format: FormatLogfmt,
+			level:  InfoLevel,
+			log: func(logger *Logger) {
+				logger.With("uid", "1234", "violations", []string{"host-pid", "host-ipc"}).Warningf("admission decision")
+			},
+			want: `time=2022-06-01T00:00:00Z level=warning logger=test msg="admission decision" uid=1234 violations=host-pid,host-ipc` + "\n",
+		},
+		{
+			name:   "ensure messages below the level are not logged",
+			format: FormatJSON,
+			level:  InfoLevel,
+			log: func(logger *Logger) {
+				logger.Debug("hidden")
+			},
+			want: "",
+		},
+		{
+			name:   "ensure derived loggers follow level changes of the parent",
+			format: FormatLogfmt,
+			level:  InfoLevel,
+			log: func(logger *Logger) {
+				child := logger.With("uid", "1234")
+				logger.SetLevel(DebugLevel)
+				child.Debug("shown")
+			},
+			want: "time=2022-06-01T00:00:00Z level=debug logger=test msg=shown uid=1234\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			logger, out := testLogger(tt.format, tt.level)
+			tt.log(logger)
+			if got := out.String(); got != tt.want {
+				t.Errorf("Logger output = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactRaw(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		raw     string
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "ensure container environment variable values are redacted",
+			raw: `{"kind":"Pod","spec":{"containers":[{"name":"app","env":[{"name":"PASSWORD","value":"hunter2"},` +
+				`{"name":"FROM_SECRET","valueFrom":{"secretKeyRef":{"name":"secret","key":"password"}}}]}]}}`,
+			want:    []string{`"name":"PASSWORD"`, `"value":"[REDACTED]"`, `"secretKeyRef"`},
+			notWant: []string{"hunter2"},
+		},
+		{
+			name:    "ensure secret data is redacted",
+			raw:     `{"kind":"Secret","data":{"password":"aHVudGVyMg=="},"stringData":{"token":"hunter2"}}`,
+			want:    []string{`"password":"[REDACTED]"`, `"token":"[REDACTED]"`},
+			notWant: []string{"hunter2", "aHVudGVyMg=="},
+		},
+		{
+			name: "ensure the last applied configuration is redacted",
+			raw: `{"kind":"Deployment","metadata":{"annotations":{"kubectl.kubernetes.io/last-applied-configuration":` +
+				`"{\"env\":[{\"name\":\"PASSWORD\",\"value\":\"hunter2\"}]}"}}}`,
+			want:    []string{`"kubectl.kubernetes.io/last-applied-configuration":"[REDACTED]"`},
+			notWant: []string{"hunter2"},
+		},
+		{
+			name:    "ensure an invalid object is redacted entirely",
+			raw:     `{"kind":"Pod","env":`,
+			want:    []string{`"[REDACTED]"`},
+			notWant: []string{"Pod"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := string(RedactRaw([]byte(tt.raw)))
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("RedactRaw() = %v, want to contain %v", got, want)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(got, notWant) {
+					t.Errorf("RedactRaw() = %v, want to not contain %v", got, notWant)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package logging
+
+import (
+	"encoding/json"
+)
+
+const (
+	// Redacted is the value which replaces sensitive values.
+	Redacted = "[REDACTED]"
+
+	lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+)
+
+// RedactRaw redacts sensitive values from a raw JSON kubernetes object.  The raw object is
+// replaced entirely if it is unable to be parsed.
+func RedactRaw(raw []byte) []byte {
+	if len(raw) == 0 {
+		return raw
+	}
+
+	object := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &object); err != nil {
+		redacted, _ := json.Marshal(Redacted)
+
+		return redacted
+	}
+
+	RedactObject(object)
+
+	redacted, err := json.Marshal(object)
+	if err != nil {
+		redacted, _ = json.Marshal(Redacted)
+	}
+
+	return redacted
+}
+
+// RedactObject redacts sensitive values from a kubernetes object in its unstructured form.  This
+// includes container environment variable values, secret data and the last applied configuration
+// annotation, which may contain either of the former.
+func RedactObject(object map[string]interface{}) {
+	if object["kind"] == "Secret" {
+		for _, field := range []string{"data", "stringData"} {
+			redactValues(object[field])
+		}
+	}
+
+	if metadata, ok := object["metadata"].(map[string]interface{}); ok {
+		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+			if _, found := annotations[lastAppliedAnnotation]; found {
+				annotations[lastAppliedAnnotation] = Redacted
+			}
+		}
+	}
+
+	redactEnv(object)
+}
+
+// redactEnv recursively redacts the values of any environment variables found in an object.
+func redactEnv(value interface{}) {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, child := range typed {
+			if env, ok := child.([]interface{}); ok && key == "env" {
+				for i := range env {
+					if variable, ok := env[i].(map[string]interface{}); ok {
+						if _, found := variable["value"]; found {
+							variable["value"] = Redacted
+						}
+					}
+				}
+
+				continue
+			}
+
+			redactEnv(child)
+		}
+	case []interface{}:
+		for i := range typed {
+			redactEnv(typed[i])
+		}
+	}
+}
+
+// redactValues redacts all values of a map.
+func redactValues(value interface{}) {
+	values, ok := value.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	for key := range values {
+		values[key] = Redacted
+	}
+}
@@ -5,49 +5,43 @@ package main
 
 import (
 	"context"
-	"crypto/tls"
 	"fmt"
-	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
+	"github.com/nukleros/pod-security-webhook/cli"
 	"github.com/nukleros/pod-security-webhook/webhook"
 )
 
 func main() {
+	// run a subcommand, such as testing policies, rather than the webhook server if requested
+	if len(os.Args) > 1 {
+		if command, ok := cli.Lookup(os.Args[1]); ok {
+			os.Exit(command(os.Args[2:], os.Stdout, os.Stderr))
+		}
+	}
+
 	webHook, err := webhook.NewWebhook()
 	if err != nil {
 		panic(fmt.Errorf("%w - error creating webhook", err))
 	}
 
-	server := &http.Server{
-		Addr: fmt.Sprintf(":%v", webHook.Port), // Listen on all the interfaces
-		TLSConfig: &tls.Config{
-			Certificates: []tls.Certificate{*webHook.Certificate},
-			MinVersion:   tls.VersionTLS12,
-		},
-
-		// set timeouts to prevent ddos attacks
-		ReadHeaderTimeout: 5 * time.Second,
+	options, err := webhook.ServerOptionsFromEnv()
+	if err != nil {
+		panic(fmt.Errorf("%w - error retrieving server options", err))
 	}
 
-	server.Handler = webHook.Router
+	// the runner shuts down gracefully once a shutdown signal is received
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 
-	go func() {
-		webHook.Log.InfoF("starting web server on port %v", webHook.Port)
-		webHook.Log.Error(server.ListenAndServeTLS("", "").Error())
-	}()
+	err = webhook.NewRunner(webHook, options).Run(ctx)
 
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	<-sigChan
+	stop()
 
-	webHook.Log.Info("received shutdown signal, shutting down web server")
-
-	if err := server.Shutdown(context.Background()); err != nil {
-		webHook.Log.Fatal("failed to shutdown web server gracefully")
-		webHook.Log.Fatal(err.Error())
+	if err != nil {
+		webHook.Log.Fatalf("%s - error running webhook", err)
 	}
+
+	webHook.Log.Info("webhook shut down gracefully")
 }
@@ -0,0 +1,221 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package policytest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// ExpectationsFile is the name of the file which marks a directory as a fixture and holds the
+// expectations for each of its cases.
+const ExpectationsFile = "expectations.yaml"
+
+var ErrFixtureInvalid = errors.New("invalid policy test fixture")
+
+// Fixture is a directory of manifests along with the expectations of admitting each of them.
+type Fixture struct {
+	// Dir is the directory of the fixture, which the paths in the expectations are relative to.
+	Dir string `json:"-"`
+
+	// Env and Policy are the environment variables and the path to the policy configuration file
+	// which apply to each of the cases, unless overridden by the case.
+	Env    map[string]string `json:"env,omitempty"`
+	Policy string            `json:"policy,omitempty"`
+
+	// Namespaces are the paths to manifests of the namespaces which are available to the
+	// validations, for example to set per-namespace annotations.
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	Cases []*Case `json:"cases"`
+}
+
+// Case is a single manifest and the expected outcome of admitting it.
+type Case struct {
+	Name string `json:"name"`
+	File string `json:"file"`
+
+	// Operation defaults to CREATE, and Namespace defaults to the namespace of the manifest or the
+	// default namespace.
+	Operation admissionv1.Operation `json:"operation,omitempty"`
+	Namespace string                `json:"namespace,omitempty"`
+	User      *User                 `json:"user,omitempty"`
+
+	// Env is merged with, and Policy replaces, the environment variables and policy configuration
+	// of the fixture.
+	Env    map[string]string `json:"env,omitempty"`
+	Policy string            `json:"policy,omitempty"`
+
+	// Allowed is the expected admission decision.  Violations and Warnings are the names of the
+	// validations which are expected to fail in deny and warn mode respectively.  They are only
+	// compared when set, and an empty list expects no violations or warnings.
+	Allowed    bool     `json:"allowed"`
+	Violations []string `json:"violations,omitempty"`
+	Warnings   []string `json:"warnings,omitempty"`
+
+	// Containers are the names of the containers which are expected to fail each validation, and
+	// Messages are text which is expected within the message of each validation.  Only the
+	// validations which are set are compared.
+	Containers map[string][]string `json:"containers,omitempty"`
+	Messages   map[string]string   `json:"messages,omitempty"`
+}
+
+// User is the requesting user of a case, which is used to test user exemptions.
+type User struct {
+	Username string   `json:"username"`
+	Groups   []string `json:"groups,omitempty"`
+}
+
+// Load loads each of the fixtures within a directory and its subdirectories.  A directory is a
+// fixture if it contains an expectations file.
+func Load(root string) ([]*Fixture, error) {
+	dirs := []string{}
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !entry.IsDir() && entry.Name() == ExpectationsFile {
+			dirs = append(dirs, filepath.Dir(path))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w - unable to find fixtures in [%s]", err, root)
+	}
+
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("%w - no [%s] files found in [%s]", ErrFixtureInvalid, ExpectationsFile, root)
+	}
+
+	sort.Strings(dirs)
+
+	fixtures := make([]*Fixture, len(dirs))
+
+	for i, dir := range dirs {
+		if fixtures[i], err = LoadFixture(dir); err != nil {
+			return nil, err
+		}
+	}
+
+	return fixtures, nil
+}
+
+// LoadFixture loads the fixture in a directory.
+func LoadFixture(dir string) (*Fixture, error) {
+	path := filepath.Join(dir, ExpectationsFile)
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w - unable to read expectations [%s]", err, path)
+	}
+
+	fixture := &Fixture{}
+	if err := yaml.UnmarshalStrict(content, fixture); err != nil {
+		return nil, fmt.Errorf("%w - unable to parse expectations [%s]; %s", ErrFixtureInvalid, path, err)
+	}
+
+	fixture.Dir = dir
+
+	for i, testCase := range fixture.Cases {
+		if testCase.Name == "" || testCase.File == "" {
+			return nil, fmt.Errorf("%w - case [%d] in [%s] must set a name and file", ErrFixtureInvalid, i, path)
+		}
+	}
+
+	return fixture, nil
+}
+
+// object loads the single object from the manifest of a case.
+func (fixture *Fixture) object(testCase *Case) (*unstructured.Unstructured, error) {
+	objects, err := fixture.objects(testCase.File)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(objects) != 1 {
+		return nil, fmt.Errorf("%w - expected a single object in [%s], found [%d]", ErrFixtureInvalid, testCase.File, len(objects))
+	}
+
+	return objects[0], nil
+}
+
+// namespaces loads each of the namespaces of the fixture.
+func (fixture *Fixture) namespaces() ([]*corev1.Namespace, error) {
+	namespaces := []*corev1.Namespace{}
+
+	for _, file := range fixture.Namespaces {
+		objects, err := fixture.objects(file)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, object := range objects {
+			if object.GetKind() != "Namespace" {
+				return nil, fmt.Errorf("%w - expected only namespaces in [%s], found [%s]", ErrFixtureInvalid, file, object.GetKind())
+			}
+
+			namespace := &corev1.Namespace{}
+			if err := runtimeConverter.FromUnstructured(object.Object, namespace); err != nil {
+				return nil, fmt.Errorf("%w - unable to convert namespace [%s] in [%s]", err, object.GetName(), file)
+			}
+
+			namespaces = append(namespaces, namespace)
+		}
+	}
+
+	return namespaces, nil
+}
+
+// objects loads each of the objects from a manifest, which may contain multiple yaml documents.
+func (fixture *Fixture) objects(file string) ([]*unstructured.Unstructured, error) {
+	path := filepath.Join(fixture.Dir, file)
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w - unable to read manifest [%s]", err, path)
+	}
+
+	objects := []*unstructured.Unstructured{}
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(content), len(content))
+
+	for {
+		object := &unstructured.Unstructured{}
+		if err := decoder.Decode(&object.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return nil, fmt.Errorf("%w - unable to parse manifest [%s]; %s", ErrFixtureInvalid, path, err)
+		}
+
+		// skip empty documents
+		if len(object.Object) == 0 {
+			continue
+		}
+
+		objects = append(objects, object)
+	}
+
+	return objects, nil
+}
+
+// path returns the path of a file relative to the fixture.
+func (fixture *Fixture) path(file string) string {
+	return filepath.Join(fixture.Dir, file)
+}
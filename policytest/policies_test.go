@@ -0,0 +1,15 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package policytest
+
+import (
+	"testing"
+)
+
+// TestPolicies runs the policy test fixtures for the built-in validations.
+//
+//nolint:paralleltest
+func TestPolicies(t *testing.T) {
+	Run(t, "../testdata/policies")
+}
@@ -0,0 +1,356 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package policytest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/nukleros/pod-security-webhook/validate"
+	"github.com/nukleros/pod-security-webhook/webhook"
+)
+
+const defaultNamespace = "default"
+
+//nolint:gochecknoglobals
+var runtimeConverter = runtime.DefaultUnstructuredConverter
+
+// Setenv sets an environment variable for the duration of a case.
+type Setenv func(key, value string)
+
+// CaseResult is the result of running a case, with a diff for each expectation which was not met.
+type CaseResult struct {
+	Fixture string
+	Case    string
+	Diffs   []string
+	Err     error
+}
+
+// Passed returns whether the case ran and met each of its expectations.
+func (result *CaseResult) Passed() bool {
+	return result.Err == nil && len(result.Diffs) == 0
+}
+
+// Run runs each of the fixtures within a directory as a subtest, reporting each expectation which
+// was not met as an error.  Environment variables are set with t.Setenv, so the test must not be
+// run in parallel.
+func Run(t *testing.T, root string) {
+	t.Helper()
+
+	fixtures, err := Load(root)
+	if err != nil {
+		t.Fatalf("unable to load policy test fixtures: %v", err)
+	}
+
+	for _, fixture := range fixtures {
+		fixture := fixture
+
+		name, err := filepath.Rel(root, fixture.Dir)
+		if err != nil {
+			name = fixture.Dir
+		}
+
+		t.Run(name, func(t *testing.T) {
+			for _, testCase := range fixture.Cases {
+				testCase := testCase
+
+				t.Run(testCase.Name, func(t *testing.T) {
+					result := fixture.Run(testCase, t.Setenv)
+					if result.Err != nil {
+						t.Fatalf("unable to run case: %v", result.Err)
+					}
+
+					for _, diff := range result.Diffs {
+						t.Error(diff)
+					}
+				})
+			}
+		})
+	}
+}
+
+// RunAll runs each case of each fixture, restoring the environment after each case.
+func RunAll(fixtures []*Fixture) []*CaseResult {
+	results := []*CaseResult{}
+
+	for _, fixture := range fixtures {
+		for _, testCase := range fixture.Cases {
+			restores := []func(){}
+
+			setenv := func(key, value string) {
+				previous, exists := os.LookupEnv(key)
+				restores = append(restores, func() {
+					if exists {
+						_ = os.Setenv(key, previous)
+					} else {
+						_ = os.Unsetenv(key)
+					}
+				})
+
+				_ = os.Setenv(key, value)
+			}
+
+			results = append(results, fixture.Run(testCase, setenv))
+
+			for i := len(restores) - 1; i >= 0; i-- {
+				restores[i]()
+			}
+		}
+	}
+
+	return results
+}
+
+// Run runs a case of the fixture through the validation process of the webhook and compares the
+// outcome to the expectations of the case.
+func (fixture *Fixture) Run(testCase *Case, setenv Setenv) *CaseResult {
+	result := &CaseResult{Fixture: fixture.Dir, Case: testCase.Name}
+
+	response, results, err := fixture.admit(testCase, setenv)
+	if err != nil {
+		result.Err = fmt.Errorf("%w - case [%s] in fixture [%s]", err, testCase.Name, fixture.Dir)
+
+		return result
+	}
+
+	result.Diffs = diff(testCase, response, results)
+
+	return result
+}
+
+// admit admits the manifest of a case with the environment, policy configuration and namespaces
+// of the case.
+func (fixture *Fixture) admit(testCase *Case, setenv Setenv) (*admissionv1.AdmissionResponse, []*validate.Result, error) {
+	// the environment is set first, as it is read when creating the webhook as well as when
+	// running each validation
+	env := map[string]string{}
+
+	for key, value := range fixture.Env {
+		env[key] = value
+	}
+
+	for key, value := range testCase.Env {
+		env[key] = value
+	}
+
+	for _, key := range sortedKeys(env) {
+		setenv(key, env[key])
+	}
+
+	hook, err := fixture.webhook(testCase)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer hook.Shutdown()
+
+	review, err := fixture.review(testCase)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return hook.Admit(review)
+}
+
+// webhook returns a webhook without a cluster, with the policy configuration and namespaces of a
+// case.
+func (fixture *Fixture) webhook(testCase *Case) (*webhook.Webhook, error) {
+	policy := &validate.PolicyConfig{}
+
+	policyPath := fixture.Policy
+	if testCase.Policy != "" {
+		policyPath = testCase.Policy
+	}
+
+	if policyPath != "" {
+		var err error
+		if policy, err = validate.LoadPolicyConfig(fixture.path(policyPath)); err != nil {
+			return nil, err
+		}
+	}
+
+	namespaces, err := fixture.namespaces()
+	if err != nil {
+		return nil, err
+	}
+
+	return webhook.NewOfflineWebhook(policy, namespaces...)
+}
+
+// review returns the admission review for the manifest of a case.
+func (fixture *Fixture) review(testCase *Case) (*admissionv1.AdmissionReview, error) {
+	object, err := fixture.object(testCase)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := json.Marshal(object)
+	if err != nil {
+		return nil, fmt.Errorf("%w - unable to marshal manifest [%s]", err, testCase.File)
+	}
+
+	operation := testCase.Operation
+	if operation == "" {
+		operation = admissionv1.Create
+	}
+
+	namespace := testCase.Namespace
+	if namespace == "" {
+		namespace = object.GetNamespace()
+	}
+
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+
+	userInfo := authenticationv1.UserInfo{}
+	if testCase.User != nil {
+		userInfo.Username = testCase.User.Username
+		userInfo.Groups = testCase.User.Groups
+	}
+
+	gvk := object.GroupVersionKind()
+	kind := metav1.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind}
+
+	return &admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: admissionv1.SchemeGroupVersion.String(), Kind: "AdmissionReview"},
+		Request: &admissionv1.AdmissionRequest{
+			UID:         types.UID(fmt.Sprintf("%s/%s", fixture.Dir, testCase.Name)),
+			Kind:        kind,
+			RequestKind: &kind,
+			Name:        object.GetName(),
+			Namespace:   namespace,
+			Operation:   operation,
+			UserInfo:    userInfo,
+			Object:      runtime.RawExtension{Raw: raw},
+		},
+	}, nil
+}
+
+// diff returns a diff for each expectation of a case which was not met.
+func diff(testCase *Case, response *admissionv1.AdmissionResponse, results []*validate.Result) []string {
+	diffs := []string{}
+
+	if response.Allowed != testCase.Allowed {
+		message := ""
+		if response.Result != nil && response.Result.Message != "" {
+			message = fmt.Sprintf(" (%s)", response.Result.Message)
+		}
+
+		diffs = append(diffs, fmt.Sprintf("allowed = %t, want %t%s", response.Allowed, testCase.Allowed, message))
+	}
+
+	if testCase.Violations != nil {
+		diffs = append(diffs, diffNames("violation", testCase.Violations, resultNames(results, validate.ResultFail))...)
+	}
+
+	if testCase.Warnings != nil {
+		diffs = append(diffs, diffNames("warning", testCase.Warnings, resultNames(results, validate.ResultWarn))...)
+	}
+
+	validations := make([]string, 0, len(testCase.Containers))
+	for validation := range testCase.Containers {
+		validations = append(validations, validation)
+	}
+
+	sort.Strings(validations)
+
+	for _, validation := range validations {
+		diffs = append(diffs, diffNames(
+			fmt.Sprintf("failed container of validation [%s]", validation),
+			testCase.Containers[validation],
+			resultFor(results, validation).Containers,
+		)...)
+	}
+
+	for _, validation := range sortedKeys(testCase.Messages) {
+		message := resultFor(results, validation).Message
+		if !strings.Contains(message, testCase.Messages[validation]) {
+			diffs = append(diffs, fmt.Sprintf(
+				"message of validation [%s] = %q, want %q within it",
+				validation,
+				message,
+				testCase.Messages[validation],
+			))
+		}
+	}
+
+	return diffs
+}
+
+// diffNames returns a diff for each name which was expected but not found, and each name which was
+// found but not expected.
+func diffNames(kind string, want, got []string) []string {
+	diffs := []string{}
+
+	wanted := map[string]bool{}
+	for _, name := range want {
+		wanted[name] = true
+	}
+
+	found := map[string]bool{}
+	for _, name := range got {
+		found[name] = true
+	}
+
+	for _, name := range want {
+		if !found[name] {
+			diffs = append(diffs, fmt.Sprintf("missing %s [%s]", kind, name))
+		}
+	}
+
+	for _, name := range got {
+		if !wanted[name] {
+			diffs = append(diffs, fmt.Sprintf("unexpected %s [%s]", kind, name))
+		}
+	}
+
+	return diffs
+}
+
+// resultNames returns the names of the validations with results of a given status.
+func resultNames(results []*validate.Result, status validate.ResultStatus) []string {
+	names := []string{}
+
+	for _, result := range results {
+		if result.Status == status {
+			names = append(names, result.Validation)
+		}
+	}
+
+	return names
+}
+
+// resultFor returns the result of a validation, or an empty result if the validation did not run.
+func resultFor(results []*validate.Result, validation string) *validate.Result {
+	for _, result := range results {
+		if result.Validation == validation {
+			return result
+		}
+	}
+
+	return &validate.Result{Validation: validation}
+}
+
+// sortedKeys returns the keys of a map in order, so that the environment is set deterministically.
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
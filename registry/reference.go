@@ -0,0 +1,96 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// DefaultRegistry is the registry used for images which do not specify a registry.
+	DefaultRegistry = "docker.io"
+
+	defaultTag           = "latest"
+	defaultRepositoryOrg = "library"
+)
+
+var ErrReferenceInvalid = errors.New("invalid image reference")
+
+// Reference is a parsed reference to a container image.
+type Reference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// ParseReference parses a container image reference such as ghcr.io/nukleros/webhook:v1.0.0, applying
+// the same defaults as the container runtime for the registry and tag.
+func ParseReference(image string) (*Reference, error) {
+	reference := &Reference{}
+
+	remainder := image
+	if index := strings.Index(remainder, "@"); index >= 0 {
+		reference.Digest = remainder[index+1:]
+		remainder = remainder[:index]
+
+		if !strings.Contains(reference.Digest, ":") {
+			return nil, fmt.Errorf("%w [%s] - invalid digest", ErrReferenceInvalid, image)
+		}
+	}
+
+	if index := strings.LastIndex(remainder, ":"); index > strings.LastIndex(remainder, "/") {
+		reference.Tag = remainder[index+1:]
+		remainder = remainder[:index]
+
+		if reference.Tag == "" {
+			return nil, fmt.Errorf("%w [%s] - empty tag", ErrReferenceInvalid, image)
+		}
+	}
+
+	components := strings.SplitN(remainder, "/", 2)
+	if len(components) == 2 && isRegistry(components[0]) {
+		reference.Registry = components[0]
+		reference.Repository = components[1]
+	} else {
+		reference.Registry = DefaultRegistry
+		reference.Repository = remainder
+	}
+
+	if reference.Registry == DefaultRegistry && !strings.Contains(reference.Repository, "/") {
+		reference.Repository = defaultRepositoryOrg + "/" + reference.Repository
+	}
+
+	if reference.Repository == "" || strings.HasSuffix(reference.Repository, "/") {
+		return nil, fmt.Errorf("%w [%s]", ErrReferenceInvalid, image)
+	}
+
+	if reference.Tag == "" && reference.Digest == "" {
+		reference.Tag = defaultTag
+	}
+
+	return reference, nil
+}
+
+// Name returns the fully qualified name of the repository of the image.
+func (reference *Reference) Name() string {
+	return reference.Registry + "/" + reference.Repository
+}
+
+// String returns the fully qualified reference to the image.
+func (reference *Reference) String() string {
+	if reference.Digest != "" {
+		return reference.Name() + "@" + reference.Digest
+	}
+
+	return reference.Name() + ":" + reference.Tag
+}
+
+// isRegistry returns whether the first component of an image name is a registry host rather than
+// part of the repository.
+func isRegistry(component string) bool {
+	return strings.ContainsAny(component, ".:") || component == "localhost"
+}
@@ -0,0 +1,76 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package registry
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseReference(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		image   string
+		want    *Reference
+		wantErr error
+	}{
+		{
+			name:  "ensure an official image defaults the registry, organization and tag",
+			image: "nginx",
+			want:  &Reference{Registry: "docker.io", Repository: "library/nginx", Tag: "latest"},
+		},
+		{
+			name:  "ensure an image without a registry uses the default registry",
+			image: "nukleros/webhook:v1.0.0",
+			want:  &Reference{Registry: "docker.io", Repository: "nukleros/webhook", Tag: "v1.0.0"},
+		},
+		{
+			name:  "ensure an image with a registry is parsed",
+			image: "ghcr.io/nukleros/pod-security-webhook:v0.1.0",
+			want:  &Reference{Registry: "ghcr.io", Repository: "nukleros/pod-security-webhook", Tag: "v0.1.0"},
+		},
+		{
+			name:  "ensure a registry with a port is not parsed as a tag",
+			image: "localhost:5000/app",
+			want:  &Reference{Registry: "localhost:5000", Repository: "app", Tag: "latest"},
+		},
+		{
+			name:  "ensure an image with a tag and digest is parsed",
+			image: "ghcr.io/nukleros/app:v1@sha256:abc",
+			want:  &Reference{Registry: "ghcr.io", Repository: "nukleros/app", Tag: "v1", Digest: "sha256:abc"},
+		},
+		{
+			name:  "ensure an image with only a digest does not default the tag",
+			image: "ghcr.io/nukleros/app@sha256:abc",
+			want:  &Reference{Registry: "ghcr.io", Repository: "nukleros/app", Digest: "sha256:abc"},
+		},
+		{
+			name:    "ensure an empty tag is invalid",
+			image:   "ghcr.io/nukleros/app:",
+			wantErr: ErrReferenceInvalid,
+		},
+		{
+			name:    "ensure an invalid digest is invalid",
+			image:   "ghcr.io/nukleros/app@abc",
+			wantErr: ErrReferenceInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := ParseReference(tt.image)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseReference() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseReference() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,335 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// MediaTypeOCIManifest is the media type of an OCI image manifest.
+	MediaTypeOCIManifest = "application/vnd.oci.image.manifest.v1+json"
+
+	// MediaTypeDockerManifest is the media type of a docker v2 schema 2 image manifest.
+	MediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+
+	// maxContentSize is the maximum size of a manifest or blob which is read from a registry.  Only
+	// manifests and signature payloads are fetched, which are far smaller than this.
+	maxContentSize = 4 << 20
+
+	defaultRegistryHost = "registry-1.docker.io"
+	digestHeader        = "Docker-Content-Digest"
+)
+
+var (
+	ErrNotFound           = errors.New("not found in registry")
+	ErrRegistryRequest    = errors.New("unable to complete registry request")
+	ErrRegistryAuthFailed = errors.New("unable to authenticate with registry")
+)
+
+// acceptedManifestTypes are the manifest media types accepted from a registry, including indexes
+// so that the digest of a multi-architecture image may be resolved.
+var acceptedManifestTypes = []string{
+	MediaTypeOCIManifest,
+	MediaTypeDockerManifest,
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+}
+
+// Registry is the access layer to a container image registry.
+type Registry interface {
+	// Resolve returns the digest of the manifest which an image reference points to.
+	Resolve(ctx context.Context, reference *Reference) (string, error)
+
+	// Manifest returns the content of the manifest for a tag or digest in the repository of an image
+	// reference.
+	Manifest(ctx context.Context, reference *Reference, tagOrDigest string) ([]byte, error)
+
+	// Blob returns the content of a blob in the repository of an image reference.
+	Blob(ctx context.Context, reference *Reference, digest string) ([]byte, error)
+}
+
+// Manifest is an image manifest, which is only decoded as far as needed to read its layers.
+type Manifest struct {
+	MediaType string       `json:"mediaType,omitempty"`
+	Layers    []Descriptor `json:"layers"`
+}
+
+// Descriptor describes content stored in a registry.
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Digest returns the sha256 digest of content in the format used by a registry.
+func Digest(content []byte) string {
+	sum := sha256.Sum256(content)
+
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// HTTPRegistry accesses registries using the OCI distribution api, authenticating with anonymous
+// bearer tokens when challenged.
+type HTTPRegistry struct {
+	Client *http.Client
+
+	// PlainHTTP accesses registries over http rather than https.
+	PlainHTTP bool
+}
+
+// NewHTTPRegistry returns a registry access layer which uses the default http client.
+func NewHTTPRegistry() *HTTPRegistry {
+	return &HTTPRegistry{Client: http.DefaultClient}
+}
+
+// Resolve returns the digest of the manifest which an image reference points to.  A reference
+// which is already pinned to a digest is returned without calling the registry.
+func (registry *HTTPRegistry) Resolve(ctx context.Context, reference *Reference) (string, error) {
+	if reference.Digest != "" {
+		return reference.Digest, nil
+	}
+
+	response, err := registry.do(ctx, http.MethodHead, reference, "manifests/"+reference.Tag, acceptedManifestTypes)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	if digest := response.Header.Get(digestHeader); digest != "" {
+		return digest, nil
+	}
+
+	// not all registries return the digest header, in which case we compute the digest from the
+	// content of the manifest.
+	content, err := registry.Manifest(ctx, reference, reference.Tag)
+	if err != nil {
+		return "", err
+	}
+
+	return Digest(content), nil
+}
+
+// Manifest returns the content of the manifest for a tag or digest in the repository of an image
+// reference.
+func (registry *HTTPRegistry) Manifest(ctx context.Context, reference *Reference, tagOrDigest string) ([]byte, error) {
+	return registry.get(ctx, reference, "manifests/"+tagOrDigest, acceptedManifestTypes)
+}
+
+// Blob returns the content of a blob in the repository of an image reference.
+func (registry *HTTPRegistry) Blob(ctx context.Context, reference *Reference, digest string) ([]byte, error) {
+	content, err := registry.get(ctx, reference, "blobs/"+digest, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if Digest(content) != digest {
+		return nil, fmt.Errorf("%w - blob [%s] in repository [%s] does not match its digest", ErrRegistryRequest, digest, reference.Name())
+	}
+
+	return content, nil
+}
+
+// get returns the content of a path in the repository of an image reference.
+func (registry *HTTPRegistry) get(ctx context.Context, reference *Reference, path string, accept []string) ([]byte, error) {
+	response, err := registry.do(ctx, http.MethodGet, reference, path, accept)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	content, err := io.ReadAll(io.LimitReader(response.Body, maxContentSize))
+	if err != nil {
+		return nil, fmt.Errorf("%w - unable to read [%s] in repository [%s]; %s", ErrRegistryRequest, path, reference.Name(), err)
+	}
+
+	return content, nil
+}
+
+// do performs a request against a path in the repository of an image reference, retrying with a
+// bearer token if the registry responds with an authentication challenge.
+func (registry *HTTPRegistry) do(
+	ctx context.Context,
+	method string,
+	reference *Reference,
+	path string,
+	accept []string,
+) (*http.Response, error) {
+	endpoint := fmt.Sprintf("%s://%s/v2/%s/%s", registry.scheme(), registryHost(reference.Registry), reference.Repository, path)
+
+	response, err := registry.request(ctx, method, endpoint, accept, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode == http.StatusUnauthorized {
+		challenge := response.Header.Get("WWW-Authenticate")
+		response.Body.Close()
+
+		token, err := registry.token(ctx, challenge)
+		if err != nil {
+			return nil, fmt.Errorf("%w - repository [%s]", err, reference.Name())
+		}
+
+		if response, err = registry.request(ctx, method, endpoint, accept, token); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case response.StatusCode == http.StatusNotFound:
+		response.Body.Close()
+
+		return nil, fmt.Errorf("%w - [%s] in repository [%s]", ErrNotFound, path, reference.Name())
+	case response.StatusCode != http.StatusOK:
+		response.Body.Close()
+
+		return nil, fmt.Errorf(
+			"%w - [%s] in repository [%s] returned status [%d]",
+			ErrRegistryRequest,
+			path,
+			reference.Name(),
+			response.StatusCode,
+		)
+	}
+
+	return response, nil
+}
+
+// request performs a single http request.
+func (registry *HTTPRegistry) request(ctx context.Context, method, endpoint string, accept []string, token string) (*http.Response, error) {
+	request, err := http.NewRequestWithContext(ctx, method, endpoint, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("%w - unable to create request for [%s]; %s", ErrRegistryRequest, endpoint, err)
+	}
+
+	if len(accept) > 0 {
+		request.Header.Set("Accept", strings.Join(accept, ", "))
+	}
+
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	response, err := registry.client().Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("%w - [%s]; %s", ErrRegistryRequest, endpoint, err)
+	}
+
+	return response, nil
+}
+
+// token requests an anonymous bearer token from the realm in an authentication challenge.
+func (registry *HTTPRegistry) token(ctx context.Context, challenge string) (string, error) {
+	params := parseChallenge(challenge)
+	if params["realm"] == "" {
+		return "", fmt.Errorf("%w - unsupported challenge [%s]", ErrRegistryAuthFailed, challenge)
+	}
+
+	query := url.Values{}
+
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			query.Set(key, params[key])
+		}
+	}
+
+	endpoint := params["realm"]
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	response, err := registry.request(ctx, http.MethodGet, endpoint, nil, "")
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w - token request returned status [%d]", ErrRegistryAuthFailed, response.StatusCode)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+
+	if err := json.NewDecoder(io.LimitReader(response.Body, maxContentSize)).Decode(&body); err != nil {
+		return "", fmt.Errorf("%w - unable to decode token response; %s", ErrRegistryAuthFailed, err)
+	}
+
+	if body.Token != "" {
+		return body.Token, nil
+	}
+
+	return body.AccessToken, nil
+}
+
+// client returns the http client used to access registries.
+func (registry *HTTPRegistry) client() *http.Client {
+	if registry.Client == nil {
+		return http.DefaultClient
+	}
+
+	return registry.Client
+}
+
+// scheme returns the scheme used to access registries.
+func (registry *HTTPRegistry) scheme() string {
+	if registry.PlainHTTP {
+		return "http"
+	}
+
+	return "https"
+}
+
+// registryHost returns the host which serves the api for a registry.
+func registryHost(registry string) string {
+	if registry == DefaultRegistry {
+		return defaultRegistryHost
+	}
+
+	return registry
+}
+
+// parseChallenge parses the parameters of a bearer authentication challenge, for example:
+//
+//	Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/nginx:pull"
+func parseChallenge(challenge string) map[string]string {
+	params := map[string]string{}
+
+	scheme, remainder, found := strings.Cut(challenge, " ")
+	if !found || !strings.EqualFold(scheme, "bearer") {
+		return params
+	}
+
+	for remainder != "" {
+		var key, value string
+
+		key, remainder, found = strings.Cut(strings.TrimLeft(remainder, ", "), "=")
+		if !found {
+			break
+		}
+
+		if strings.HasPrefix(remainder, `"`) {
+			value, remainder, _ = strings.Cut(remainder[1:], `"`)
+		} else {
+			value, remainder, _ = strings.Cut(remainder, ",")
+		}
+
+		params[strings.ToLower(strings.TrimSpace(key))] = value
+	}
+
+	return params
+}
@@ -0,0 +1,72 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package resources
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Kind is a kind of resource which holds a pod specification and is validated by the webhook.
+type Kind struct {
+	schema.GroupVersionKind
+
+	// Resource is the plural resource name of the kind, as used in webhook rules and RBAC.
+	Resource string
+
+	// Owners are the kinds which manage resources of this kind.  Resources owned by one of them
+	// are validated via their owner rather than individually.
+	Owners []string
+}
+
+// Kinds returns each of the kinds which are validated by the webhook.  This is the source for the
+// webhook configuration rules and the permissions of the webhook, so each kind must also be
+// supported by GetPodSpec.
+func Kinds() []Kind {
+	return []Kind{
+		{
+			GroupVersionKind: corev1.SchemeGroupVersion.WithKind("Pod"),
+			Resource:         "pods",
+			Owners:           []string{"ReplicaSet", "Deployment", "DaemonSet", "StatefulSet", "CronJob", "Job"},
+		},
+		{
+			GroupVersionKind: appsv1.SchemeGroupVersion.WithKind("Deployment"),
+			Resource:         "deployments",
+		},
+		{
+			GroupVersionKind: appsv1.SchemeGroupVersion.WithKind("ReplicaSet"),
+			Resource:         "replicasets",
+			Owners:           []string{"Deployment"},
+		},
+		{
+			GroupVersionKind: appsv1.SchemeGroupVersion.WithKind("StatefulSet"),
+			Resource:         "statefulsets",
+		},
+		{
+			GroupVersionKind: appsv1.SchemeGroupVersion.WithKind("DaemonSet"),
+			Resource:         "daemonsets",
+		},
+		{
+			GroupVersionKind: batchv1.SchemeGroupVersion.WithKind("CronJob"),
+			Resource:         "cronjobs",
+		},
+		{
+			GroupVersionKind: batchv1.SchemeGroupVersion.WithKind("Job"),
+			Resource:         "jobs",
+		},
+	}
+}
+
+// KindFor returns the kind which is validated by the webhook given its name.
+func KindFor(name string) (Kind, bool) {
+	for _, kind := range Kinds() {
+		if kind.Kind == name {
+			return kind, true
+		}
+	}
+
+	return Kind{}, false
+}
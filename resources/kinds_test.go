@@ -0,0 +1,95 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package resources
+
+import (
+	"errors"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestKinds(t *testing.T) {
+	t.Parallel()
+
+	for _, kind := range Kinds() {
+		kind := kind
+
+		t.Run("ensure the pod spec of each kind can be retrieved for "+kind.Kind, func(t *testing.T) {
+			t.Parallel()
+
+			object := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			object.SetGroupVersionKind(kind.GroupVersionKind)
+
+			if _, err := GetPodSpec(object); errors.Is(err, ErrValidatingKind) {
+				t.Errorf("GetPodSpec() does not support kind [%s]", kind.Kind)
+			}
+
+			if GroupVersionKindFor(kind.Kind) != kind.GroupVersionKind {
+				t.Errorf("GroupVersionKindFor(%s) = %s, want %s", kind.Kind, GroupVersionKindFor(kind.Kind), kind.GroupVersionKind)
+			}
+		})
+	}
+}
+
+func TestSkipViaOwnerReferences(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		kind  string
+		owner string
+		want  bool
+	}{
+		{
+			name:  "ensure a pod owned by a replica set is skipped",
+			kind:  "Pod",
+			owner: "ReplicaSet",
+			want:  true,
+		},
+		{
+			name:  "ensure a replica set owned by a deployment is skipped",
+			kind:  "ReplicaSet",
+			owner: "Deployment",
+			want:  true,
+		},
+		{
+			name: "ensure a replica set without an owner is not skipped",
+			kind: "ReplicaSet",
+			want: false,
+		},
+		{
+			name:  "ensure a pod owned by an unknown kind is not skipped",
+			kind:  "Pod",
+			owner: "Unknown",
+			want:  false,
+		},
+		{
+			name:  "ensure a deployment is never skipped",
+			kind:  "Deployment",
+			owner: "Deployment",
+			want:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			object := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			object.SetGroupVersionKind(GroupVersionKindFor(tt.kind))
+
+			if tt.owner != "" {
+				object.SetOwnerReferences([]metav1.OwnerReference{{Kind: tt.owner, Name: "owner"}})
+			}
+
+			if got := SkipViaOwnerReferences(object); got != tt.want {
+				t.Errorf("SkipViaOwnerReferences() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
@@ -12,6 +12,9 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -40,6 +43,13 @@ func GetPodSpec(resource client.Object) (*corev1.PodSpec, error) {
 		}
 
 		return &deployment.Spec.Template.Spec, nil
+	case "ReplicaSet":
+		replicaSet := &appsv1.ReplicaSet{}
+		if err := resources.ToTyped(replicaSet, resource); err != nil {
+			return nil, fmt.Errorf("%w - unable to convert replica set to typed object", err)
+		}
+
+		return &replicaSet.Spec.Template.Spec, nil
 	case "StatefulSet":
 		statefulSet := &appsv1.StatefulSet{}
 		if err := resources.ToTyped(statefulSet, resource); err != nil {
@@ -73,6 +83,50 @@ func GetPodSpec(resource client.Object) (*corev1.PodSpec, error) {
 	}
 }
 
+// GetPodAnnotations returns the annotations of the pod for a given set of objects, which for a
+// workload is the metadata of its pod template.
+func GetPodAnnotations(resource client.Object) map[string]string {
+	if resource.GetObjectKind().GroupVersionKind().Kind == "Pod" {
+		return resource.GetAnnotations()
+	}
+
+	object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(resource)
+	if err != nil {
+		return nil
+	}
+
+	annotations, _, err := unstructured.NestedStringMap(object, append(GetPodTemplatePath(resource), "metadata", "annotations")...)
+	if err != nil {
+		return nil
+	}
+
+	return annotations
+}
+
+// GetPodTemplatePath returns the path to the pod template for a given set of objects, which holds
+// the metadata and spec of the pod.  The path for a pod is empty as the pod is its own template.
+func GetPodTemplatePath(resource client.Object) []string {
+	switch resource.GetObjectKind().GroupVersionKind().Kind {
+	case "Pod":
+		return []string{}
+	case "CronJob":
+		return []string{"spec", "jobTemplate", "spec", "template"}
+	default:
+		return []string{"spec", "template"}
+	}
+}
+
+// GroupVersionKindFor returns the group version kind for a supported kind.  This is useful
+// for typed objects retrieved from the kubernetes client, which do not have their type
+// metadata set.
+func GroupVersionKindFor(kind string) schema.GroupVersionKind {
+	if supported, ok := KindFor(kind); ok {
+		return supported.GroupVersionKind
+	}
+
+	return corev1.SchemeGroupVersion.WithKind(kind)
+}
+
 // GetSecurityContext returns the security context for a container.
 //nolint:gocritic
 // TODO: pass container as pointer.  this has implications when passing in a loop
@@ -126,6 +180,36 @@ func EffectiveRunAsNonRoot(podSec *corev1.PodSecurityContext, containerSec *core
 	return false
 }
 
+// EffectiveSELinuxOptions determines the effective selinux options of a container.
+func EffectiveSELinuxOptions(podSec *corev1.PodSecurityContext, containerSec *corev1.SecurityContext) *corev1.SELinuxOptions {
+	if containerSec != nil && containerSec.SELinuxOptions != nil {
+		return containerSec.SELinuxOptions
+	}
+
+	if podSec != nil {
+		return podSec.SELinuxOptions
+	}
+
+	return nil
+}
+
+// EffectiveProcMount determines the effective proc mount type of a container.  The proc mount type
+// may only be set on a container and defaults to the default proc mount type.
+func EffectiveProcMount(containerSec *corev1.SecurityContext) corev1.ProcMountType {
+	if containerSec != nil && containerSec.ProcMount != nil {
+		return *containerSec.ProcMount
+	}
+
+	return corev1.DefaultProcMount
+}
+
+// EffectiveAppArmorProfile determines the effective apparmor profile of a container from the pod
+// annotations.  An empty profile is returned if no profile is set, in which case the runtime
+// default profile is used.
+func EffectiveAppArmorProfile(podAnnotations map[string]string, containerName string) string {
+	return podAnnotations[corev1.AppArmorBetaContainerAnnotationKeyPrefix+containerName]
+}
+
 // EffectiveRunAsUser determines the effective run as user id.
 func EffectiveRunAsUser(podSec *corev1.PodSecurityContext, containerSec *corev1.SecurityContext) *int64 {
 	if containerSec != nil && containerSec.RunAsUser != nil {
@@ -139,6 +223,51 @@ func EffectiveRunAsUser(podSec *corev1.PodSecurityContext, containerSec *corev1.
 	return nil
 }
 
+// EffectiveWindowsOptions determines the effective windows options of a container.  Each option set
+// on the container takes precedence over the same option set on the pod.
+func EffectiveWindowsOptions(podSec *corev1.PodSecurityContext, containerSec *corev1.SecurityContext) corev1.WindowsSecurityContextOptions {
+	options := corev1.WindowsSecurityContextOptions{}
+
+	if podSec != nil && podSec.WindowsOptions != nil {
+		options = *podSec.WindowsOptions
+	}
+
+	if containerSec == nil || containerSec.WindowsOptions == nil {
+		return options
+	}
+
+	if containerSec.WindowsOptions.GMSACredentialSpecName != nil {
+		options.GMSACredentialSpecName = containerSec.WindowsOptions.GMSACredentialSpecName
+	}
+
+	if containerSec.WindowsOptions.GMSACredentialSpec != nil {
+		options.GMSACredentialSpec = containerSec.WindowsOptions.GMSACredentialSpec
+	}
+
+	if containerSec.WindowsOptions.RunAsUserName != nil {
+		options.RunAsUserName = containerSec.WindowsOptions.RunAsUserName
+	}
+
+	if containerSec.WindowsOptions.HostProcess != nil {
+		options.HostProcess = containerSec.WindowsOptions.HostProcess
+	}
+
+	return options
+}
+
+// EffectiveRunAsGroup determines the effective run as group id.
+func EffectiveRunAsGroup(podSec *corev1.PodSecurityContext, containerSec *corev1.SecurityContext) *int64 {
+	if containerSec != nil && containerSec.RunAsGroup != nil {
+		return containerSec.RunAsGroup
+	}
+
+	if podSec != nil {
+		return podSec.RunAsGroup
+	}
+
+	return nil
+}
+
 // ToString converts an object to a string which is useful while producing consistent logs.  This is safe to
 // return via an admission review object, as sometimes certain characters can cause the response to the
 // kube-apiserver to fail.
@@ -171,8 +300,9 @@ func SkipViaAnnotations(resource client.Object, overrideKey string) bool {
 // SkipViaOwnerReferences determines if a resource needs to be skipped due to the owner
 // references that it possesses.
 func SkipViaOwnerReferences(resource client.Object) bool {
-	// if we are not working with a pod we cannot skip
-	if resource.GetObjectKind().GroupVersionKind().Kind != "Pod" {
+	// if we are not working with a kind which is managed by another kind we cannot skip
+	kind, ok := KindFor(resource.GetObjectKind().GroupVersionKind().Kind)
+	if !ok || len(kind.Owners) == 0 {
 		return false
 	}
 
@@ -181,17 +311,10 @@ func SkipViaOwnerReferences(resource client.Object) bool {
 		return false
 	}
 
-	// if this is a pod and it is owned by one of the other controllers we are already validating, we
-	// do not need to valiate this pod again
+	// if this is owned by one of the other controllers we are already validating, we do not need
+	// to valiate this again
 	for _, ownerRef := range resource.GetOwnerReferences() {
-		for _, validOwner := range []string{
-			"ReplicaSet",
-			"Deployment",
-			"DaemonSet",
-			"StatefulSet",
-			"CronJob",
-			"Job",
-		} {
+		for _, validOwner := range kind.Owners {
 			if ownerRef.Kind == validOwner {
 				return true
 			}
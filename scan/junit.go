@@ -0,0 +1,125 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package scan
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/nukleros/pod-security-webhook/validate"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// writeJUnit writes the findings as a JUnit XML report, with a test suite for each workload and a
+// test case for each validation.  Warnings are reported as passed test cases with the warning as
+// output.
+func writeJUnit(w io.Writer, findings []*Finding) error {
+	report := junitTestSuites{Name: toolName}
+	suites := map[*Manifest]int{}
+
+	for _, finding := range findings {
+		index, ok := suites[finding.Manifest]
+		if !ok {
+			index = len(report.Suites)
+			suites[finding.Manifest] = index
+
+			report.Suites = append(report.Suites, junitTestSuite{
+				Name: fmt.Sprintf("%s:%d %s", finding.Manifest.File, finding.Manifest.Line, finding.Resource()),
+			})
+		}
+
+		suite := &report.Suites[index]
+		testCase := junitTestCase{Name: finding.Result.Validation, ClassName: finding.Resource()}
+
+		switch finding.Result.Status {
+		case validate.ResultFail:
+			testCase.Failure = &junitFailure{
+				Message: finding.Result.Message,
+				Type:    validate.MetadataFor(finding.Result.Validation).Severity,
+				Text:    junitDetails(finding),
+			}
+
+			suite.Failures++
+		case validate.ResultSkip:
+			testCase.Skipped = &junitSkipped{Message: fmt.Sprintf("%s: %s", finding.Result.SkipReason, finding.Result.Message)}
+
+			suite.Skipped++
+		case validate.ResultWarn:
+			testCase.SystemOut = "warning: " + junitDetails(finding)
+		case validate.ResultPass:
+		}
+
+		suite.Tests++
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	for i := range report.Suites {
+		report.Tests += report.Suites[i].Tests
+		report.Failures += report.Suites[i].Failures
+		report.Skipped += report.Suites[i].Skipped
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("%w - unable to write junit report", err)
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+
+	if err := encoder.Encode(report); err != nil {
+		return fmt.Errorf("%w - unable to write junit report", err)
+	}
+
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("%w - unable to write junit report", err)
+	}
+
+	return nil
+}
+
+// junitDetails returns the message, location and remediation of a finding.
+func junitDetails(finding *Finding) string {
+	details := fmt.Sprintf("%s\nlocation: %s:%d", finding.Result.Message, finding.Manifest.File, finding.Line())
+
+	if remediation := validate.MetadataFor(finding.Result.Validation).Remediation; remediation != "" {
+		details = fmt.Sprintf("%s\nremediation: %s", details, remediation)
+	}
+
+	return details
+}
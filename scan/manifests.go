@@ -0,0 +1,232 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package scan
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/nukleros/pod-security-webhook/resources"
+)
+
+var ErrManifestInvalid = errors.New("invalid manifest")
+
+// Manifest is a single object from a manifest file, along with its location in the file so that
+// results may be mapped back to the source.
+type Manifest struct {
+	File   string
+	Line   int
+	Object *unstructured.Unstructured
+
+	// node is the parsed yaml of the object, which holds the line of each of its fields.
+	node *yaml.Node
+}
+
+// Load loads each of the objects from a set of manifest files.  Directories are searched for
+// files with a .yaml, .yml or .json extension.  Documents which are not kubernetes objects, such as
+// those without a kind, are ignored.
+func Load(paths ...string) ([]*Manifest, error) {
+	manifests := []*Manifest{}
+
+	for _, path := range paths {
+		files, err := manifestFiles(path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			loaded, err := loadFile(file)
+			if err != nil {
+				return nil, err
+			}
+
+			manifests = append(manifests, loaded...)
+		}
+	}
+
+	return manifests, nil
+}
+
+// manifestFiles returns the manifest files for a path, which is either a file or a directory.
+func manifestFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w - unable to read manifests [%s]", err, path)
+	}
+
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	files := []string{}
+
+	err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		switch filepath.Ext(file) {
+		case ".yaml", ".yml", ".json":
+			if !entry.IsDir() {
+				files = append(files, file)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w - unable to find manifests in [%s]", err, path)
+	}
+
+	return files, nil
+}
+
+// loadFile loads each of the objects from a manifest file, which may contain multiple yaml
+// documents.
+func loadFile(file string) ([]*Manifest, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("%w - unable to read manifest [%s]", err, file)
+	}
+
+	manifests := []*Manifest{}
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+
+	for {
+		document := &yaml.Node{}
+		if err := decoder.Decode(document); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return nil, fmt.Errorf("%w - unable to parse manifest [%s]; %s", ErrManifestInvalid, file, err)
+		}
+
+		// skip empty documents and documents which are not objects
+		if len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+			continue
+		}
+
+		node := document.Content[0]
+
+		if find(node, "kind") == nil || find(node, "apiVersion") == nil {
+			continue
+		}
+
+		object, err := toUnstructured(node)
+		if err != nil {
+			return nil, fmt.Errorf("%w - unable to convert object at line [%d] of [%s]", err, node.Line, file)
+		}
+
+		manifests = append(manifests, &Manifest{File: file, Line: node.Line, Object: object, node: node})
+	}
+
+	return manifests, nil
+}
+
+// toUnstructured converts a parsed yaml object to an unstructured object.  The object is converted
+// by way of json so that numbers are converted to the types expected by unstructured objects.
+func toUnstructured(node *yaml.Node) (*unstructured.Unstructured, error) {
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return nil, fmt.Errorf("%w - %s", ErrManifestInvalid, err)
+	}
+
+	content, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w - %s", ErrManifestInvalid, err)
+	}
+
+	object := &unstructured.Unstructured{}
+	if err := object.UnmarshalJSON(content); err != nil {
+		return nil, fmt.Errorf("%w - %s", ErrManifestInvalid, err)
+	}
+
+	return object, nil
+}
+
+// LineOf returns the line of a field of the object given its path, where items of a list are
+// given by their index.  The line of the object is returned if the field is not found.
+func (manifest *Manifest) LineOf(path ...string) int {
+	if key, _ := lookup(manifest.node, path...); key != nil {
+		return key.Line
+	}
+
+	return manifest.Line
+}
+
+// ContainerLine returns the line of a container of the object given its name.  The line of the
+// pod spec, or of the object, is returned if the container is not found.
+func (manifest *Manifest) ContainerLine(name string) int {
+	specPath := append(resources.GetPodTemplatePath(manifest.Object), "spec")
+
+	for _, field := range []string{"initContainers", "containers", "ephemeralContainers"} {
+		containers := find(manifest.node, append(specPath[:len(specPath):len(specPath)], field)...)
+		if containers == nil || containers.Kind != yaml.SequenceNode {
+			continue
+		}
+
+		for _, container := range containers.Content {
+			if containerName := find(container, "name"); containerName != nil && containerName.Value == name {
+				return container.Line
+			}
+		}
+	}
+
+	return manifest.LineOf(specPath...)
+}
+
+// find returns the node of a field given its path, or nil if the field is not found.
+func find(node *yaml.Node, path ...string) *yaml.Node {
+	_, value := lookup(node, path...)
+
+	return value
+}
+
+// lookup returns the node of the key and the node of the value of a field given its path, or nil
+// if the field is not found.  The key of an item of a list is the item itself.
+func lookup(node *yaml.Node, path ...string) (key, value *yaml.Node) {
+	key, value = node, node
+
+	for _, field := range path {
+		if value == nil {
+			return nil, nil
+		}
+
+		switch value.Kind {
+		case yaml.MappingNode:
+			parent := value
+			key, value = nil, nil
+
+			for i := 0; i+1 < len(parent.Content); i += 2 {
+				if parent.Content[i].Value == field {
+					key, value = parent.Content[i], parent.Content[i+1]
+
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			index, err := strconv.Atoi(field)
+			if err != nil || index < 0 || index >= len(value.Content) {
+				return nil, nil
+			}
+
+			key, value = value.Content[index], value.Content[index]
+		default:
+			return nil, nil
+		}
+	}
+
+	return key, value
+}
@@ -0,0 +1,108 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package scan
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/nukleros/pod-security-webhook/validate"
+)
+
+// Format is the format in which findings are reported.
+type Format string
+
+const (
+	FormatText  Format = "text"
+	FormatSARIF Format = "sarif"
+	FormatJUnit Format = "junit"
+)
+
+var ErrFormatInvalid = errors.New("invalid report format")
+
+// ParseFormat parses a report format from a string.
+func ParseFormat(format string) (Format, error) {
+	switch parsed := Format(strings.ToLower(format)); parsed {
+	case FormatText, FormatSARIF, FormatJUnit:
+		return parsed, nil
+	default:
+		return "", fmt.Errorf("%w - [%s] must be one of [%s, %s, %s]", ErrFormatInvalid, format, FormatText, FormatSARIF, FormatJUnit)
+	}
+}
+
+// Write writes a report of the findings in the given format.
+func Write(w io.Writer, format Format, findings []*Finding) error {
+	switch format {
+	case FormatSARIF:
+		return writeSARIF(w, findings)
+	case FormatJUnit:
+		return writeJUnit(w, findings)
+	case FormatText:
+		return writeText(w, findings)
+	default:
+		return fmt.Errorf("%w - [%s]", ErrFormatInvalid, format)
+	}
+}
+
+// Failed returns whether any of the findings failed validation.  Findings in warn mode are not
+// considered failures.
+func Failed(findings []*Finding) bool {
+	for _, finding := range findings {
+		if finding.Result.Status == validate.ResultFail {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writeText writes each failure and warning along with its location and remediation, followed
+// by a summary of the findings.
+func writeText(w io.Writer, findings []*Finding) error {
+	summary := map[validate.ResultStatus]int{}
+	manifests := map[*Manifest]bool{}
+
+	for _, finding := range findings {
+		summary[finding.Result.Status]++
+		manifests[finding.Manifest] = true
+
+		if finding.Result.Status != validate.ResultFail && finding.Result.Status != validate.ResultWarn {
+			continue
+		}
+
+		if _, err := fmt.Fprintf(
+			w,
+			"%s:%d: %s [%s] %s\n",
+			finding.Manifest.File,
+			finding.Line(),
+			strings.ToUpper(string(finding.Result.Status)),
+			finding.Result.Validation,
+			finding.Result.Message,
+		); err != nil {
+			return fmt.Errorf("%w - unable to write report", err)
+		}
+
+		if remediation := validate.MetadataFor(finding.Result.Validation).Remediation; remediation != "" {
+			if _, err := fmt.Fprintf(w, "    remediation: %s\n", remediation); err != nil {
+				return fmt.Errorf("%w - unable to write report", err)
+			}
+		}
+	}
+
+	if _, err := fmt.Fprintf(
+		w,
+		"\n%d workloads scanned: %d failed, %d warnings, %d passed, %d skipped\n",
+		len(manifests),
+		summary[validate.ResultFail],
+		summary[validate.ResultWarn],
+		summary[validate.ResultPass],
+		summary[validate.ResultSkip],
+	); err != nil {
+		return fmt.Errorf("%w - unable to write report", err)
+	}
+
+	return nil
+}
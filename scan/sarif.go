@@ -0,0 +1,203 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package scan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/nukleros/pod-security-webhook/validate"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	toolName           = "pod-security-webhook"
+	toolInformationURI = "https://github.com/nukleros/pod-security-webhook"
+)
+
+// sarifSeverities maps the severity of a validation to a security severity score, which is used by
+// GitHub code scanning to rank results.
+//
+//nolint:gochecknoglobals
+var sarifSeverities = map[string]string{
+	validate.SeverityCritical: "9.5",
+	validate.SeverityHigh:     "8.0",
+	validate.SeverityMedium:   "5.5",
+	validate.SeverityLow:      "3.0",
+	validate.SeverityInfo:     "0.0",
+}
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string                 `json:"id"`
+	Name                 string                 `json:"name"`
+	ShortDescription     sarifMessage           `json:"shortDescription"`
+	Help                 sarifMessage           `json:"help"`
+	DefaultConfiguration sarifConfiguration     `json:"defaultConfiguration"`
+	Properties           map[string]interface{} `json:"properties"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// writeSARIF writes the failures and warnings as a SARIF 2.1.0 log, with a rule for each
+// validation.  Passed and skipped validations are not reported as results.
+func writeSARIF(w io.Writer, findings []*Finding) error {
+	rules := []sarifRule{}
+	ruleIndexes := map[string]int{}
+	results := []sarifResult{}
+
+	for _, finding := range findings {
+		name := finding.Result.Validation
+
+		if _, ok := ruleIndexes[name]; !ok {
+			ruleIndexes[name] = len(rules)
+			rules = append(rules, newSARIFRule(name))
+		}
+
+		var level string
+
+		switch finding.Result.Status {
+		case validate.ResultFail:
+			level = "error"
+		case validate.ResultWarn:
+			level = "warning"
+		default:
+			continue
+		}
+
+		results = append(results, sarifResult{
+			RuleID:    name,
+			RuleIndex: ruleIndexes[name],
+			Level:     level,
+			Message:   sarifMessage{Text: finding.Result.Message},
+			Locations: []sarifLocation{
+				{
+					PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(finding.Manifest.File)},
+						Region:           sarifRegion{StartLine: finding.Line()},
+					},
+					LogicalLocations: []sarifLogicalLocation{
+						{FullyQualifiedName: finding.Resource(), Kind: "resource"},
+					},
+				},
+			},
+		})
+	}
+
+	log := sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs: []sarifRun{
+			{
+				Tool: sarifTool{
+					Driver: sarifDriver{
+						Name:           toolName,
+						InformationURI: toolInformationURI,
+						Rules:          rules,
+					},
+				},
+				Results: results,
+			},
+		},
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(log); err != nil {
+		return fmt.Errorf("%w - unable to write sarif report", err)
+	}
+
+	return nil
+}
+
+// newSARIFRule returns the rule for a validation given its name.
+func newSARIFRule(name string) sarifRule {
+	metadata := validate.MetadataFor(name)
+
+	description := metadata.Description
+	if description == "" {
+		description = "Validation " + name
+	}
+
+	remediation := metadata.Remediation
+	if remediation == "" {
+		remediation = description
+	}
+
+	tags := append([]string{"security", metadata.Category}, metadata.Tags...)
+
+	return sarifRule{
+		ID:                   name,
+		Name:                 name,
+		ShortDescription:     sarifMessage{Text: description},
+		Help:                 sarifMessage{Text: remediation},
+		DefaultConfiguration: sarifConfiguration{Level: "error"},
+		Properties: map[string]interface{}{
+			"tags":              tags,
+			"security-severity": sarifSeverities[metadata.Severity],
+		},
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package scan
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/nukleros/pod-security-webhook/resources"
+	"github.com/nukleros/pod-security-webhook/validate"
+	"github.com/nukleros/pod-security-webhook/webhook"
+)
+
+// Finding is the result of a validation against a manifest.
+type Finding struct {
+	Manifest *Manifest
+	Result   *validate.Result
+}
+
+// Line returns the line of the manifest which the finding applies to, which is the first
+// container which failed the validation if the failure applies to individual containers.
+func (finding *Finding) Line() int {
+	if len(finding.Result.Containers) > 0 {
+		return finding.Manifest.ContainerLine(finding.Result.Containers[0])
+	}
+
+	return finding.Manifest.LineOf(append(resources.GetPodTemplatePath(finding.Manifest.Object), "spec")...)
+}
+
+// Resource returns a description of the object of the finding.
+func (finding *Finding) Resource() string {
+	return strings.ToLower(resources.ToString(finding.Manifest.Object))
+}
+
+// Scan runs all validations against each workload within a set of manifests, exactly as an audit
+// of the cluster would, and returns the findings.  Namespaces within the manifests are available
+// to the validations, for example to set per-namespace annotations.  Objects which are not
+// workloads are ignored.
+func Scan(policy *validate.PolicyConfig, manifests []*Manifest) ([]*Finding, error) {
+	namespaces, err := Namespaces(manifests)
+	if err != nil {
+		return nil, err
+	}
+
+	hook, err := webhook.NewOfflineWebhook(policy, namespaces...)
+	if err != nil {
+		return nil, fmt.Errorf("%w - unable to create webhook for scan", err)
+	}
+
+	findings := []*Finding{}
+
+	for _, manifest := range manifests {
+		results, err := hook.Evaluate(manifest.Object)
+		if err != nil {
+			if errors.Is(err, resources.ErrValidatingKind) {
+				continue
+			}
+
+			return nil, fmt.Errorf("%w - unable to scan object at line [%d] of [%s]", err, manifest.Line, manifest.File)
+		}
+
+		for _, result := range results {
+			findings = append(findings, &Finding{Manifest: manifest, Result: result})
+		}
+	}
+
+	return findings, nil
+}
+
+// Namespaces returns each of the namespaces within a set of manifests.
+func Namespaces(manifests []*Manifest) ([]*corev1.Namespace, error) {
+	namespaces := []*corev1.Namespace{}
+
+	for _, manifest := range manifests {
+		if manifest.Object.GetKind() != "Namespace" {
+			continue
+		}
+
+		namespace := &corev1.Namespace{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(manifest.Object.Object, namespace); err != nil {
+			return nil, fmt.Errorf("%w - unable to convert namespace at line [%d] of [%s]", err, manifest.Line, manifest.File)
+		}
+
+		namespaces = append(namespaces, namespace)
+	}
+
+	return namespaces, nil
+}
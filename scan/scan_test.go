@@ -0,0 +1,281 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package scan
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nukleros/pod-security-webhook/validate"
+)
+
+const testManifests = `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+    - port: 80
+---
+# a document which is not an object is ignored
+values:
+  - one
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: apps
+spec:
+  template:
+    spec:
+      securityContext:
+        runAsNonRoot: true
+        runAsUser: 1234
+      containers:
+        - name: valid
+          image: nginx
+          securityContext:
+            allowPrivilegeEscalation: false
+            capabilities:
+              drop:
+                - ALL
+        - name: privileged
+          image: nginx
+          securityContext:
+            privileged: true
+            allowPrivilegeEscalation: false
+            capabilities:
+              drop:
+                - ALL
+`
+
+func writeManifests(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "manifests.yaml")
+	if err := os.WriteFile(path, []byte(testManifests), 0o600); err != nil {
+		t.Fatalf("unable to write manifests: %v", err)
+	}
+
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	manifests, err := Load(filepath.Dir(writeManifests(t)))
+	if err != nil {
+		t.Fatalf("unable to load manifests: %v", err)
+	}
+
+	if len(manifests) != 2 {
+		t.Fatalf("Load() returned [%d] manifests, want 2", len(manifests))
+	}
+
+	deployment := manifests[1]
+
+	tests := []struct {
+		name string
+		got  int
+		want int
+	}{
+		{
+			name: "ensure the line of the object is the start of its document",
+			got:  deployment.Line,
+			want: 13,
+		},
+		{
+			name: "ensure the line of a container is found by its name",
+			got:  deployment.ContainerLine("privileged"),
+			want: 32,
+		},
+		{
+			name: "ensure the line of the pod spec is returned for a missing container",
+			got:  deployment.ContainerLine("missing"),
+			want: 20,
+		},
+		{
+			name: "ensure the line of a field is found by its path",
+			got:  deployment.LineOf("spec", "template", "spec", "containers", "0", "image"),
+			want: 26,
+		},
+		{
+			name: "ensure the line of the object is returned for a missing field",
+			got:  deployment.LineOf("spec", "replicas"),
+			want: 13,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if tt.got != tt.want {
+				t.Errorf("line = %d, want %d", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	path := writeManifests(t)
+
+	manifests, err := Load(path)
+	if err != nil {
+		t.Fatalf("unable to load manifests: %v", err)
+	}
+
+	findings, err := Scan(&validate.PolicyConfig{}, manifests)
+	if err != nil {
+		t.Fatalf("unable to scan manifests: %v", err)
+	}
+
+	if !Failed(findings) {
+		t.Fatalf("Failed() = false, want true")
+	}
+
+	t.Run("ensure sarif results are reported with the rule and location of each failure", func(t *testing.T) {
+		t.Parallel()
+
+		output := &bytes.Buffer{}
+		if err := Write(output, FormatSARIF, findings); err != nil {
+			t.Fatalf("unable to write report: %v", err)
+		}
+
+		log := &sarifLog{}
+		if err := json.Unmarshal(output.Bytes(), log); err != nil {
+			t.Fatalf("unable to parse report: %v", err)
+		}
+
+		if log.Version != sarifVersion || len(log.Runs) != 1 {
+			t.Fatalf("report has version [%s] with [%d] runs, want version [%s] with 1 run", log.Version, len(log.Runs), sarifVersion)
+		}
+
+		run := log.Runs[0]
+		if len(run.Results) != 1 {
+			t.Fatalf("report has [%d] results, want 1", len(run.Results))
+		}
+
+		result := run.Results[0]
+		if result.RuleID != validate.PrivilegedValidationName || run.Tool.Driver.Rules[result.RuleIndex].ID != result.RuleID {
+			t.Errorf("result has rule [%s] at index [%d], want rule [%s]", result.RuleID, result.RuleIndex, validate.PrivilegedValidationName)
+		}
+
+		location := result.Locations[0].PhysicalLocation
+		if location.ArtifactLocation.URI != filepath.ToSlash(path) || location.Region.StartLine != 32 {
+			t.Errorf("result has location [%s:%d], want [%s:32]", location.ArtifactLocation.URI, location.Region.StartLine, path)
+		}
+
+		if help := run.Tool.Driver.Rules[result.RuleIndex].Help.Text; help != validate.MetadataFor(result.RuleID).Remediation {
+			t.Errorf("rule has help [%s], want the remediation of the validation", help)
+		}
+	})
+
+	t.Run("ensure junit test cases are reported for each validation with a failure for each failed validation", func(t *testing.T) {
+		t.Parallel()
+
+		output := &bytes.Buffer{}
+		if err := Write(output, FormatJUnit, findings); err != nil {
+			t.Fatalf("unable to write report: %v", err)
+		}
+
+		report := &junitTestSuites{}
+		if err := xml.Unmarshal(output.Bytes(), report); err != nil {
+			t.Fatalf("unable to parse report: %v", err)
+		}
+
+		if len(report.Suites) != 1 || report.Tests != len(findings) || report.Failures != 1 {
+			t.Fatalf(
+				"report has [%d] suites with [%d] tests and [%d] failures, want 1 suite with [%d] tests and 1 failure",
+				len(report.Suites), report.Tests, report.Failures, len(findings),
+			)
+		}
+
+		for _, testCase := range report.Suites[0].TestCases {
+			if (testCase.Failure != nil) != (testCase.Name == validate.PrivilegedValidationName) {
+				t.Errorf("test case [%s] has failure [%v]", testCase.Name, testCase.Failure)
+			}
+		}
+	})
+}
+
+// TestScanImageMirrors ensures that images are scanned as they would be admitted, after they have
+// been rewritten to their mirrors.
+//
+//nolint:paralleltest
+func TestScanImageMirrors(t *testing.T) {
+	t.Setenv(validate.ImageRegistryEnv, "mirror.internal/")
+	t.Setenv(validate.ImageRegistriesEnv, "")
+
+	manifests, err := Load(writeManifests(t))
+	if err != nil {
+		t.Fatalf("unable to load manifests: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		mirrors []*validate.ImageMirror
+		want    validate.ResultStatus
+	}{
+		{
+			name:    "ensure images rewritten to a trusted mirror pass the trusted registry validation",
+			mirrors: []*validate.ImageMirror{{Sources: []string{"docker.io"}, Mirror: "mirror.internal/docker.io"}},
+			want:    validate.ResultPass,
+		},
+		{
+			name:    "ensure images without a mirror fail the trusted registry validation",
+			mirrors: nil,
+			want:    validate.ResultFail,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			findings, err := Scan(&validate.PolicyConfig{ImageMirrors: tt.mirrors}, manifests)
+			if err != nil {
+				t.Fatalf("unable to scan manifests: %v", err)
+			}
+
+			found := false
+
+			for _, finding := range findings {
+				if finding.Result.Validation != validate.ImageRegistryValidationName {
+					continue
+				}
+
+				found = true
+
+				if finding.Result.Status != tt.want {
+					t.Errorf("finding has status [%s], want [%s] (%s)", finding.Result.Status, tt.want, finding.Result.Message)
+				}
+			}
+
+			if !found {
+				t.Errorf("no finding for validation [%s]", validate.ImageRegistryValidationName)
+			}
+		})
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	t.Parallel()
+
+	if format, err := ParseFormat("SARIF"); err != nil || format != FormatSARIF {
+		t.Errorf("ParseFormat(SARIF) = %s, %v, want %s", format, err, FormatSARIF)
+	}
+
+	if _, err := ParseFormat("html"); err == nil {
+		t.Errorf("ParseFormat(html) returned no error")
+	}
+}
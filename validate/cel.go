@@ -0,0 +1,214 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	apiservercel "k8s.io/apiextensions-apiserver/pkg/apiserver/schema/cel"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/schema/cel/library"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// CELObjectVariable is the variable name of the object under validation in a custom
+	// validation expression.
+	CELObjectVariable = "object"
+
+	// CELPodSpecVariable is the variable name of the pod specification extracted from the object
+	// under validation in a custom validation expression.
+	CELPodSpecVariable = "podSpec"
+
+	// DefaultCELCostLimit is the default runtime cost limit of a custom validation expression.  This
+	// matches the per-call limit of validation rules in custom resource definitions.
+	DefaultCELCostLimit = 1000000
+
+	// DefaultCELEstimatedCostLimit is the default limit of the estimated worst case cost of a custom
+	// validation expression, which is checked when the expression is compiled.  This matches the
+	// static limit of validation rules in custom resource definitions.
+	DefaultCELEstimatedCostLimit = 10000000
+
+	// celInterruptCheckFrequency is the number of iterations within a comprehension to evaluate
+	// before checking whether the evaluation has been interrupted.
+	celInterruptCheckFrequency = 100
+)
+
+var (
+	ErrCustomValidationCompile = errors.New("unable to compile custom validation")
+	ErrCustomValidationEval    = errors.New("unable to evaluate custom validation")
+	ErrCustomValidationFailed  = errors.New("unable to permit pod failing custom validation")
+
+	//nolint:gochecknoglobals
+	celEnvOnce sync.Once
+	//nolint:gochecknoglobals
+	celEnv *cel.Env
+	//nolint:gochecknoglobals
+	celEnvTypes *celTypes
+	//nolint:gochecknoglobals
+	celEnvErr error
+)
+
+// CustomValidation is a validation defined in the policy configuration as a CEL expression.  The
+// expression is evaluated against the object under validation and its pod specification, and
+// must return true for the object to pass validation.  For example, the following expression
+// requires each container to set a memory limit:
+//
+//	podSpec.containers.all(c, has(c.resources.limits) && 'memory' in c.resources.limits)
+//
+// The pod specification is typed by the kubernetes api, while only the apiVersion, kind and
+// metadata of the object are available, as the remaining fields of the object depend on its kind.
+type CustomValidation struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+	Message    string `json:"message,omitempty"`
+	CostLimit  uint64 `json:"costLimit,omitempty"`
+
+	// EstimatedCostLimit is the limit of the estimated worst case cost of the expression, which
+	// assumes lists and maps of the largest size permitted by the api server.
+	EstimatedCostLimit uint64 `json:"estimatedCostLimit,omitempty"`
+
+	program cel.Program
+	types   *celTypes
+}
+
+// celEnvironment returns the shared CEL environment for custom validations, which includes the
+// kubernetes CEL extension libraries and the types of the variables.
+func celEnvironment() (*cel.Env, *celTypes, error) {
+	celEnvOnce.Do(func() {
+		base, err := cel.NewEnv(append([]cel.EnvOption{cel.HomogeneousAggregateLiterals()}, library.ExtensionLibs...)...)
+		if err != nil {
+			celEnvErr = err
+
+			return
+		}
+
+		if celEnvTypes, celEnvErr = newCELTypes(base); celEnvErr != nil {
+			return
+		}
+
+		celEnv, celEnvErr = base.Extend(celEnvTypes.options...)
+	})
+
+	return celEnv, celEnvTypes, celEnvErr
+}
+
+// Compile parses and type-checks the expression of the custom validation and prepares it for
+// evaluation.  The expression must return a boolean, and its estimated worst case cost must be
+// within its estimated cost limit.
+func (custom *CustomValidation) Compile() error {
+	if custom.Expression == "" {
+		return fmt.Errorf("%w [%s] - missing expression", ErrCustomValidationCompile, custom.Name)
+	}
+
+	env, types, err := celEnvironment()
+	if err != nil {
+		return fmt.Errorf("%w [%s] - unable to create cel environment; %s", ErrCustomValidationCompile, custom.Name, err)
+	}
+
+	ast, issues := env.Compile(custom.Expression)
+	if issues != nil && issues.Err() != nil {
+		return fmt.Errorf("%w [%s] - %s", ErrCustomValidationCompile, custom.Name, issues.Err())
+	}
+
+	if ast.OutputType() != cel.BoolType {
+		return fmt.Errorf(
+			"%w [%s] - expression must return a bool but returns [%s]",
+			ErrCustomValidationCompile,
+			custom.Name,
+			ast.OutputType(),
+		)
+	}
+
+	costLimit := custom.CostLimit
+	if costLimit == 0 {
+		costLimit = DefaultCELCostLimit
+	}
+
+	estimatedCostLimit := custom.EstimatedCostLimit
+	if estimatedCostLimit == 0 {
+		estimatedCostLimit = DefaultCELEstimatedCostLimit
+	}
+
+	estimator := &library.CostEstimator{SizeEstimator: types}
+
+	cost, err := env.EstimateCost(ast, estimator)
+	if err != nil {
+		return fmt.Errorf("%w [%s] - unable to estimate cost; %s", ErrCustomValidationCompile, custom.Name, err)
+	}
+
+	if cost.Max > estimatedCostLimit {
+		return fmt.Errorf(
+			"%w [%s] - estimated cost [%d] exceeds estimated cost limit [%d]",
+			ErrCustomValidationCompile,
+			custom.Name,
+			cost.Max,
+			estimatedCostLimit,
+		)
+	}
+
+	program, err := env.Program(
+		ast,
+		cel.EvalOptions(cel.OptOptimize),
+		cel.CostTracking(estimator),
+		cel.CostLimit(costLimit),
+		cel.InterruptCheckFrequency(celInterruptCheckFrequency),
+	)
+	if err != nil {
+		return fmt.Errorf("%w [%s] - %s", ErrCustomValidationCompile, custom.Name, err)
+	}
+
+	custom.types = types
+
+	custom.program = program
+
+	return nil
+}
+
+// Validation returns a new validation which runs the custom validation.
+func (custom *CustomValidation) Validation() *Validation {
+	return NewValidation(custom.Name, custom.Run)
+}
+
+// Run evaluates the custom validation expression against the resource and pod specification of a
+// validation.  An expression which fails to evaluate, for example by exceeding its cost limit, fails
+// the validation.
+func (custom *CustomValidation) Run(validation *Validation) (bool, error) {
+	if custom.program == nil {
+		return validation.Failed(fmt.Errorf("%w - expression has not been compiled", ErrCustomValidationEval))
+	}
+
+	object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(validation.Resource)
+	if err != nil {
+		return validation.Failed(fmt.Errorf("%w - unable to convert object; %s", ErrCustomValidationEval, err))
+	}
+
+	podSpec := map[string]interface{}{}
+	if validation.PodSpec != nil {
+		if podSpec, err = runtime.DefaultUnstructuredConverter.ToUnstructured(validation.PodSpec); err != nil {
+			return validation.Failed(fmt.Errorf("%w - unable to convert pod spec; %s", ErrCustomValidationEval, err))
+		}
+	}
+
+	value, _, err := custom.program.Eval(map[string]interface{}{
+		CELObjectVariable:  apiservercel.UnstructuredToVal(object, custom.types.schemas[CELObjectVariable]),
+		CELPodSpecVariable: apiservercel.UnstructuredToVal(podSpec, custom.types.schemas[CELPodSpecVariable]),
+	})
+	if err != nil {
+		return validation.Failed(fmt.Errorf("%w - %s", ErrCustomValidationEval, err))
+	}
+
+	if passed, ok := value.Value().(bool); ok && passed {
+		return true, nil
+	}
+
+	message := custom.Message
+	if message == "" {
+		message = fmt.Sprintf("expression [%s] evaluated to false", custom.Expression)
+	}
+
+	return validation.Failed(fmt.Errorf("%w - %s", ErrCustomValidationFailed, message))
+}
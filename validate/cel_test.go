@@ -0,0 +1,301 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package validate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	memoryLimitsExpression = "podSpec.containers.all(c, has(c.resources.limits) && 'memory' in c.resources.limits)"
+	teamLabelExpression    = "has(object.metadata.labels) && 'team' in object.metadata.labels"
+)
+
+func TestCustomValidationCompile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name               string
+		expression         string
+		estimatedCostLimit uint64
+		wantErr            bool
+	}{
+		{
+			name:       "ensure a boolean expression compiles",
+			expression: memoryLimitsExpression,
+			wantErr:    false,
+		},
+		{
+			name:       "ensure an expression using the kubernetes libraries compiles",
+			expression: "object.metadata.name.matches('^[a-z]+$')",
+			wantErr:    false,
+		},
+		{
+			name:       "ensure an empty expression fails to compile",
+			expression: "",
+			wantErr:    true,
+		},
+		{
+			name:       "ensure an invalid expression fails to compile",
+			expression: "podSpec.containers.all(c,",
+			wantErr:    true,
+		},
+		{
+			name:       "ensure an expression with an unknown variable fails to compile",
+			expression: "spec.hostPID == false",
+			wantErr:    true,
+		},
+		{
+			name:       "ensure a non-boolean expression fails to compile",
+			expression: "size(podSpec.containers)",
+			wantErr:    true,
+		},
+		{
+			name:       "ensure an expression with a misspelled pod spec field fails to compile",
+			expression: "podSpec.contianers.all(c, has(c.resources.limits))",
+			wantErr:    true,
+		},
+		{
+			name:       "ensure an expression with a pod spec field of the wrong type fails to compile",
+			expression: "podSpec.hostPID == 'true'",
+			wantErr:    true,
+		},
+		{
+			name:       "ensure an expression with an object field outside of its metadata fails to compile",
+			expression: "object.spec.replicas > 1",
+			wantErr:    true,
+		},
+		{
+			name:               "ensure an expression exceeding its estimated cost limit fails to compile",
+			expression:         memoryLimitsExpression,
+			estimatedCostLimit: 1,
+			wantErr:            true,
+		},
+		{
+			name: "ensure an expression exceeding the default estimated cost limit fails to compile",
+			expression: "podSpec.containers.all(c, podSpec.containers.all(d, " +
+				"c.env.all(e, d.env.all(f, e.name.matches(f.name)))))",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			custom := &CustomValidation{Name: "custom", Expression: tt.expression, EstimatedCostLimit: tt.estimatedCostLimit}
+			if err := custom.Compile(); (err != nil) != tt.wantErr {
+				t.Errorf("CustomValidation.Compile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCustomValidationRun(t *testing.T) {
+	t.Parallel()
+
+	limitedPodSpec := validPodSpec()
+	for i := range limitedPodSpec.Containers {
+		limitedPodSpec.Containers[i].Resources.Limits = corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("128Mi"),
+		}
+	}
+
+	tests := []struct {
+		name       string
+		expression string
+		costLimit  uint64
+		labels     map[string]string
+		podSpec    *corev1.PodSpec
+		want       bool
+		wantErr    bool
+	}{
+		{
+			name:       "ensure a pod spec with memory limits passes validation",
+			expression: memoryLimitsExpression,
+			podSpec:    limitedPodSpec,
+			want:       true,
+			wantErr:    false,
+		},
+		{
+			name:       "ensure a pod spec without memory limits fails validation",
+			expression: memoryLimitsExpression,
+			podSpec:    validPodSpec(),
+			want:       false,
+			wantErr:    true,
+		},
+		{
+			name:       "ensure an object with a team label passes validation",
+			expression: teamLabelExpression,
+			labels:     map[string]string{"team": "platform"},
+			podSpec:    validPodSpec(),
+			want:       true,
+			wantErr:    false,
+		},
+		{
+			name:       "ensure an object without a team label fails validation",
+			expression: teamLabelExpression,
+			podSpec:    validPodSpec(),
+			want:       false,
+			wantErr:    true,
+		},
+		{
+			name:       "ensure an expression which errors fails validation",
+			expression: "object.metadata.labels.team == 'platform'",
+			podSpec:    validPodSpec(),
+			want:       false,
+			wantErr:    true,
+		},
+		{
+			name:       "ensure an expression exceeding its cost limit fails validation",
+			expression: memoryLimitsExpression,
+			costLimit:  1,
+			podSpec:    limitedPodSpec,
+			want:       false,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			custom := &CustomValidation{Name: "custom", Expression: tt.expression, CostLimit: tt.costLimit}
+			if err := custom.Compile(); err != nil {
+				t.Fatalf("CustomValidation.Compile() error = %v", err)
+			}
+			validation := custom.Validation()
+			validation.Resource = &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "custom", Labels: tt.labels},
+				Spec:       *tt.podSpec,
+			}
+			validation.PodSpec = tt.podSpec
+			got, err := validation.Execute()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CustomValidation.Run() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+			if got != tt.want {
+				t.Errorf("CustomValidation.Run() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadPolicyConfig(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		want    int
+		wantErr bool
+	}{
+		{
+			name: "ensure a valid policy configuration loads",
+			content: `customValidations:
+- name: require-memory-limits
+  expression: "` + memoryLimitsExpression + `"
+  message: containers must set memory limits
+- name: require-team-label
+  expression: "` + teamLabelExpression + `"
+`,
+			want:    2,
+			wantErr: false,
+		},
+		{
+			name: "ensure a custom validation with an invalid name fails to load",
+			content: `customValidations:
+- name: Require_Memory_Limits
+  expression: "` + memoryLimitsExpression + `"
+`,
+			wantErr: true,
+		},
+		{
+			name: "ensure a custom validation with a built-in name fails to load",
+			content: `customValidations:
+- name: ` + HostPIDValidationName + `
+  expression: "` + memoryLimitsExpression + `"
+`,
+			wantErr: true,
+		},
+		{
+			name: "ensure a custom validation with an invalid expression fails to load",
+			content: `customValidations:
+- name: require-memory-limits
+  expression: "podSpec.containers["
+`,
+			wantErr: true,
+		},
+		{
+			name: "ensure a custom validation with a misspelled field fails to load",
+			content: `customValidations:
+- name: require-memory-limits
+  expression: "podSpec.contianers.all(c, true)"
+`,
+			wantErr: true,
+		},
+		{
+			name: "ensure resource requirements with a minimum above the maximum fail to load",
+			content: `resourceRequirements:
+  memory:
+    min: 1Gi
+    max: 512Mi
+`,
+			wantErr: true,
+		},
+		{
+			name: "ensure an image signature policy with an invalid public key fails to load",
+			content: `imageSignatures:
+  policies:
+  - registry: ghcr.io/nukleros/
+    publicKeys:
+    - |
+      -----BEGIN PUBLIC KEY-----
+      bm90IGEga2V5
+      -----END PUBLIC KEY-----
+`,
+			wantErr: true,
+		},
+		{
+			name: "ensure trusted registries with an invalid mode fail to load",
+			content: `trustedRegistries:
+  mode: Merge
+`,
+			wantErr: true,
+		},
+		{
+			name:    "ensure an unknown field fails to load",
+			content: "customValidation: []\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			path := filepath.Join(t.TempDir(), "policy.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("unable to write policy configuration: %v", err)
+			}
+			got, err := LoadPolicyConfig(path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadPolicyConfig() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+			if got != nil && len(got.CustomValidations) != tt.want {
+				t.Errorf("LoadPolicyConfig() custom validations = %v, want %v", len(got.CustomValidations), tt.want)
+			}
+		})
+	}
+}
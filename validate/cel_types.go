@@ -0,0 +1,227 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
+	"k8s.io/apiextensions-apiserver/third_party/forked/celopenapi/model"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// celRootTypeName is the name of the type which holds the variables of a custom validation, so that
+// the types of each variable are named by the path to them, such as customValidation.podSpec.
+const celRootTypeName = "customValidation"
+
+// celTypes are the types of the variables of a custom validation, which are derived from the go
+// types of the kubernetes api so that expressions are type-checked against the fields of the api.
+type celTypes struct {
+	root    *model.DeclType
+	schemas map[string]*schema.Structural
+	options []cel.EnvOption
+}
+
+// newCELTypes returns the types of the variables of a custom validation.  The object exposes its
+// type and object metadata, as its remaining fields depend on its kind, while the pod
+// specification exposes each of its fields.
+func newCELTypes(base *cel.Env) (*celTypes, error) {
+	objectSchema := &schema.Structural{
+		Generic: schema.Generic{Type: "object"},
+		Properties: map[string]schema.Structural{
+			"apiVersion": {Generic: schema.Generic{Type: "string"}},
+			"kind":       {Generic: schema.Generic{Type: "string"}},
+			"metadata":   *structuralSchema(reflect.TypeOf(metav1.ObjectMeta{}), map[reflect.Type]bool{}),
+		},
+	}
+
+	types := &celTypes{
+		schemas: map[string]*schema.Structural{
+			CELObjectVariable:  objectSchema,
+			CELPodSpecVariable: structuralSchema(reflect.TypeOf(corev1.PodSpec{}), map[reflect.Type]bool{}),
+		},
+	}
+
+	rootSchema := &schema.Structural{Generic: schema.Generic{Type: "object"}, Properties: map[string]schema.Structural{}}
+	for name, variableSchema := range types.schemas {
+		rootSchema.Properties[name] = *variableSchema
+	}
+
+	ruleTypes, err := model.NewRuleTypes(celRootTypeName, model.SchemaDeclType(rootSchema, false), model.NewRegistry(base))
+	if err != nil {
+		return nil, fmt.Errorf("%w - unable to create types of custom validation variables", err)
+	}
+
+	root, ok := ruleTypes.FindDeclType(celRootTypeName)
+	if !ok {
+		return nil, fmt.Errorf("unable to find type [%s] of custom validation variables", celRootTypeName)
+	}
+
+	if types.options, err = ruleTypes.EnvOptions(base.TypeProvider()); err != nil {
+		return nil, fmt.Errorf("%w - unable to create types of custom validation variables", err)
+	}
+
+	for name := range types.schemas {
+		types.options = append(types.options, cel.Variable(name, root.Fields[name].Type.CelType()))
+	}
+
+	types.root = root
+
+	return types, nil
+}
+
+// EstimateSize estimates the maximum size of the lists, maps and strings within the variables of a
+// custom validation from their types, so that the cost of an expression may be estimated.
+func (types *celTypes) EstimateSize(element checker.AstNode) *checker.SizeEstimate {
+	path := element.Path()
+	if len(path) == 0 {
+		return nil
+	}
+
+	node := types.root
+
+	for _, name := range path {
+		switch name {
+		case "@items", "@values":
+			node = node.ElemType
+		case "@keys":
+			node = node.KeyType
+		default:
+			field, ok := node.Fields[name]
+			if !ok {
+				return nil
+			}
+
+			node = field.Type
+		}
+
+		if node == nil {
+			return nil
+		}
+	}
+
+	return &checker.SizeEstimate{Min: 0, Max: uint64(node.MaxElements)}
+}
+
+// EstimateCallCost returns no estimate, so that the estimate of the kubernetes libraries is used.
+func (types *celTypes) EstimateCallCost(function, overloadID string, target *checker.AstNode, args []checker.AstNode) *checker.CallEstimate {
+	return nil
+}
+
+// structuralSchema returns the structural schema of a kubernetes api type, as it is serialized to
+// json.  Types which are already being visited, which only occur in recursive types, preserve their
+// fields without exposing them.
+//
+//nolint:exhaustive
+func structuralSchema(goType reflect.Type, visiting map[reflect.Type]bool) *schema.Structural {
+	switch goType {
+	case reflect.TypeOf(resource.Quantity{}), reflect.TypeOf(intstr.IntOrString{}):
+		return &schema.Structural{Extensions: schema.Extensions{XIntOrString: true}}
+	case reflect.TypeOf(metav1.Time{}), reflect.TypeOf(metav1.MicroTime{}):
+		return &schema.Structural{
+			Generic:         schema.Generic{Type: "string", Nullable: true},
+			ValueValidation: &schema.ValueValidation{Format: "date-time"},
+		}
+	}
+
+	switch goType.Kind() {
+	case reflect.Ptr:
+		elem := structuralSchema(goType.Elem(), visiting)
+		elem.Nullable = true
+
+		return elem
+	case reflect.String:
+		return &schema.Structural{Generic: schema.Generic{Type: "string"}}
+	case reflect.Bool:
+		return &schema.Structural{Generic: schema.Generic{Type: "boolean"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &schema.Structural{Generic: schema.Generic{Type: "integer"}}
+	case reflect.Float32, reflect.Float64:
+		return &schema.Structural{Generic: schema.Generic{Type: "number"}}
+	case reflect.Slice:
+		if goType.Elem().Kind() == reflect.Uint8 {
+			return &schema.Structural{
+				Generic:         schema.Generic{Type: "string"},
+				ValueValidation: &schema.ValueValidation{Format: "byte"},
+			}
+		}
+
+		return &schema.Structural{Generic: schema.Generic{Type: "array"}, Items: structuralSchema(goType.Elem(), visiting)}
+	case reflect.Map:
+		return &schema.Structural{
+			Generic: schema.Generic{
+				Type:                 "object",
+				AdditionalProperties: &schema.StructuralOrBool{Structural: structuralSchema(goType.Elem(), visiting), Bool: true},
+			},
+		}
+	case reflect.Struct:
+		if visiting[goType] {
+			return &schema.Structural{Generic: schema.Generic{Type: "object"}, Extensions: schema.Extensions{XPreserveUnknownFields: true}}
+		}
+
+		visiting[goType] = true
+		defer delete(visiting, goType)
+
+		structural := &schema.Structural{Generic: schema.Generic{Type: "object"}, Properties: map[string]schema.Structural{}}
+		addStructFields(structural, goType, visiting)
+
+		return structural
+	default:
+		return &schema.Structural{Generic: schema.Generic{Type: "object"}, Extensions: schema.Extensions{XPreserveUnknownFields: true}}
+	}
+}
+
+// addStructFields adds the properties of the exported fields of a struct to a structural schema,
+// using the name of each field in its json tag.  Fields which are serialized without omitempty are
+// required, which bounds the estimated size of the lists which contain them.
+func addStructFields(structural *schema.Structural, goType reflect.Type, visiting map[reflect.Type]bool) {
+	for i := 0; i < goType.NumField(); i++ {
+		field := goType.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		tag := strings.Split(field.Tag.Get("json"), ",")
+		if tag[0] == "-" {
+			continue
+		}
+
+		inline, omitEmpty := field.Anonymous && tag[0] == "", false
+
+		for _, option := range tag[1:] {
+			inline = inline || option == "inline"
+			omitEmpty = omitEmpty || option == "omitempty"
+		}
+
+		if inline {
+			addStructFields(structural, field.Type, visiting)
+
+			continue
+		}
+
+		name := tag[0]
+		if name == "" {
+			name = field.Name
+		}
+
+		property := structuralSchema(field.Type, visiting)
+		structural.Properties[name] = *property
+
+		if !omitEmpty && !property.Nullable {
+			if structural.ValueValidation == nil {
+				structural.ValueValidation = &schema.ValueValidation{}
+			}
+
+			structural.ValueValidation.Required = append(structural.ValueValidation.Required, name)
+		}
+	}
+}
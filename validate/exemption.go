@@ -0,0 +1,150 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/nukleros/pod-security-webhook/resources"
+)
+
+const (
+	ExemptionAnnotationPrefix = "exemption.pod-security-webhook.nukleros.io"
+	ExemptionGracePeriodEnv   = "EXEMPTION_GRACE_PERIOD"
+
+	defaultExemptionGracePeriod = 7 * 24 * time.Hour
+)
+
+var (
+	ErrExemptionInvalid         = errors.New("invalid exemption")
+	ErrExemptionMissingField    = errors.New("exemption missing required field")
+	ErrExemptionInvalidDuration = errors.New("invalid exemption grace period")
+)
+
+// ExemptionStatus represents the state of an exemption at a given point in time.
+type ExemptionStatus string
+
+const (
+	// ExemptionActive indicates the exemption has not yet expired and the validation should
+	// be skipped.
+	ExemptionActive ExemptionStatus = "active"
+
+	// ExemptionGrace indicates the exemption has expired but is still within the grace period
+	// and the validation should be run in warn mode.
+	ExemptionGrace ExemptionStatus = "grace"
+
+	// ExemptionExpired indicates the exemption has expired beyond the grace period and the
+	// validation should be enforced.
+	ExemptionExpired ExemptionStatus = "expired"
+)
+
+// Exemption is a time-bound exemption from a validation.  It is stored as the JSON value
+// of an annotation on the resource, for example:
+//
+//	exemption.pod-security-webhook.nukleros.io/host-network: |
+//	  {"justification": "cni installer", "ticket": "SEC-123", "approver": "security-team", "expires": "2023-01-01T00:00:00Z"}
+type Exemption struct {
+	Validation    string    `json:"validation,omitempty"`
+	Justification string    `json:"justification"`
+	Ticket        string    `json:"ticket"`
+	Approver      string    `json:"approver"`
+	Expires       time.Time `json:"expires"`
+}
+
+// ExemptionAnnotation returns the expected exemption annotation given the name of the
+// validation.
+func (validation *Validation) ExemptionAnnotation() string {
+	return ExemptionAnnotationFor(validation.Name)
+}
+
+// ExemptionAnnotationFor returns the exemption annotation for a validation name.
+func ExemptionAnnotationFor(name string) string {
+	return fmt.Sprintf("%s/%s", ExemptionAnnotationPrefix, name)
+}
+
+// GetExemption returns the exemption for a validation from a resource.  A nil exemption
+// is returned if the resource has no exemption for the validation.
+func GetExemption(resource client.Object, name string) (*Exemption, error) {
+	value := resources.GetAnnotation(resource, ExemptionAnnotationFor(name))
+	if value == "" {
+		return nil, nil
+	}
+
+	return ParseExemption(name, value)
+}
+
+// ParseExemption parses the value of an exemption annotation and ensures that all
+// required fields are set.
+func ParseExemption(name, value string) (*Exemption, error) {
+	exemption := &Exemption{}
+	if err := json.Unmarshal([]byte(value), exemption); err != nil {
+		return nil, fmt.Errorf("%w for validation %s - %s", ErrExemptionInvalid, name, err)
+	}
+
+	exemption.Validation = name
+
+	for _, field := range []struct {
+		name  string
+		value string
+	}{
+		{name: "justification", value: exemption.Justification},
+		{name: "ticket", value: exemption.Ticket},
+		{name: "approver", value: exemption.Approver},
+	} {
+		if field.value == "" {
+			return nil, fmt.Errorf("%w [%s] for validation %s", ErrExemptionMissingField, field.name, name)
+		}
+	}
+
+	if exemption.Expires.IsZero() {
+		return nil, fmt.Errorf("%w [expires] for validation %s", ErrExemptionMissingField, name)
+	}
+
+	return exemption, nil
+}
+
+// Status returns the status of the exemption at a point in time given a grace period.
+func (exemption *Exemption) Status(now time.Time, gracePeriod time.Duration) ExemptionStatus {
+	if now.Before(exemption.Expires) {
+		return ExemptionActive
+	}
+
+	if now.Before(exemption.Expires.Add(gracePeriod)) {
+		return ExemptionGrace
+	}
+
+	return ExemptionExpired
+}
+
+// String returns a string representation of the exemption which is useful for logging.
+func (exemption *Exemption) String() string {
+	return fmt.Sprintf(
+		"ticket=%s approver=%s expires=%s",
+		exemption.Ticket,
+		exemption.Approver,
+		exemption.Expires.Format(time.RFC3339),
+	)
+}
+
+// ExemptionGracePeriod returns the grace period after an exemption expires in which the
+// validation runs in warn mode prior to being enforced.
+func ExemptionGracePeriod() (time.Duration, error) {
+	gracePeriod := os.Getenv(ExemptionGracePeriodEnv)
+	if gracePeriod == "" {
+		return defaultExemptionGracePeriod, nil
+	}
+
+	duration, err := time.ParseDuration(gracePeriod)
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("%w - [%s=%s]", ErrExemptionInvalidDuration, ExemptionGracePeriodEnv, gracePeriod)
+	}
+
+	return duration, nil
+}
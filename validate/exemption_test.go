@@ -0,0 +1,129 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package validate
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const validExemption = `{"justification": "cni", "ticket": "SEC-1", "approver": "security", "expires": "2022-06-01T00:00:00Z"}`
+
+func TestGetExemption(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantNil     bool
+		wantErr     bool
+	}{
+		{
+			name:        "ensure a resource without an exemption returns no exemption",
+			annotations: map[string]string{},
+			wantNil:     true,
+			wantErr:     false,
+		},
+		{
+			name: "ensure a resource with a valid exemption returns the exemption",
+			annotations: map[string]string{
+				ExemptionAnnotationFor(HostNetworkValidationName): validExemption,
+			},
+			wantNil: false,
+			wantErr: false,
+		},
+		{
+			name: "ensure a resource with an exemption for another validation returns no exemption",
+			annotations: map[string]string{
+				ExemptionAnnotationFor(HostPIDValidationName): validExemption,
+			},
+			wantNil: true,
+			wantErr: false,
+		},
+		{
+			name: "ensure a resource with an invalid exemption returns an error",
+			annotations: map[string]string{
+				ExemptionAnnotationFor(HostNetworkValidationName): "true",
+			},
+			wantNil: true,
+			wantErr: true,
+		},
+		{
+			name: "ensure a resource with an exemption missing a justification returns an error",
+			annotations: map[string]string{
+				ExemptionAnnotationFor(HostNetworkValidationName): `{"ticket": "SEC-1", "approver": "security", "expires": "2022-06-01T00:00:00Z"}`,
+			},
+			wantNil: true,
+			wantErr: true,
+		},
+		{
+			name: "ensure a resource with an exemption missing an expiry returns an error",
+			annotations: map[string]string{
+				ExemptionAnnotationFor(HostNetworkValidationName): `{"justification": "cni", "ticket": "SEC-1", "approver": "security"}`,
+			},
+			wantNil: true,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: tt.annotations}}
+			got, err := GetExemption(pod, HostNetworkValidationName)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetExemption() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+			if (got == nil) != tt.wantNil {
+				t.Errorf("GetExemption() = %v, wantNil %v", got, tt.wantNil)
+			}
+		})
+	}
+}
+
+func TestExemptionStatus(t *testing.T) {
+	t.Parallel()
+
+	expires := time.Date(2022, time.June, 1, 0, 0, 0, 0, time.UTC)
+	gracePeriod := 24 * time.Hour
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want ExemptionStatus
+	}{
+		{
+			name: "ensure an exemption prior to expiry is active",
+			now:  expires.Add(-time.Minute),
+			want: ExemptionActive,
+		},
+		{
+			name: "ensure an exemption after expiry and within the grace period is in grace",
+			now:  expires.Add(time.Minute),
+			want: ExemptionGrace,
+		},
+		{
+			name: "ensure an exemption after expiry and after the grace period is expired",
+			now:  expires.Add(gracePeriod).Add(time.Minute),
+			want: ExemptionExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			exemption := &Exemption{Expires: expires}
+			if got := exemption.Status(tt.now, gracePeriod); got != tt.want {
+				t.Errorf("Exemption.Status() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package validate
+
+import (
+	"fmt"
+	"os"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/nukleros/pod-security-webhook/resources"
+)
+
+// ValidationSource is where a validation is defined.
+type ValidationSource string
+
+const (
+	ValidationSourceBuiltin ValidationSource = "builtin"
+	ValidationSourceCustom  ValidationSource = "custom"
+	ValidationSourcePlugin  ValidationSource = "plugin"
+)
+
+// ControlType is the type of setting which controls a validation.
+type ControlType string
+
+const (
+	ControlTypeEnv                 ControlType = "env"
+	ControlTypeAnnotation          ControlType = "annotation"
+	ControlTypeNamespaceAnnotation ControlType = "namespace-annotation"
+	ControlTypePolicy              ControlType = "policy"
+)
+
+// Control is a setting which changes the outcome of a validation, along with the value which
+// causes the change and its current value, if any.
+type Control struct {
+	Type    ControlType `json:"type"`
+	Key     string      `json:"key"`
+	Value   string      `json:"value"`
+	Current string      `json:"current,omitempty"`
+	Effect  string      `json:"effect"`
+}
+
+// Explanation explains the outcome of a validation for a resource: whether it was enabled, why it
+// was skipped or downgraded, its result, how to remediate a failure and the settings which change
+// the outcome.
+type Explanation struct {
+	Validation  string            `json:"validation"`
+	Source      ValidationSource  `json:"source"`
+	Enabled     bool              `json:"enabled"`
+	Action      EnforcementAction `json:"action,omitempty"`
+	Status      ResultStatus      `json:"status"`
+	SkipReason  SkipReason        `json:"skipReason,omitempty"`
+	Message     string            `json:"message,omitempty"`
+	ExemptedBy  string            `json:"exemptedBy,omitempty"`
+	Remediation string            `json:"remediation,omitempty"`
+	Controls    []Control         `json:"controls"`
+}
+
+// Explain explains the result of the validation against a resource.  The validation is expected
+// to have been registered for the resource, whether or not it was skipped.
+func (validation *Validation) Explain(result *Result, resource client.Object, policy *PolicyConfig) *Explanation {
+	envOverride := validation.EnvironmetVariableOverride()
+	enabled := result.SkipReason != SkipReasonEnv
+
+	explanation := &Explanation{
+		Validation: validation.Name,
+		Source:     policy.SourceOf(validation.Name),
+		Enabled:    enabled,
+		Status:     result.Status,
+		SkipReason: result.SkipReason,
+		Message:    result.Message,
+		ExemptedBy: result.ExemptedBy,
+	}
+
+	validationMetadata := MetadataFor(validation.Name)
+	if result.Status == ResultFail || result.Status == ResultWarn {
+		explanation.Remediation = validationMetadata.Remediation
+	}
+
+	if enabled {
+		explanation.Action = validation.Action
+	}
+
+	env := func(key, value, effect string) {
+		explanation.Controls = append(explanation.Controls, Control{
+			Type: ControlTypeEnv, Key: key, Value: value, Current: os.Getenv(key), Effect: effect,
+		})
+	}
+
+	annotation := func(key, value, effect string) {
+		explanation.Controls = append(explanation.Controls, Control{
+			Type: ControlTypeAnnotation, Key: key, Value: value, Current: resources.GetAnnotation(resource, key), Effect: effect,
+		})
+	}
+
+	env(envOverride, SkipValidationEnvValue, "disables the validation for all resources")
+	env(envOverride, WarnValidationEnvValue, "permits resources which fail the validation with a warning")
+
+	annotation(validation.AnnotationOverride(), "<reason>", "skips the validation for this resource")
+	annotation(
+		ExemptionAnnotationFor(validation.Name),
+		`{"justification": "<justification>", "ticket": "<ticket>", "approver": "<approver>", "expires": "<RFC3339 time>"}`,
+		"skips the validation for this resource until the exemption expires",
+	)
+
+	env(validation.environmentVariable(exemptUsersEnvSuffix), "<user>,...", "skips the validation for requests from the listed users")
+	env(validation.environmentVariable(exemptGroupsEnvSuffix), "<group>,...", "skips the validation for requests from the listed groups")
+	env(
+		validation.environmentVariable(exemptServiceAccountsEnvSuffix),
+		"<namespace>:<name>,...",
+		"skips the validation for requests from the listed service accounts",
+	)
+	env(
+		validation.environmentVariable(exemptActionEnvSuffix),
+		WarnValidationEnvValue,
+		"downgrades the validation to warn mode, rather than skipping it, for exempt requests",
+	)
+
+	switch explanation.Source {
+	case ValidationSourceCustom:
+		explanation.Controls = append(explanation.Controls, Control{
+			Type:   ControlTypePolicy,
+			Key:    fmt.Sprintf("customValidations[name=%s].expression", validation.Name),
+			Value:  "<expression>",
+			Effect: "changes the expression which resources must satisfy",
+		})
+	case ValidationSourcePlugin:
+		explanation.Controls = append(explanation.Controls, Control{
+			Type:   ControlTypePolicy,
+			Key:    fmt.Sprintf("plugins[name=%s].failurePolicy", validation.Name),
+			Value:  string(PluginFailurePolicyIgnore),
+			Effect: "permits resources when the plugin can not be called",
+		})
+	case ValidationSourceBuiltin:
+		for _, control := range validationMetadata.Controls {
+			switch control.Type {
+			case ControlTypeEnv:
+				control.Current = os.Getenv(control.Key)
+			case ControlTypeAnnotation:
+				control.Current = resources.GetAnnotation(resource, control.Key)
+			case ControlTypeNamespaceAnnotation:
+				if validation.Namespace != nil {
+					control.Current = validation.Namespace.GetAnnotations()[control.Key]
+				}
+			case ControlTypePolicy:
+			}
+
+			explanation.Controls = append(explanation.Controls, control)
+		}
+	}
+
+	return explanation
+}
+
+// SourceOf returns where a validation is defined given its name.
+func (config *PolicyConfig) SourceOf(name string) ValidationSource {
+	if IsBuiltin(name) || config == nil {
+		return ValidationSourceBuiltin
+	}
+
+	for _, custom := range config.CustomValidations {
+		if custom.Name == name {
+			return ValidationSourceCustom
+		}
+	}
+
+	for _, plugin := range config.Plugins {
+		if plugin.Name == name {
+			return ValidationSourcePlugin
+		}
+	}
+
+	return ValidationSourceBuiltin
+}
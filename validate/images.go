@@ -16,47 +16,204 @@ const (
 	ImageRegistryValidationName = "trusted-image-registry"
 	ImageRegistryEnv            = "TRUSTED_IMAGE_REGISTRY"
 	ImageRegistriesEnv          = "TRUSTED_IMAGE_REGISTRIES"
+
+	TrustedRegistriesAnnotation     = "pod-security-webhook.nukleros.io/trusted-registries"
+	TrustedRegistriesModeAnnotation = "pod-security-webhook.nukleros.io/trusted-registries-mode"
+)
+
+var (
+	ErrPodImageRegistry           = errors.New("unable to permit pod with images from an untrusted registry")
+	ErrTrustedRegistriesInvalid   = errors.New("invalid trusted registries configuration")
+	ErrTrustedRegistriesModeValue = errors.New("invalid trusted registries mode")
 )
 
-var ErrPodImageRegistry = errors.New("unable to permit pod with images from an untrusted registry")
+// TrustedRegistriesMode is how the trusted registries of a namespace are merged with the global
+// trusted registries.
+type TrustedRegistriesMode string
+
+const (
+	// TrustedRegistriesModeExtend trusts the registries of the namespace in addition to the global
+	// trusted registries.
+	TrustedRegistriesModeExtend TrustedRegistriesMode = "Extend"
+
+	// TrustedRegistriesModeReplace trusts only the registries of the namespace, ignoring the global
+	// trusted registries.
+	TrustedRegistriesModeReplace TrustedRegistriesMode = "Replace"
+)
+
+// TrustedRegistriesConfig is the policy configuration of the trusted registries for each namespace.
+// The registries for a namespace are merged with those from the namespace annotation.
+type TrustedRegistriesConfig struct {
+	Mode       TrustedRegistriesMode `json:"mode,omitempty"`
+	Namespaces map[string][]string   `json:"namespaces,omitempty"`
+}
 
 // ImageRegistry validates whether a pod spec has a valid registry.
 func ImageRegistry(validation *Validation) (bool, error) {
-	trustedRegistry := os.Getenv(ImageRegistryEnv)
-	trustedRegistries := os.Getenv(ImageRegistriesEnv)
+	allContainers := []corev1.Container{}
+	allContainers = append(append(allContainers, validation.PodSpec.InitContainers...), validation.PodSpec.Containers...)
+
+	// the namespace annotations may add trusted registries or replace the global trusted registries,
+	// so without the namespace we can only permit images from the trusted registries of the
+	// namespace in the policy configuration, which are trusted regardless of the annotations
+	if validation.Namespace == nil {
+		if len(untrustedContainers(allContainers, validation.policyTrustedRegistries())) == 0 {
+			return true, nil
+		}
+
+		return validation.Failed(fmt.Errorf(
+			"%w [%s] to read annotations [%s, %s]",
+			ErrNamespaceUnavailable,
+			validation.Resource.GetNamespace(),
+			TrustedRegistriesAnnotation,
+			TrustedRegistriesModeAnnotation,
+		))
+	}
+
+	allTrustedRegistries, err := validation.trustedRegistries()
+	if err != nil {
+		return validation.Failed(err)
+	}
 
 	// if we do not have a trusted registry, we can skip this validation check
-	if trustedRegistry == "" && trustedRegistries == "" {
+	if len(allTrustedRegistries) == 0 {
 		return true, nil
 	}
 
-	allTrustedRegistries := []string{}
-	for _, registryList := range []string{trustedRegistry, trustedRegistries} {
-		allTrustedRegistries = append(allTrustedRegistries, strings.Split(registryList, ",")...)
+	if containersWithUntrustedRegistries := untrustedContainers(allContainers, allTrustedRegistries); len(containersWithUntrustedRegistries) > 0 {
+		return validation.Failed(
+			fmt.Errorf(
+				"%w - container not using trusted registries [%s]",
+				ErrPodImageRegistry,
+				strings.Join(allTrustedRegistries, ", "),
+			),
+			containersWithUntrustedRegistries...,
+		)
 	}
 
-	allContainers := []corev1.Container{}
-	allContainers = append(append(allContainers, validation.PodSpec.InitContainers...), validation.PodSpec.Containers...)
+	return true, nil
+}
+
+// ParseTrustedRegistriesMode parses a trusted registries mode, ignoring case.  An empty value
+// returns the default mode, which extends the global trusted registries.
+func ParseTrustedRegistriesMode(value string) (TrustedRegistriesMode, error) {
+	switch {
+	case value == "", strings.EqualFold(value, string(TrustedRegistriesModeExtend)):
+		return TrustedRegistriesModeExtend, nil
+	case strings.EqualFold(value, string(TrustedRegistriesModeReplace)):
+		return TrustedRegistriesModeReplace, nil
+	default:
+		return "", fmt.Errorf(
+			"%w [%s] - expected one of [%s, %s]",
+			ErrTrustedRegistriesModeValue,
+			value,
+			TrustedRegistriesModeExtend,
+			TrustedRegistriesModeReplace,
+		)
+	}
+}
+
+// trustedRegistries returns the trusted registries for the namespace of the validation.  The
+// global trusted registries from the environment are extended, or replaced, by the trusted
+// registries of the namespace from the policy configuration and the namespace annotation.  The
+// mode is set by the namespace annotation, or the policy configuration if the namespace does not
+// have the annotation.  The namespace of the validation must have been retrieved.
+func (validation *Validation) trustedRegistries() ([]string, error) {
+	global := splitRegistries(os.Getenv(ImageRegistryEnv), os.Getenv(ImageRegistriesEnv))
+	namespaced := validation.policyTrustedRegistries()
 
-	containersWithUntrustedRegistries := []corev1.Container{}
+	mode := TrustedRegistriesModeExtend
+	if validation.Policy != nil && validation.Policy.TrustedRegistries != nil {
+		mode = validation.Policy.TrustedRegistries.Mode
+	}
+
+	annotations := validation.Namespace.GetAnnotations()
+
+	namespaced = append(namespaced, splitRegistries(annotations[TrustedRegistriesAnnotation])...)
+
+	if value, ok := annotations[TrustedRegistriesModeAnnotation]; ok {
+		parsed, err := ParseTrustedRegistriesMode(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w from namespace annotation [%s]", err, TrustedRegistriesModeAnnotation)
+		}
+
+		mode = parsed
+	}
+
+	// the namespace can only replace the global trusted registries if it has its own
+	if len(namespaced) > 0 && mode == TrustedRegistriesModeReplace {
+		return namespaced, nil
+	}
+
+	return append(global, namespaced...), nil
+}
+
+// policyTrustedRegistries returns the trusted registries of the namespace of the resource from the
+// policy configuration.
+func (validation *Validation) policyTrustedRegistries() []string {
+	if validation.Policy == nil || validation.Policy.TrustedRegistries == nil {
+		return []string{}
+	}
+
+	name := validation.Resource.GetNamespace()
+	if validation.Namespace != nil {
+		name = validation.Namespace.Name
+	}
+
+	return append([]string{}, validation.Policy.TrustedRegistries.Namespaces[name]...)
+}
+
+// untrustedContainers returns the containers whose images are not from one of the trusted
+// registries.
+func untrustedContainers(containers []corev1.Container, trustedRegistries []string) []corev1.Container {
+	untrusted := []corev1.Container{}
 
 CONTAINERS:
-	for c := range allContainers {
-		for r := range allTrustedRegistries {
-			if strings.HasPrefix(allContainers[c].Image, allTrustedRegistries[r]) {
+	for c := range containers {
+		for r := range trustedRegistries {
+			if strings.HasPrefix(containers[c].Image, trustedRegistries[r]) {
 				continue CONTAINERS
 			}
 		}
 
-		containersWithUntrustedRegistries = append(containersWithUntrustedRegistries, allContainers[c])
+		untrusted = append(untrusted, containers[c])
 	}
 
-	if len(containersWithUntrustedRegistries) > 0 {
-		return validation.Failed(
-			fmt.Errorf("%w - container not using registry %s", ErrPodImageRegistry, trustedRegistry),
-			containersWithUntrustedRegistries...,
-		)
+	return untrusted
+}
+
+// validate validates the trusted registries configuration and sets the default mode.
+func (config *TrustedRegistriesConfig) validate() error {
+	mode, err := ParseTrustedRegistriesMode(string(config.Mode))
+	if err != nil {
+		return fmt.Errorf("%w - %s", ErrTrustedRegistriesInvalid, err)
 	}
 
-	return true, nil
+	config.Mode = mode
+
+	for namespace, registries := range config.Namespaces {
+		for _, registry := range registries {
+			if strings.TrimSpace(registry) == "" {
+				return fmt.Errorf("%w - namespace [%s] has an empty registry", ErrTrustedRegistriesInvalid, namespace)
+			}
+		}
+	}
+
+	return nil
+}
+
+// splitRegistries splits comma-separated lists of registries, ignoring empty entries which would
+// otherwise match all images as a prefix.
+func splitRegistries(registryLists ...string) []string {
+	registries := []string{}
+
+	for _, registryList := range registryLists {
+		for _, registry := range strings.Split(registryList, ",") {
+			if registry = strings.TrimSpace(registry); registry != "" {
+				registries = append(registries, registry)
+			}
+		}
+	}
+
+	return registries
 }
@@ -0,0 +1,80 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package validate
+
+import (
+	"errors"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestValidateImageRegistryWithoutNamespace ensures that only the registries of a namespace from
+// the policy configuration are trusted when the namespace is unavailable, which can not be
+// reproduced by the offline webhook used by the policy test fixtures.
+//
+//nolint:paralleltest
+func TestValidateImageRegistryWithoutNamespace(t *testing.T) {
+	t.Setenv(ImageRegistryEnv, "ghcr.io/nukleros/")
+	t.Setenv(ImageRegistriesEnv, "")
+
+	namespaces := map[string][]string{"platform": {"quay.io/"}}
+
+	tests := []struct {
+		name    string
+		image   string
+		mode    TrustedRegistriesMode
+		want    bool
+		wantErr error
+	}{
+		{
+			name:  "ensure an image from the policy registries of the namespace passes validation in extend mode",
+			image: "quay.io/prometheus/prometheus:v2",
+			mode:  TrustedRegistriesModeExtend,
+			want:  true,
+		},
+		{
+			name:    "ensure an image from the global trusted registries fails validation in extend mode",
+			image:   "ghcr.io/nukleros/app:v1",
+			mode:    TrustedRegistriesModeExtend,
+			want:    false,
+			wantErr: ErrNamespaceUnavailable,
+		},
+		{
+			name:  "ensure an image from the policy registries of the namespace passes validation in replace mode",
+			image: "quay.io/prometheus/prometheus:v2",
+			mode:  TrustedRegistriesModeReplace,
+			want:  true,
+		},
+		{
+			name:    "ensure an image from an untrusted registry fails validation in replace mode",
+			image:   "docker.io/library/nginx:1.25",
+			mode:    TrustedRegistriesModeReplace,
+			want:    false,
+			wantErr: ErrNamespaceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			resource := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "platform"}}
+			resource.SetGroupVersionKind(appsv1.SchemeGroupVersion.WithKind("Deployment"))
+			got, err := ImageRegistry(&Validation{
+				Name:     ImageRegistryValidationName,
+				Resource: resource,
+				PodSpec:  &corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: tt.image}}},
+				Policy:   &PolicyConfig{TrustedRegistries: &TrustedRegistriesConfig{Mode: tt.mode, Namespaces: namespaces}},
+			})
+			if got != tt.want {
+				t.Errorf("ImageRegistry() = %v, want %v", got, tt.want)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ImageRegistry() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
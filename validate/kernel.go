@@ -0,0 +1,78 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/nukleros/pod-security-webhook/resources"
+)
+
+const (
+	UnsafeSysctlsValidationName   = "unsafe-sysctls"
+	UnsafeProcMountValidationName = "unsafe-proc-mount"
+)
+
+var (
+	ErrPodUnsafeSysctls         = errors.New("unable to permit pod with unsafe sysctls")
+	ErrContainerUnsafeProcMount = errors.New("unable to permit container with unmasked proc mount")
+)
+
+// safeSysctls is the set of sysctls which are namespaced and isolated between pods on the same
+// node, as defined by the baseline pod security standard.
+//
+//nolint:gochecknoglobals
+var safeSysctls = map[string]bool{
+	"kernel.shm_rmid_forced":              true,
+	"net.ipv4.ip_local_port_range":        true,
+	"net.ipv4.ip_unprivileged_port_start": true,
+	"net.ipv4.tcp_syncookies":             true,
+	"net.ipv4.ping_group_range":           true,
+}
+
+// UnsafeSysctls validates whether a pod spec sets sysctls outside of the safe set.  Sysctls may only
+// be set at the pod level.
+func UnsafeSysctls(validation *Validation) (bool, error) {
+	if validation.PodSpec.SecurityContext == nil {
+		return true, nil
+	}
+
+	unsafeSysctls := []string{}
+
+	for _, sysctl := range validation.PodSpec.SecurityContext.Sysctls {
+		if !safeSysctls[sysctl.Name] {
+			unsafeSysctls = append(unsafeSysctls, sysctl.Name)
+		}
+	}
+
+	if len(unsafeSysctls) > 0 {
+		return validation.Failed(fmt.Errorf("%w [%s]", ErrPodUnsafeSysctls, strings.Join(unsafeSysctls, ", ")))
+	}
+
+	return true, nil
+}
+
+// UnsafeProcMount validates whether a container in a pod spec sets an unmasked proc mount.
+func UnsafeProcMount(validation *Validation) (bool, error) {
+	containersWithUnmaskedProcMount := []corev1.Container{}
+
+	allContainers := []corev1.Container{}
+	allContainers = append(append(allContainers, validation.PodSpec.InitContainers...), validation.PodSpec.Containers...)
+
+	for i := range allContainers {
+		if resources.EffectiveProcMount(allContainers[i].SecurityContext) != corev1.DefaultProcMount {
+			containersWithUnmaskedProcMount = append(containersWithUnmaskedProcMount, allContainers[i])
+		}
+	}
+
+	if len(containersWithUnmaskedProcMount) == 0 {
+		return true, nil
+	}
+
+	return validation.Failed(ErrContainerUnsafeProcMount, containersWithUnmaskedProcMount...)
+}
@@ -0,0 +1,333 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package validate
+
+const (
+	CategoryPodSecurity = "Pod Security"
+	CategoryImages      = "Images"
+	CategoryRBAC        = "RBAC"
+	CategoryResources   = "Resource Management"
+
+	TagCIS            = "CIS"
+	TagPSSBaseline    = "PSS-Baseline"
+	TagPSSRestricted  = "PSS-Restricted"
+	TagSupplyChain    = "Supply-Chain"
+	TagAccessControls = "Access-Controls"
+	TagReliability    = "Reliability"
+
+	SeverityCritical = "critical"
+	SeverityHigh     = "high"
+	SeverityMedium   = "medium"
+	SeverityLow      = "low"
+	SeverityInfo     = "info"
+)
+
+// Metadata is descriptive information about a validation used when reporting results.
+type Metadata struct {
+	Category string
+	Tags     []string
+	Severity string
+
+	// Description describes what the validation checks, and Remediation describes how to change
+	// a resource which fails the validation.
+	Description string
+	Remediation string
+
+	// Controls are the settings specific to the validation which change its outcome, in addition to
+	// the overrides and exemptions which apply to every validation.
+	Controls []Control
+}
+
+// metadata is the metadata for each of the built-in validations.
+//
+//nolint:gochecknoglobals
+var metadata = map[string]Metadata{
+	RunAsNonRootValidationName: {
+		Category:    CategoryPodSecurity,
+		Tags:        []string{TagCIS, TagPSSRestricted},
+		Severity:    SeverityMedium,
+		Description: "Containers must run as a non-root user.",
+		Remediation: "Set securityContext.runAsNonRoot to true and securityContext.runAsUser to a non-zero user id on the pod or on each container.",
+	},
+	PrivilegedValidationName: {
+		Category:    CategoryPodSecurity,
+		Tags:        []string{TagCIS, TagPSSBaseline},
+		Severity:    SeverityHigh,
+		Description: "Containers must not run in privileged mode.",
+		Remediation: "Remove securityContext.privileged from each container, or set it to false.",
+	},
+	AllowPrivilegeEscalationValidationName: {
+		Category:    CategoryPodSecurity,
+		Tags:        []string{TagCIS, TagPSSRestricted},
+		Severity:    SeverityHigh,
+		Description: "Containers must not allow privilege escalation.",
+		Remediation: "Set securityContext.allowPrivilegeEscalation to false on each container.",
+	},
+	HostPIDValidationName: {
+		Category:    CategoryPodSecurity,
+		Tags:        []string{TagCIS, TagPSSBaseline},
+		Severity:    SeverityHigh,
+		Description: "Pods must not share the process id namespace of the host.",
+		Remediation: "Remove spec.hostPID from the pod, or set it to false.",
+	},
+	HostIPCValidationName: {
+		Category:    CategoryPodSecurity,
+		Tags:        []string{TagCIS, TagPSSBaseline},
+		Severity:    SeverityHigh,
+		Description: "Pods must not share the IPC namespace of the host.",
+		Remediation: "Remove spec.hostIPC from the pod, or set it to false.",
+	},
+	HostNetworkValidationName: {
+		Category:    CategoryPodSecurity,
+		Tags:        []string{TagCIS, TagPSSBaseline},
+		Severity:    SeverityHigh,
+		Description: "Pods must not share the network namespace of the host.",
+		Remediation: "Remove spec.hostNetwork from the pod, or set it to false.",
+	},
+	WindowsHostProcessValidationName: {
+		Category:    CategoryPodSecurity,
+		Tags:        []string{TagPSSBaseline},
+		Severity:    SeverityHigh,
+		Description: "Windows containers must not run as HostProcess containers, and must only use permitted gMSA credential specs.",
+		Remediation: "Remove securityContext.windowsOptions.hostProcess, or set it to false, and reference a credential spec listed in ALLOWED_GMSA_CREDENTIAL_SPECS with gmsaCredentialSpecName.",
+		Controls: []Control{
+			{
+				Type:   ControlTypeEnv,
+				Key:    AllowedGMSACredentialSpecsEnv,
+				Value:  "<name>,...",
+				Effect: "permits only the listed gMSA credential specs by name, rather than any credential spec",
+			},
+		},
+	},
+	UnsafeSysctlsValidationName: {
+		Category:    CategoryPodSecurity,
+		Tags:        []string{TagPSSBaseline},
+		Severity:    SeverityHigh,
+		Description: "Pods must only set sysctls which are safe and namespaced.",
+		Remediation: "Remove any sysctl from spec.securityContext.sysctls which is not in the safe set, such as kernel.* or vm.* settings.",
+	},
+	UnsafeProcMountValidationName: {
+		Category:    CategoryPodSecurity,
+		Tags:        []string{TagPSSBaseline},
+		Severity:    SeverityHigh,
+		Description: "Containers must use the default proc mount.",
+		Remediation: "Remove securityContext.procMount from each container, or set it to Default.",
+	},
+	AppArmorProfileValidationName: {
+		Category:    CategoryPodSecurity,
+		Tags:        []string{TagPSSBaseline},
+		Severity:    SeverityMedium,
+		Description: "Containers must not override the AppArmor profile with an unconfined or unknown profile.",
+		Remediation: "Remove the container.apparmor.security.beta.kubernetes.io annotations, or set them to runtime/default or a localhost/* profile.",
+	},
+	SELinuxOptionsValidationName: {
+		Category:    CategoryPodSecurity,
+		Tags:        []string{TagPSSBaseline},
+		Severity:    SeverityMedium,
+		Description: "Containers must not set a custom SELinux user, role or type.",
+		Remediation: "Remove seLinuxOptions.user and seLinuxOptions.role, and set seLinuxOptions.type to container_t, container_init_t or container_kvm_t if set.",
+	},
+	SchedulingValidationName: {
+		Category:    CategoryPodSecurity,
+		Tags:        []string{TagAccessControls},
+		Severity:    SeverityHigh,
+		Description: "Pods must not target specific nodes or control-plane nodes, and must respect the node selectors of the namespace.",
+		Remediation: "Remove spec.nodeName and any toleration of the control-plane taints, and set a node selector permitted by the namespace.",
+		Controls: []Control{
+			{
+				Type:   ControlTypeNamespaceAnnotation,
+				Key:    AllowedTolerationsAnnotation,
+				Value:  "<taint key>,...",
+				Effect: "permits tolerations of the listed control-plane taints in the namespace, or of all taints with *",
+			},
+			{
+				Type:   ControlTypeNamespaceAnnotation,
+				Key:    NodeSelectorAnnotation,
+				Value:  "<key>=<value>,...",
+				Effect: "requires pods in the namespace to select the nodes with the listed labels",
+			},
+		},
+	},
+	UserGroupRangesValidationName: {
+		Category:    CategoryPodSecurity,
+		Tags:        []string{TagAccessControls},
+		Severity:    SeverityMedium,
+		Description: "Pods must run with user and group ids within the permitted ranges.",
+		Remediation: "Set runAsUser, runAsGroup, fsGroup and supplementalGroups to ids within the ranges permitted for the namespace.",
+		Controls: []Control{
+			{
+				Type:   ControlTypeEnv,
+				Key:    AllowedUIDRangesEnv,
+				Value:  "<min>-<max>,...",
+				Effect: "permits only user ids within the listed ranges in namespaces without their own ranges",
+			},
+			{
+				Type:   ControlTypeEnv,
+				Key:    AllowedGIDRangesEnv,
+				Value:  "<min>-<max>,...",
+				Effect: "permits only group ids within the listed ranges in namespaces without their own ranges",
+			},
+			{
+				Type:   ControlTypeNamespaceAnnotation,
+				Key:    AllowedUIDRangesAnnotation,
+				Value:  "<min>-<max>,...",
+				Effect: "permits only user ids within the listed ranges in the namespace, replacing the global ranges",
+			},
+			{
+				Type:   ControlTypeNamespaceAnnotation,
+				Key:    AllowedGIDRangesAnnotation,
+				Value:  "<min>-<max>,...",
+				Effect: "permits only group ids within the listed ranges in the namespace, replacing the global ranges",
+			},
+		},
+	},
+	AllowedVolumeTypesValidationName: {
+		Category:    CategoryPodSecurity,
+		Tags:        []string{TagPSSRestricted},
+		Severity:    SeverityMedium,
+		Description: "Pods must only use permitted volume types.",
+		Remediation: "Replace any hostPath, flex or in-tree cloud provider volumes with a volume type listed in ALLOWED_VOLUME_TYPES, such as persistentVolumeClaim or csi.",
+		Controls: []Control{
+			{
+				Type:   ControlTypeEnv,
+				Key:    AllowedVolumeTypesEnv,
+				Value:  "<type>,...",
+				Effect: "permits only the listed volume types, replacing the default volume types",
+			},
+		},
+	},
+	AddCapabilitiesValidationName: {
+		Category:    CategoryPodSecurity,
+		Tags:        []string{TagCIS, TagPSSBaseline},
+		Severity:    SeverityMedium,
+		Description: "Containers must not add Linux capabilities.",
+		Remediation: "Remove securityContext.capabilities.add from each container.",
+	},
+	DropCapabilitiesValidationName: {
+		Category:    CategoryPodSecurity,
+		Tags:        []string{TagCIS, TagPSSRestricted},
+		Severity:    SeverityMedium,
+		Description: "Containers must drop all Linux capabilities.",
+		Remediation: "Set securityContext.capabilities.drop to [ALL] on each container.",
+	},
+	ImageRegistryValidationName: {
+		Category:    CategoryImages,
+		Tags:        []string{TagSupplyChain},
+		Severity:    SeverityMedium,
+		Description: "Containers must use images from a trusted registry.",
+		Remediation: "Use images from one of the trusted registries of the namespace, or mirror the image to a trusted registry.",
+		Controls: []Control{
+			{
+				Type:   ControlTypeEnv,
+				Key:    ImageRegistriesEnv,
+				Value:  "<registry>,...",
+				Effect: "trusts images from the listed registry prefixes in all namespaces, along with " + ImageRegistryEnv,
+			},
+			{
+				Type:   ControlTypeNamespaceAnnotation,
+				Key:    TrustedRegistriesAnnotation,
+				Value:  "<registry>,...",
+				Effect: "trusts images from the listed registry prefixes in the namespace",
+			},
+			{
+				Type:   ControlTypeNamespaceAnnotation,
+				Key:    TrustedRegistriesModeAnnotation,
+				Value:  string(TrustedRegistriesModeReplace),
+				Effect: "trusts only the registries of the namespace, rather than extending the global trusted registries",
+			},
+			{
+				Type:   ControlTypePolicy,
+				Key:    "trustedRegistries.namespaces.<namespace>",
+				Value:  "[<registry>, ...]",
+				Effect: "trusts images from the listed registry prefixes in the namespace",
+			},
+			{
+				Type:   ControlTypePolicy,
+				Key:    "trustedRegistries.mode",
+				Value:  string(TrustedRegistriesModeReplace),
+				Effect: "trusts only the registries of each namespace which has its own, rather than extending the global trusted registries",
+			},
+		},
+	},
+	ImageSignatureValidationName: {
+		Category:    CategoryImages,
+		Tags:        []string{TagSupplyChain},
+		Severity:    SeverityHigh,
+		Description: "Containers must use images signed by a trusted key.",
+		Remediation: "Sign the image with cosign using a key trusted by the policy configuration, or use an image which is signed.",
+		Controls: []Control{
+			{
+				Type:   ControlTypePolicy,
+				Key:    "imageSignatures.policies",
+				Value:  "[{registry, publicKeys, namespaces}, ...]",
+				Effect: "requires images from each registry to be signed by one of its public keys, in the listed namespaces or all namespaces",
+			},
+		},
+	},
+	CPURequirementsValidationName: {
+		Category:    CategoryResources,
+		Tags:        []string{TagReliability},
+		Severity:    SeverityMedium,
+		Description: "Containers must set CPU requests and limits within the permitted bounds.",
+		Remediation: "Set resources.requests.cpu and resources.limits.cpu on each container.",
+		Controls: []Control{
+			{
+				Type:   ControlTypePolicy,
+				Key:    "resourceRequirements.cpu",
+				Value:  "{requests, limits, min, max, maxLimitRequestRatio}",
+				Effect: "sets the cpu requirements of each container, which are not checked when unset",
+			},
+			{
+				Type:   ControlTypePolicy,
+				Key:    "resourceRequirements.kinds.<kind>.cpu",
+				Value:  "{requests, limits, min, max, maxLimitRequestRatio}",
+				Effect: "sets the cpu requirements of each container of the kind, overriding those for all kinds",
+			},
+		},
+	},
+	MemoryRequirementsValidationName: {
+		Category:    CategoryResources,
+		Tags:        []string{TagReliability},
+		Severity:    SeverityMedium,
+		Description: "Containers must set memory requests and limits within the permitted bounds.",
+		Remediation: "Set resources.requests.memory and resources.limits.memory on each container.",
+		Controls: []Control{
+			{
+				Type:   ControlTypePolicy,
+				Key:    "resourceRequirements.memory",
+				Value:  "{requests, limits, min, max, maxLimitRequestRatio}",
+				Effect: "sets the memory requirements of each container, which are not checked when unset",
+			},
+			{
+				Type:   ControlTypePolicy,
+				Key:    "resourceRequirements.kinds.<kind>.memory",
+				Value:  "{requests, limits, min, max, maxLimitRequestRatio}",
+				Effect: "sets the memory requirements of each container of the kind, overriding those for all kinds",
+			},
+		},
+	},
+	DefaultServiceAccountValidationName: {
+		Category:    CategoryRBAC,
+		Tags:        []string{TagCIS, TagAccessControls},
+		Severity:    SeverityLow,
+		Description: "Pods must not use the default service account.",
+		Remediation: "Create a service account for the workload and set spec.serviceAccountName.",
+	},
+}
+
+// MetadataFor returns the metadata for a validation given its name.
+func MetadataFor(name string) Metadata {
+	if found, ok := metadata[name]; ok {
+		return found
+	}
+
+	return Metadata{Category: CategoryPodSecurity, Severity: SeverityMedium}
+}
+
+// IsBuiltin returns whether a validation name belongs to a built-in validation.
+func IsBuiltin(name string) bool {
+	_, ok := metadata[name]
+
+	return ok
+}
@@ -0,0 +1,84 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/nukleros/pod-security-webhook/registry"
+)
+
+var ErrImageMirrorInvalid = errors.New("invalid image mirror configuration")
+
+// ImageMirror rewrites images from a set of source registries to a mirror.  Sources may be a
+// registry, such as docker.io, or a registry and repository prefix, such as quay.io/prometheus.
+type ImageMirror struct {
+	Sources []string `json:"sources"`
+	Mirror  string   `json:"mirror"`
+}
+
+// MirrorImage returns the image rewritten to the first mirror with a source which matches the
+// image, keeping its tag and digest.  False is returned if the image does not match a source.
+func (config *PolicyConfig) MirrorImage(image string) (string, bool) {
+	if len(config.ImageMirrors) == 0 {
+		return "", false
+	}
+
+	reference, err := registry.ParseReference(image)
+	if err != nil {
+		return "", false
+	}
+
+	name := reference.Name()
+
+	for _, mirror := range config.ImageMirrors {
+		for _, source := range mirror.Sources {
+			if name != source && !strings.HasPrefix(name, source+"/") {
+				continue
+			}
+
+			mirrored := mirror.Mirror + strings.TrimPrefix(name, source)
+
+			if reference.Tag != "" {
+				mirrored += ":" + reference.Tag
+			}
+
+			if reference.Digest != "" {
+				mirrored += "@" + reference.Digest
+			}
+
+			return mirrored, true
+		}
+	}
+
+	return "", false
+}
+
+// validate validates an image mirror, removing any trailing slashes from the mirror and sources
+// so that they are matched against the repository name of an image.
+func (mirror *ImageMirror) validate() error {
+	mirror.Mirror = strings.TrimSuffix(mirror.Mirror, "/")
+	if mirror.Mirror == "" {
+		return fmt.Errorf("%w - mirror must be set", ErrImageMirrorInvalid)
+	}
+
+	if len(mirror.Sources) == 0 {
+		return fmt.Errorf("%w - mirror [%s] has no sources", ErrImageMirrorInvalid, mirror.Mirror)
+	}
+
+	for i := range mirror.Sources {
+		mirror.Sources[i] = strings.TrimSuffix(mirror.Sources[i], "/")
+		if mirror.Sources[i] == "" {
+			return fmt.Errorf("%w - mirror [%s] has an empty source", ErrImageMirrorInvalid, mirror.Mirror)
+		}
+
+		if mirror.Sources[i] == mirror.Mirror || strings.HasPrefix(mirror.Mirror, mirror.Sources[i]+"/") {
+			return fmt.Errorf("%w - mirror [%s] matches its own source [%s]", ErrImageMirrorInvalid, mirror.Mirror, mirror.Sources[i])
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package validate
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMirrorImage(t *testing.T) {
+	t.Parallel()
+
+	config := &PolicyConfig{
+		ImageMirrors: []*ImageMirror{
+			{Sources: []string{"quay.io/prometheus"}, Mirror: "registry.internal/prometheus"},
+			{Sources: []string{"docker.io", "gcr.io/"}, Mirror: "registry.internal/mirror/"},
+		},
+	}
+
+	if err := config.compile(); err != nil {
+		t.Fatalf("unable to compile policy configuration: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		image  string
+		want   string
+		wantOK bool
+	}{
+		{
+			name:   "ensure an official image is rewritten with its default organization and tag",
+			image:  "nginx",
+			want:   "registry.internal/mirror/library/nginx:latest",
+			wantOK: true,
+		},
+		{
+			name:   "ensure an image from a source registry keeps its tag",
+			image:  "docker.io/nukleros/webhook:v1.0.0",
+			want:   "registry.internal/mirror/nukleros/webhook:v1.0.0",
+			wantOK: true,
+		},
+		{
+			name:   "ensure an image from a source registry keeps its tag and digest",
+			image:  "gcr.io/distroless/static:nonroot@sha256:abc",
+			want:   "registry.internal/mirror/distroless/static:nonroot@sha256:abc",
+			wantOK: true,
+		},
+		{
+			name:   "ensure an image from a source repository prefix is rewritten",
+			image:  "quay.io/prometheus/node-exporter@sha256:abc",
+			want:   "registry.internal/prometheus/node-exporter@sha256:abc",
+			wantOK: true,
+		},
+		{
+			name:   "ensure a repository which only shares a prefix with a source is not rewritten",
+			image:  "quay.io/prometheus-community/exporter:v1",
+			wantOK: false,
+		},
+		{
+			name:   "ensure an image from another registry is not rewritten",
+			image:  "ghcr.io/nukleros/webhook:v1.0.0",
+			wantOK: false,
+		},
+		{
+			name:   "ensure an image from the mirror is not rewritten",
+			image:  "registry.internal/mirror/library/nginx:latest",
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, ok := config.MirrorImage(tt.image)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("MirrorImage() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestImageMirrorValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		mirror  *ImageMirror
+		wantErr bool
+	}{
+		{
+			name:    "ensure a valid mirror passes validation",
+			mirror:  &ImageMirror{Sources: []string{"docker.io"}, Mirror: "registry.internal/dockerhub"},
+			wantErr: false,
+		},
+		{
+			name:    "ensure a mirror without sources fails validation",
+			mirror:  &ImageMirror{Mirror: "registry.internal/dockerhub"},
+			wantErr: true,
+		},
+		{
+			name:    "ensure a mirror without a mirror fails validation",
+			mirror:  &ImageMirror{Sources: []string{"docker.io"}},
+			wantErr: true,
+		},
+		{
+			name:    "ensure a mirror matched by its own source fails validation",
+			mirror:  &ImageMirror{Sources: []string{"registry.internal"}, Mirror: "registry.internal/dockerhub"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := tt.mirror.validate()
+			if (err != nil) != tt.wantErr || (err != nil && !errors.Is(err, ErrImageMirrorInvalid)) {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package validate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	defaultPluginTimeout                 = 3 * time.Second
+	defaultPluginCircuitFailureThreshold = 5
+	defaultPluginCircuitResetTimeout     = 30 * time.Second
+)
+
+var (
+	ErrPluginInvalid     = errors.New("invalid validation plugin")
+	ErrPluginUnavailable = errors.New("unable to call validation plugin")
+	ErrPluginDenied      = errors.New("unable to permit pod denied by validation plugin")
+)
+
+// PluginProtocol is the protocol used to call a validation plugin.
+type PluginProtocol string
+
+const (
+	// PluginProtocolGRPC calls the plugin as a grpc service using the json codec.
+	PluginProtocolGRPC PluginProtocol = "grpc"
+
+	// PluginProtocolHTTP calls the plugin by posting json to an http endpoint.
+	PluginProtocolHTTP PluginProtocol = "http"
+)
+
+// PluginFailurePolicy is the policy applied when a validation plugin can not be called.
+type PluginFailurePolicy string
+
+const (
+	// PluginFailurePolicyFail fails the validation when the plugin can not be called (fail closed).
+	PluginFailurePolicyFail PluginFailurePolicy = "Fail"
+
+	// PluginFailurePolicyIgnore passes the validation when the plugin can not be called (fail open).
+	PluginFailurePolicyIgnore PluginFailurePolicy = "Ignore"
+)
+
+// PluginRequest is the request sent to a validation plugin.
+type PluginRequest struct {
+	Validation string                 `json:"validation"`
+	Object     map[string]interface{} `json:"object"`
+	PodSpec    *corev1.PodSpec        `json:"podSpec,omitempty"`
+}
+
+// PluginResponse is the response returned by a validation plugin.  Containers optionally lists the
+// names of the containers which caused the plugin to deny the object.
+type PluginResponse struct {
+	Allowed    bool     `json:"allowed"`
+	Message    string   `json:"message,omitempty"`
+	Containers []string `json:"containers,omitempty"`
+}
+
+// PluginCircuitBreaker is the configuration of the circuit breaker for a validation plugin.  The
+// circuit opens after a number of consecutive failures, during which the plugin is not called,
+// and closes again once a call succeeds after the reset timeout.
+type PluginCircuitBreaker struct {
+	FailureThreshold int             `json:"failureThreshold,omitempty"`
+	ResetTimeout     metav1.Duration `json:"resetTimeout,omitempty"`
+}
+
+// Plugin is a validation defined in the policy configuration which forwards the object and its
+// pod specification to an external grpc or http service.
+type Plugin struct {
+	Name           string               `json:"name"`
+	Protocol       PluginProtocol       `json:"protocol"`
+	Endpoint       string               `json:"endpoint"`
+	Insecure       bool                 `json:"insecure,omitempty"`
+	Timeout        metav1.Duration      `json:"timeout,omitempty"`
+	FailurePolicy  PluginFailurePolicy  `json:"failurePolicy,omitempty"`
+	CircuitBreaker PluginCircuitBreaker `json:"circuitBreaker,omitempty"`
+
+	// OnError is called when the plugin can not be called, regardless of the failure policy.
+	OnError func(plugin *Plugin, err error) `json:"-"`
+
+	transport pluginTransport
+	breaker   *circuitBreaker
+}
+
+// pluginTransport is the client used to call a validation plugin.
+type pluginTransport interface {
+	call(ctx context.Context, request *PluginRequest) (*PluginResponse, error)
+	close() error
+}
+
+// Connect validates the configuration of the plugin, applying defaults, and creates the client
+// used to call the plugin.
+func (plugin *Plugin) Connect() error {
+	if plugin.Endpoint == "" {
+		return fmt.Errorf("%w [%s] - missing endpoint", ErrPluginInvalid, plugin.Name)
+	}
+
+	if plugin.Timeout.Duration == 0 {
+		plugin.Timeout.Duration = defaultPluginTimeout
+	}
+
+	switch plugin.FailurePolicy {
+	case "":
+		plugin.FailurePolicy = PluginFailurePolicyFail
+	case PluginFailurePolicyFail, PluginFailurePolicyIgnore:
+	default:
+		return fmt.Errorf("%w [%s] - unknown failure policy [%s]", ErrPluginInvalid, plugin.Name, plugin.FailurePolicy)
+	}
+
+	if plugin.CircuitBreaker.FailureThreshold == 0 {
+		plugin.CircuitBreaker.FailureThreshold = defaultPluginCircuitFailureThreshold
+	}
+
+	if plugin.CircuitBreaker.ResetTimeout.Duration == 0 {
+		plugin.CircuitBreaker.ResetTimeout.Duration = defaultPluginCircuitResetTimeout
+	}
+
+	var err error
+
+	switch plugin.Protocol {
+	case PluginProtocolGRPC:
+		plugin.transport, err = newGRPCPluginTransport(plugin.Endpoint, plugin.Insecure)
+	case PluginProtocolHTTP:
+		plugin.transport, err = newHTTPPluginTransport(plugin.Endpoint)
+	default:
+		err = fmt.Errorf("unknown protocol [%s]", plugin.Protocol)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w [%s] - %s", ErrPluginInvalid, plugin.Name, err)
+	}
+
+	plugin.breaker = newCircuitBreaker(plugin.CircuitBreaker.FailureThreshold, plugin.CircuitBreaker.ResetTimeout.Duration)
+
+	return nil
+}
+
+// Close closes the client used to call the plugin.
+func (plugin *Plugin) Close() error {
+	if plugin.transport == nil {
+		return nil
+	}
+
+	return plugin.transport.close()
+}
+
+// Validation returns a new validation which runs the plugin.
+func (plugin *Plugin) Validation() *Validation {
+	return NewValidation(plugin.Name, plugin.Run)
+}
+
+// Run calls the plugin with the resource and pod specification of a validation.  If the plugin
+// can not be called, the validation passes or fails according to the failure policy.
+func (plugin *Plugin) Run(validation *Validation) (bool, error) {
+	response, err := plugin.call(validation)
+	if err != nil {
+		if plugin.OnError != nil {
+			plugin.OnError(plugin, err)
+		}
+
+		if plugin.FailurePolicy == PluginFailurePolicyIgnore {
+			return true, nil
+		}
+
+		return validation.Failed(err)
+	}
+
+	if response.Allowed {
+		return true, nil
+	}
+
+	message := response.Message
+	if message == "" {
+		message = fmt.Sprintf("denied by plugin %s", plugin.Name)
+	}
+
+	return validation.Failed(
+		fmt.Errorf("%w - %s", ErrPluginDenied, message),
+		pluginContainers(validation.PodSpec, response.Containers)...,
+	)
+}
+
+// call calls the plugin through the circuit breaker.
+func (plugin *Plugin) call(validation *Validation) (*PluginResponse, error) {
+	if plugin.transport == nil {
+		return nil, fmt.Errorf("%w [%s] - plugin is not connected", ErrPluginUnavailable, plugin.Name)
+	}
+
+	// the object is converted prior to the circuit breaker, so that a failed conversion, which is
+	// not a failure of the plugin, does not take the trial call of a half-open circuit
+	object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(validation.Resource)
+	if err != nil {
+		return nil, fmt.Errorf("%w [%s] - unable to convert object; %s", ErrPluginUnavailable, plugin.Name, err)
+	}
+
+	if !plugin.breaker.allow() {
+		return nil, fmt.Errorf("%w [%s] - circuit breaker is open", ErrPluginUnavailable, plugin.Name)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), plugin.Timeout.Duration)
+	defer cancel()
+
+	response, err := plugin.transport.call(ctx, &PluginRequest{
+		Validation: plugin.Name,
+		Object:     object,
+		PodSpec:    validation.PodSpec,
+	})
+	if err != nil {
+		plugin.breaker.failure()
+
+		return nil, fmt.Errorf("%w [%s] - %s", ErrPluginUnavailable, plugin.Name, err)
+	}
+
+	plugin.breaker.success()
+
+	return response, nil
+}
+
+// pluginContainers returns the containers of a pod specification with the given names.
+func pluginContainers(podSpec *corev1.PodSpec, names []string) []corev1.Container {
+	if podSpec == nil || len(names) == 0 {
+		return nil
+	}
+
+	containers := []corev1.Container{}
+
+	for _, container := range append(append([]corev1.Container{}, podSpec.InitContainers...), podSpec.Containers...) {
+		for _, name := range names {
+			if strings.EqualFold(container.Name, name) {
+				containers = append(containers, container)
+
+				break
+			}
+		}
+	}
+
+	return containers
+}
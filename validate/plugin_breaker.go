@@ -0,0 +1,73 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package validate
+
+import (
+	"sync"
+	"time"
+)
+
+// circuitBreaker prevents calls to a validation plugin which is failing.  The circuit opens after
+// a number of consecutive failures.  Once the reset timeout has elapsed, a single trial call is
+// permitted, which closes the circuit if it succeeds or opens it again if it fails.
+type circuitBreaker struct {
+	threshold    int
+	resetTimeout time.Duration
+	now          func() time.Time
+
+	mutex    sync.Mutex
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+// newCircuitBreaker returns a new closed circuit breaker.
+func newCircuitBreaker(threshold int, resetTimeout time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold:    threshold,
+		resetTimeout: resetTimeout,
+		now:          time.Now,
+	}
+}
+
+// allow returns whether a call is permitted.
+func (breaker *circuitBreaker) allow() bool {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	if breaker.failures < breaker.threshold {
+		return true
+	}
+
+	// only a single trial call is permitted once the reset timeout has elapsed
+	if breaker.trial || breaker.now().Sub(breaker.openedAt) < breaker.resetTimeout {
+		return false
+	}
+
+	breaker.trial = true
+
+	return true
+}
+
+// success records a successful call, closing the circuit.
+func (breaker *circuitBreaker) success() {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	breaker.failures = 0
+	breaker.trial = false
+}
+
+// failure records a failed call, opening the circuit once the threshold is reached.
+func (breaker *circuitBreaker) failure() {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	breaker.failures++
+	breaker.trial = false
+
+	if breaker.failures >= breaker.threshold {
+		breaker.openedAt = breaker.now()
+	}
+}
@@ -0,0 +1,300 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package validate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var errPluginUnavailableTest = errors.New("service catalog unavailable")
+
+// catalogPlugin is a stand-in for a plugin which looks up the team label of an object in a
+// service catalog.
+type catalogPlugin struct {
+	calls int32
+	fail  bool
+}
+
+func (plugin *catalogPlugin) Validate(ctx context.Context, request *PluginRequest) (*PluginResponse, error) {
+	atomic.AddInt32(&plugin.calls, 1)
+
+	if plugin.fail {
+		return nil, errPluginUnavailableTest
+	}
+
+	metadata, _ := request.Object["metadata"].(map[string]interface{})
+	labels, _ := metadata["labels"].(map[string]interface{})
+
+	if labels["team"] == "platform" {
+		return &PluginResponse{Allowed: true}, nil
+	}
+
+	return &PluginResponse{
+		Allowed:    false,
+		Message:    "team is not registered in the service catalog",
+		Containers: []string{request.PodSpec.Containers[0].Name},
+	}, nil
+}
+
+func startGRPCPlugin(t *testing.T, plugin PluginServer) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen: %v", err)
+	}
+
+	server := grpc.NewServer(PluginServerCodec())
+	server.RegisterService(&PluginServiceDesc, plugin)
+
+	go func() {
+		//nolint:errcheck
+		_ = server.Serve(listener)
+	}()
+
+	t.Cleanup(server.Stop)
+
+	return listener.Addr().String()
+}
+
+func startHTTPPlugin(t *testing.T, plugin PluginServer) string {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := &PluginRequest{}
+		if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		response, err := plugin.Validate(r.Context(), request)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		//nolint:errcheck
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+
+	t.Cleanup(server.Close)
+
+	return server.URL
+}
+
+func pluginValidation(plugin *Plugin, labels map[string]string) *Validation {
+	validation := plugin.Validation()
+	validation.PodSpec = validPodSpec()
+	validation.Resource = &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "plugin", Labels: labels},
+		Spec:       *validation.PodSpec,
+	}
+
+	return validation
+}
+
+func TestPluginRun(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		protocol      PluginProtocol
+		fail          bool
+		failurePolicy PluginFailurePolicy
+		labels        map[string]string
+		want          bool
+		wantErr       string
+	}{
+		{
+			name:     "ensure a grpc plugin permits an allowed object",
+			protocol: PluginProtocolGRPC,
+			labels:   map[string]string{"team": "platform"},
+			want:     true,
+		},
+		{
+			name:     "ensure a grpc plugin denies an object with the failed containers",
+			protocol: PluginProtocolGRPC,
+			labels:   map[string]string{"team": "unknown"},
+			want:     false,
+			wantErr:  "team is not registered in the service catalog for containers valid",
+		},
+		{
+			name:     "ensure an http plugin permits an allowed object",
+			protocol: PluginProtocolHTTP,
+			labels:   map[string]string{"team": "platform"},
+			want:     true,
+		},
+		{
+			name:     "ensure an http plugin denies an object",
+			protocol: PluginProtocolHTTP,
+			want:     false,
+			wantErr:  "team is not registered in the service catalog",
+		},
+		{
+			name:          "ensure a failing plugin fails closed",
+			protocol:      PluginProtocolGRPC,
+			fail:          true,
+			failurePolicy: PluginFailurePolicyFail,
+			want:          false,
+			wantErr:       ErrPluginUnavailable.Error(),
+		},
+		{
+			name:          "ensure a failing plugin fails open",
+			protocol:      PluginProtocolHTTP,
+			fail:          true,
+			failurePolicy: PluginFailurePolicyIgnore,
+			want:          true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			server := &catalogPlugin{fail: tt.fail}
+			plugin := &Plugin{Name: "service-catalog", Protocol: tt.protocol, Insecure: true, FailurePolicy: tt.failurePolicy}
+			if tt.protocol == PluginProtocolGRPC {
+				plugin.Endpoint = startGRPCPlugin(t, server)
+			} else {
+				plugin.Endpoint = startHTTPPlugin(t, server)
+			}
+			if err := plugin.Connect(); err != nil {
+				t.Fatalf("Plugin.Connect() error = %v", err)
+			}
+			defer plugin.Close()
+			var errored bool
+			plugin.OnError = func(*Plugin, error) { errored = true }
+			got, err := pluginValidation(plugin, tt.labels).Execute()
+			if got != tt.want {
+				t.Errorf("Plugin.Run() = %v, want %v", got, tt.want)
+			}
+			if (err != nil) != (tt.wantErr != "") || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Plugin.Run() error = %v, want %v", err, tt.wantErr)
+			}
+			if errored != tt.fail {
+				t.Errorf("Plugin.OnError() called = %v, want %v", errored, tt.fail)
+			}
+		})
+	}
+}
+
+func TestPluginCircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	server := &catalogPlugin{fail: true}
+	plugin := &Plugin{
+		Name:     "service-catalog",
+		Protocol: PluginProtocolGRPC,
+		Endpoint: startGRPCPlugin(t, server),
+		Insecure: true,
+		CircuitBreaker: PluginCircuitBreaker{
+			FailureThreshold: 2,
+			ResetTimeout:     metav1.Duration{Duration: time.Minute},
+		},
+	}
+
+	if err := plugin.Connect(); err != nil {
+		t.Fatalf("Plugin.Connect() error = %v", err)
+	}
+
+	defer plugin.Close()
+
+	now := time.Now()
+	plugin.breaker.now = func() time.Time { return now }
+
+	// the circuit opens after the failure threshold and the plugin is no longer called
+	for i := 0; i < 4; i++ {
+		//nolint:errcheck
+		_, _ = pluginValidation(plugin, nil).Execute()
+	}
+
+	if calls := atomic.LoadInt32(&server.calls); calls != 2 {
+		t.Errorf("Plugin.Run() calls with open circuit = %v, want %v", calls, 2)
+	}
+
+	// a trial call is permitted after the reset timeout, which closes the circuit on success
+	now = now.Add(time.Minute)
+	server.fail = false
+
+	if got, err := pluginValidation(plugin, map[string]string{"team": "platform"}).Execute(); !got || err != nil {
+		t.Errorf("Plugin.Run() after reset timeout = %v, %v, want %v", got, err, true)
+	}
+
+	if calls := atomic.LoadInt32(&server.calls); calls != 3 {
+		t.Errorf("Plugin.Run() calls after reset timeout = %v, want %v", calls, 3)
+	}
+}
+
+// unconvertiblePod is a pod which is unable to be converted to an unstructured object.
+type unconvertiblePod struct {
+	corev1.Pod
+}
+
+func (pod *unconvertiblePod) MarshalJSON() ([]byte, error) {
+	return nil, errPluginUnavailableTest
+}
+
+func TestPluginCircuitBreakerConversionFailure(t *testing.T) {
+	t.Parallel()
+
+	server := &catalogPlugin{fail: true}
+	plugin := &Plugin{
+		Name:          "service-catalog",
+		Protocol:      PluginProtocolGRPC,
+		Endpoint:      startGRPCPlugin(t, server),
+		Insecure:      true,
+		FailurePolicy: PluginFailurePolicyIgnore,
+		CircuitBreaker: PluginCircuitBreaker{
+			FailureThreshold: 1,
+			ResetTimeout:     metav1.Duration{Duration: time.Minute},
+		},
+	}
+
+	if err := plugin.Connect(); err != nil {
+		t.Fatalf("Plugin.Connect() error = %v", err)
+	}
+
+	defer plugin.Close()
+
+	now := time.Now()
+	plugin.breaker.now = func() time.Time { return now }
+
+	// open the circuit, and then wait for the reset timeout so that it is half-open
+	//nolint:errcheck
+	_, _ = pluginValidation(plugin, nil).Execute()
+
+	now = now.Add(time.Minute)
+	server.fail = false
+
+	// an object which is unable to be converted must not take the trial call
+	validation := pluginValidation(plugin, nil)
+	validation.Resource = &unconvertiblePod{Pod: *validation.Resource.(*corev1.Pod)}
+
+	if _, err := plugin.call(validation); !errors.Is(err, ErrPluginUnavailable) {
+		t.Errorf("Plugin.call() with unconvertible object error = %v, want %v", err, ErrPluginUnavailable)
+	}
+
+	if _, err := plugin.call(pluginValidation(plugin, map[string]string{"team": "platform"})); err != nil {
+		t.Errorf("Plugin.call() after conversion failure error = %v, want trial call", err)
+	}
+
+	if calls := atomic.LoadInt32(&server.calls); calls != 2 {
+		t.Errorf("Plugin.call() calls = %v, want %v", calls, 2)
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package validate
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const (
+	// PluginGRPCMethod is the full name of the grpc method called on a validation plugin.
+	PluginGRPCMethod = "/podsecuritywebhook.v1.ValidationPlugin/Validate"
+
+	// pluginCodecName is the name of the grpc codec used to call validation plugins, which
+	// results in a content type of application/grpc+json.
+	pluginCodecName = "json"
+
+	// maxPluginResponseBytes is the maximum size of a response read from an http plugin.
+	maxPluginResponseBytes = 1024 * 1024
+)
+
+// PluginServer is the interface implemented by a grpc validation plugin written in Go.
+type PluginServer interface {
+	Validate(ctx context.Context, request *PluginRequest) (*PluginResponse, error)
+}
+
+// PluginServiceDesc is the grpc service description of a validation plugin.  A plugin written in Go
+// may register itself with a grpc server created with the PluginServerCodec option:
+//
+//	server := grpc.NewServer(validate.PluginServerCodec())
+//	server.RegisterService(&validate.PluginServiceDesc, plugin)
+//
+//nolint:gochecknoglobals
+var PluginServiceDesc = grpc.ServiceDesc{
+	ServiceName: "podsecuritywebhook.v1.ValidationPlugin",
+	HandlerType: (*PluginServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Validate",
+			Handler:    pluginValidateHandler,
+		},
+	},
+	Streams: []grpc.StreamDesc{},
+}
+
+// PluginCodec is the grpc codec used to call validation plugins, which encodes messages as json
+// rather than protocol buffers.
+type PluginCodec struct{}
+
+// Marshal encodes a message as json.
+func (PluginCodec) Marshal(v interface{}) ([]byte, error) { return json.Marshal(v) }
+
+// Unmarshal decodes a message from json.
+func (PluginCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+// Name returns the name of the codec.
+func (PluginCodec) Name() string { return pluginCodecName }
+
+// PluginServerCodec returns the grpc server option which a validation plugin written in Go must use.
+func PluginServerCodec() grpc.ServerOption {
+	return grpc.ForceServerCodec(PluginCodec{})
+}
+
+// pluginValidateHandler is the grpc handler for the validate method of a validation plugin.
+func pluginValidateHandler(
+	server interface{},
+	ctx context.Context,
+	decode func(interface{}) error,
+	interceptor grpc.UnaryServerInterceptor,
+) (interface{}, error) {
+	request := &PluginRequest{}
+	if err := decode(request); err != nil {
+		return nil, err
+	}
+
+	if interceptor == nil {
+		return server.(PluginServer).Validate(ctx, request)
+	}
+
+	info := &grpc.UnaryServerInfo{Server: server, FullMethod: PluginGRPCMethod}
+
+	return interceptor(ctx, request, info, func(ctx context.Context, request interface{}) (interface{}, error) {
+		return server.(PluginServer).Validate(ctx, request.(*PluginRequest))
+	})
+}
+
+// grpcPluginTransport calls a validation plugin as a grpc service.
+type grpcPluginTransport struct {
+	conn *grpc.ClientConn
+}
+
+// newGRPCPluginTransport returns a new grpc plugin transport.  The connection is established
+// lazily so that a plugin which is unavailable at startup does not prevent the webhook from
+// starting.
+func newGRPCPluginTransport(endpoint string, plaintext bool) (*grpcPluginTransport, error) {
+	transportCredentials := credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	if plaintext {
+		transportCredentials = insecure.NewCredentials()
+	}
+
+	conn, err := grpc.Dial(
+		endpoint,
+		grpc.WithTransportCredentials(transportCredentials),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(PluginCodec{})),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w - unable to create grpc connection to [%s]", err, endpoint)
+	}
+
+	return &grpcPluginTransport{conn: conn}, nil
+}
+
+func (transport *grpcPluginTransport) call(ctx context.Context, request *PluginRequest) (*PluginResponse, error) {
+	response := &PluginResponse{}
+	if err := transport.conn.Invoke(ctx, PluginGRPCMethod, request, response); err != nil {
+		return nil, fmt.Errorf("%w - grpc request failed", err)
+	}
+
+	return response, nil
+}
+
+func (transport *grpcPluginTransport) close() error {
+	return transport.conn.Close()
+}
+
+// httpPluginTransport calls a validation plugin by posting json to an http endpoint.
+type httpPluginTransport struct {
+	endpoint string
+	client   *http.Client
+}
+
+// newHTTPPluginTransport returns a new http plugin transport.
+func newHTTPPluginTransport(endpoint string) (*httpPluginTransport, error) {
+	if _, err := url.ParseRequestURI(endpoint); err != nil {
+		return nil, fmt.Errorf("%w - invalid http endpoint [%s]", err, endpoint)
+	}
+
+	return &httpPluginTransport{endpoint: endpoint, client: &http.Client{}}, nil
+}
+
+func (transport *httpPluginTransport) call(ctx context.Context, request *PluginRequest) (*PluginResponse, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("%w - unable to encode plugin request", err)
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, transport.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("%w - unable to create plugin request", err)
+	}
+
+	httpRequest.Header.Set("Content-Type", "application/json")
+
+	httpResponse, err := transport.client.Do(httpRequest)
+	if err != nil {
+		return nil, fmt.Errorf("%w - http request failed", err)
+	}
+
+	defer httpResponse.Body.Close()
+
+	if httpResponse.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response status [%d]", httpResponse.StatusCode)
+	}
+
+	response := &PluginResponse{}
+	if err := json.NewDecoder(io.LimitReader(httpResponse.Body, maxPluginResponseBytes)).Decode(response); err != nil {
+		return nil, fmt.Errorf("%w - unable to decode plugin response", err)
+	}
+
+	return response, nil
+}
+
+func (transport *httpPluginTransport) close() error {
+	transport.client.CloseIdleConnections()
+
+	return nil
+}
@@ -0,0 +1,151 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	PolicyConfigEnv = "POLICY_CONFIG"
+)
+
+var (
+	ErrPolicyConfigInvalid = errors.New("invalid policy configuration")
+
+	// validationNameFormat is the format of a validation name, which must be usable in both an
+	// environment variable and an annotation.
+	validationNameFormat = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+)
+
+// PolicyConfig is the policy configuration for the webhook, which is loaded from a yaml file.
+type PolicyConfig struct {
+	CustomValidations []*CustomValidation `json:"customValidations,omitempty"`
+	Plugins           []*Plugin           `json:"plugins,omitempty"`
+
+	ResourceRequirements *ResourceRequirementsConfig `json:"resourceRequirements,omitempty"`
+	ImageSignatures      *ImageSignaturesConfig      `json:"imageSignatures,omitempty"`
+	ImageMirrors         []*ImageMirror              `json:"imageMirrors,omitempty"`
+	TrustedRegistries    *TrustedRegistriesConfig    `json:"trustedRegistries,omitempty"`
+}
+
+// PolicyConfigFromEnv loads the policy configuration from the file set in the POLICY_CONFIG
+// environment variable.  An empty policy configuration is returned if the variable is unset.
+func PolicyConfigFromEnv() (*PolicyConfig, error) {
+	path := os.Getenv(PolicyConfigEnv)
+	if path == "" {
+		return &PolicyConfig{}, nil
+	}
+
+	return LoadPolicyConfig(path)
+}
+
+// LoadPolicyConfig loads the policy configuration from a yaml file.  Each custom validation
+// is compiled and type-checked, and each plugin is validated, when loaded so that an invalid
+// configuration is reported at startup rather than at admission time.
+func LoadPolicyConfig(path string) (*PolicyConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w - unable to read policy configuration file [%s]", err, path)
+	}
+
+	config := &PolicyConfig{}
+	if err := yaml.UnmarshalStrict(content, config); err != nil {
+		return nil, fmt.Errorf("%w - unable to parse policy configuration file [%s]; %s", ErrPolicyConfigInvalid, path, err)
+	}
+
+	if err := config.compile(); err != nil {
+		return nil, fmt.Errorf("%w - policy configuration file [%s]", err, path)
+	}
+
+	return config, nil
+}
+
+// Close closes the clients used to call each of the plugins in the policy configuration.
+func (config *PolicyConfig) Close() error {
+	for _, plugin := range config.Plugins {
+		if err := plugin.Close(); err != nil {
+			return fmt.Errorf("%w - unable to close plugin [%s]", err, plugin.Name)
+		}
+	}
+
+	return nil
+}
+
+// compile validates the policy configuration, compiling each of the custom validations and
+// connecting each of the plugins.
+func (config *PolicyConfig) compile() error {
+	if config.ResourceRequirements != nil {
+		if err := config.ResourceRequirements.validate(false); err != nil {
+			return err
+		}
+	}
+
+	if config.ImageSignatures != nil {
+		if err := config.ImageSignatures.validate(); err != nil {
+			return err
+		}
+	}
+
+	if config.TrustedRegistries != nil {
+		if err := config.TrustedRegistries.validate(); err != nil {
+			return err
+		}
+	}
+
+	for _, mirror := range config.ImageMirrors {
+		if err := mirror.validate(); err != nil {
+			return err
+		}
+	}
+
+	names := map[string]bool{}
+
+	for _, custom := range config.CustomValidations {
+		if err := checkValidationName(custom.Name, names); err != nil {
+			return err
+		}
+
+		if err := custom.Compile(); err != nil {
+			return err
+		}
+	}
+
+	for _, plugin := range config.Plugins {
+		if err := checkValidationName(plugin.Name, names); err != nil {
+			return err
+		}
+
+		if err := plugin.Connect(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkValidationName checks that the name of a validation from the policy configuration is valid
+// and does not match a built-in validation or a validation which has already been seen.
+func checkValidationName(name string, seen map[string]bool) error {
+	if !validationNameFormat.MatchString(name) {
+		return fmt.Errorf(
+			"%w - validation name [%s] must consist of lower case alphanumeric characters or '-'",
+			ErrPolicyConfigInvalid,
+			name,
+		)
+	}
+
+	if IsBuiltin(name) || seen[name] {
+		return fmt.Errorf("%w - duplicate validation name [%s]", ErrPolicyConfigInvalid, name)
+	}
+
+	seen[name] = true
+
+	return nil
+}
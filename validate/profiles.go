@@ -0,0 +1,90 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package validate
+
+import (
+	"errors"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/nukleros/pod-security-webhook/resources"
+)
+
+const (
+	AppArmorProfileValidationName = "apparmor-profile"
+	SELinuxOptionsValidationName  = "selinux-options"
+)
+
+var (
+	ErrContainerAppArmorProfile = errors.New("unable to permit container with apparmor profile other than runtime/default or localhost")
+	ErrContainerSELinuxOptions  = errors.New("unable to permit container with custom selinux user, role or type")
+)
+
+// allowedSELinuxTypes is the set of selinux types which may be set, as defined by the baseline pod
+// security standard.
+//
+//nolint:gochecknoglobals
+var allowedSELinuxTypes = map[string]bool{
+	"":                 true,
+	"container_t":      true,
+	"container_init_t": true,
+	"container_kvm_t":  true,
+}
+
+// AppArmorProfile validates whether a container in a pod spec overrides the default apparmor
+// profile with a profile other than the runtime default or a profile loaded on the node.
+func AppArmorProfile(validation *Validation) (bool, error) {
+	containersWithUnsafeProfile := []corev1.Container{}
+
+	annotations := resources.GetPodAnnotations(validation.Resource)
+
+	allContainers := []corev1.Container{}
+	allContainers = append(append(allContainers, validation.PodSpec.InitContainers...), validation.PodSpec.Containers...)
+
+	for i := range allContainers {
+		profile := resources.EffectiveAppArmorProfile(annotations, allContainers[i].Name)
+		if profile == "" ||
+			profile == corev1.AppArmorBetaProfileRuntimeDefault ||
+			strings.HasPrefix(profile, corev1.AppArmorBetaProfileNamePrefix) {
+			continue
+		}
+
+		containersWithUnsafeProfile = append(containersWithUnsafeProfile, allContainers[i])
+	}
+
+	if len(containersWithUnsafeProfile) == 0 {
+		return true, nil
+	}
+
+	return validation.Failed(ErrContainerAppArmorProfile, containersWithUnsafeProfile...)
+}
+
+// SELinuxOptions validates whether a container in a pod spec sets a custom selinux user or role, or
+// an selinux type other than the container types.
+func SELinuxOptions(validation *Validation) (bool, error) {
+	containersWithCustomOptions := []corev1.Container{}
+
+	allContainers := []corev1.Container{}
+	allContainers = append(append(allContainers, validation.PodSpec.InitContainers...), validation.PodSpec.Containers...)
+
+	for i := range allContainers {
+		options := resources.EffectiveSELinuxOptions(validation.PodSpec.SecurityContext, allContainers[i].SecurityContext)
+		if options == nil {
+			continue
+		}
+
+		if options.User == "" && options.Role == "" && allowedSELinuxTypes[options.Type] {
+			continue
+		}
+
+		containersWithCustomOptions = append(containersWithCustomOptions, allContainers[i])
+	}
+
+	if len(containersWithCustomOptions) == 0 {
+		return true, nil
+	}
+
+	return validation.Failed(ErrContainerSELinuxOptions, containersWithCustomOptions...)
+}
@@ -0,0 +1,196 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/nukleros/pod-security-webhook/resources"
+)
+
+const (
+	UserGroupRangesValidationName = "user-group-ranges"
+
+	AllowedUIDRangesEnv = "ALLOWED_UID_RANGES"
+	AllowedGIDRangesEnv = "ALLOWED_GID_RANGES"
+
+	AllowedUIDRangesAnnotation = "pod-security-webhook.nukleros.io/uid-ranges"
+	AllowedGIDRangesAnnotation = "pod-security-webhook.nukleros.io/gid-ranges"
+)
+
+var (
+	ErrPodUserGroupRanges = errors.New("unable to permit pod with user or group ids outside of the allowed ranges")
+	ErrIDRangeInvalid     = errors.New("invalid id range")
+)
+
+// IDRange is an inclusive range of user or group ids.
+type IDRange struct {
+	Min int64
+	Max int64
+}
+
+// String returns the string representation of an id range.
+func (idRange IDRange) String() string {
+	if idRange.Min == idRange.Max {
+		return strconv.FormatInt(idRange.Min, 10)
+	}
+
+	return fmt.Sprintf("%d-%d", idRange.Min, idRange.Max)
+}
+
+// IDRanges is a set of id ranges.
+type IDRanges []IDRange
+
+// Contains returns whether an id is within any of the ranges.
+func (idRanges IDRanges) Contains(id int64) bool {
+	for _, idRange := range idRanges {
+		if id >= idRange.Min && id <= idRange.Max {
+			return true
+		}
+	}
+
+	return false
+}
+
+// String returns the string representation of a set of id ranges.
+func (idRanges IDRanges) String() string {
+	ranges := make([]string, len(idRanges))
+	for i := range idRanges {
+		ranges[i] = idRanges[i].String()
+	}
+
+	return strings.Join(ranges, ",")
+}
+
+// ParseIDRanges parses a comma-separated list of id ranges, each of which is either a single id or
+// an inclusive range in the form min-max, for example 1000-1999,2500.
+func ParseIDRanges(value string) (IDRanges, error) {
+	idRanges := IDRanges{}
+
+	for _, rangeValue := range strings.Split(value, ",") {
+		rangeValue = strings.TrimSpace(rangeValue)
+		if rangeValue == "" {
+			continue
+		}
+
+		bounds := strings.SplitN(rangeValue, "-", 2)
+
+		minimum, err := strconv.ParseInt(strings.TrimSpace(bounds[0]), 10, 64)
+		if err != nil || minimum < 0 {
+			return nil, fmt.Errorf("%w [%s]", ErrIDRangeInvalid, rangeValue)
+		}
+
+		maximum := minimum
+
+		if len(bounds) == 2 {
+			if maximum, err = strconv.ParseInt(strings.TrimSpace(bounds[1]), 10, 64); err != nil || maximum < minimum {
+				return nil, fmt.Errorf("%w [%s]", ErrIDRangeInvalid, rangeValue)
+			}
+		}
+
+		idRanges = append(idRanges, IDRange{Min: minimum, Max: maximum})
+	}
+
+	return idRanges, nil
+}
+
+// UserGroupRanges validates whether the effective runAsUser, runAsGroup, fsGroup and supplementalGroups
+// of a pod spec are within the allowed ranges.  The allowed ranges are set by an annotation on the
+// namespace, or globally by an environment variable if the namespace does not have an annotation.  If
+// no ranges are set, we can skip this validation check.  A container must set runAsUser, either
+// directly or through the pod, when user id ranges are set.
+func UserGroupRanges(validation *Validation) (bool, error) {
+	uidRanges, err := validation.allowedIDRanges(AllowedUIDRangesAnnotation, AllowedUIDRangesEnv)
+	if err != nil {
+		return validation.Failed(err)
+	}
+
+	gidRanges, err := validation.allowedIDRanges(AllowedGIDRangesAnnotation, AllowedGIDRangesEnv)
+	if err != nil {
+		return validation.Failed(err)
+	}
+
+	violations := []string{}
+
+	podSec := validation.PodSpec.SecurityContext
+
+	if len(gidRanges) > 0 && podSec != nil {
+		if podSec.FSGroup != nil && !gidRanges.Contains(*podSec.FSGroup) {
+			violations = append(violations, fmt.Sprintf("fsGroup [%d]", *podSec.FSGroup))
+		}
+
+		for _, group := range podSec.SupplementalGroups {
+			if !gidRanges.Contains(group) {
+				violations = append(violations, fmt.Sprintf("supplementalGroups [%d]", group))
+			}
+		}
+	}
+
+	allContainers := []corev1.Container{}
+	allContainers = append(append(allContainers, validation.PodSpec.InitContainers...), validation.PodSpec.Containers...)
+
+	for i := range allContainers {
+		if len(uidRanges) > 0 {
+			runAsUser := resources.EffectiveRunAsUser(podSec, allContainers[i].SecurityContext)
+
+			switch {
+			case runAsUser == nil:
+				violations = append(violations, fmt.Sprintf("container [%s] runAsUser [unset]", allContainers[i].Name))
+			case !uidRanges.Contains(*runAsUser):
+				violations = append(violations, fmt.Sprintf("container [%s] runAsUser [%d]", allContainers[i].Name, *runAsUser))
+			}
+		}
+
+		if len(gidRanges) > 0 {
+			runAsGroup := resources.EffectiveRunAsGroup(podSec, allContainers[i].SecurityContext)
+			if runAsGroup != nil && !gidRanges.Contains(*runAsGroup) {
+				violations = append(violations, fmt.Sprintf("container [%s] runAsGroup [%d]", allContainers[i].Name, *runAsGroup))
+			}
+		}
+	}
+
+	if len(violations) > 0 {
+		return validation.Failed(fmt.Errorf(
+			"%w [uid=%s gid=%s] - %s",
+			ErrPodUserGroupRanges,
+			uidRanges,
+			gidRanges,
+			strings.Join(violations, ", "),
+		))
+	}
+
+	return true, nil
+}
+
+// allowedIDRanges returns the allowed id ranges from the namespace annotation, or the environment
+// variable if the namespace does not have the annotation.  An error is returned if the namespace is
+// unavailable, rather than falling back to the environment variable, as the namespace may restrict
+// the ranges further.
+func (validation *Validation) allowedIDRanges(annotation, env string) (IDRanges, error) {
+	if validation.Namespace == nil {
+		return nil, fmt.Errorf("%w [%s] to read annotation [%s]", ErrNamespaceUnavailable, validation.Resource.GetNamespace(), annotation)
+	}
+
+	if value, ok := validation.Namespace.GetAnnotations()[annotation]; ok {
+		idRanges, err := ParseIDRanges(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w from namespace annotation [%s]", err, annotation)
+		}
+
+		return idRanges, nil
+	}
+
+	idRanges, err := ParseIDRanges(os.Getenv(env))
+	if err != nil {
+		return nil, fmt.Errorf("%w from environment variable [%s]", err, env)
+	}
+
+	return idRanges, nil
+}
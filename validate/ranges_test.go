@@ -0,0 +1,81 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package validate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestParseIDRanges(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		value   string
+		want    IDRanges
+		wantErr bool
+	}{
+		{
+			name:    "ensure an empty value returns no ranges",
+			value:   "",
+			want:    IDRanges{},
+			wantErr: false,
+		},
+		{
+			name:    "ensure ranges and single ids are parsed",
+			value:   "1000-1999, 2500",
+			want:    IDRanges{{Min: 1000, Max: 1999}, {Min: 2500, Max: 2500}},
+			wantErr: false,
+		},
+		{
+			name:    "ensure a range with a maximum below the minimum returns an error",
+			value:   "1999-1000",
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "ensure a non-numeric range returns an error",
+			value:   "1000/10000",
+			want:    nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := ParseIDRanges(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseIDRanges() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseIDRanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestValidateUserGroupRangesWithoutNamespace ensures that the ranges fail closed when the
+// namespace is unavailable, which can not be reproduced by the offline webhook used by the policy
+// test fixtures.
+//
+//nolint:paralleltest
+func TestValidateUserGroupRangesWithoutNamespace(t *testing.T) {
+	t.Setenv(AllowedUIDRangesEnv, "1000-1999")
+	t.Setenv(AllowedGIDRangesEnv, "")
+
+	podSpec := validPodSpec()
+
+	got, err := UserGroupRanges(&Validation{Resource: &corev1.Pod{Spec: *podSpec}, PodSpec: podSpec})
+	if got || !errors.Is(err, ErrNamespaceUnavailable) {
+		t.Errorf("UserGroupRanges() = %v, %v, want false, %v", got, err, ErrNamespaceUnavailable)
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	CPURequirementsValidationName    = "unset-cpu-requirements"
+	MemoryRequirementsValidationName = "unset-memory-requirements"
+)
+
+var (
+	ErrContainerResourceRequirements = errors.New("unable to permit container with invalid resource requirements")
+	ErrResourceRequirementsInvalid   = errors.New("invalid resource requirements configuration")
+)
+
+// ResourceRequirementsConfig is the policy configuration of the resource requirements validations.
+// Requirements may be set per resource, and overridden per resource for a specific kind, for
+// example to only require memory limits for jobs.
+type ResourceRequirementsConfig struct {
+	CPU    *ResourceRequirement                   `json:"cpu,omitempty"`
+	Memory *ResourceRequirement                   `json:"memory,omitempty"`
+	Kinds  map[string]*ResourceRequirementsConfig `json:"kinds,omitempty"`
+}
+
+// ResourceRequirement is the requirement for an individual resource of each container.  The
+// minimum, maximum and maximum limit to request ratio follow the semantics of a LimitRange.
+type ResourceRequirement struct {
+	Requests             bool               `json:"requests,omitempty"`
+	Limits               bool               `json:"limits,omitempty"`
+	Min                  *resource.Quantity `json:"min,omitempty"`
+	Max                  *resource.Quantity `json:"max,omitempty"`
+	MaxLimitRequestRatio *resource.Quantity `json:"maxLimitRequestRatio,omitempty"`
+}
+
+// CPURequirements validates whether each container in a pod spec meets the cpu requirements.
+func CPURequirements(validation *Validation) (bool, error) {
+	return resourceRequirements(validation, corev1.ResourceCPU)
+}
+
+// MemoryRequirements validates whether each container in a pod spec meets the memory requirements.
+func MemoryRequirements(validation *Validation) (bool, error) {
+	return resourceRequirements(validation, corev1.ResourceMemory)
+}
+
+// resourceRequirements validates whether each container in a pod spec meets the requirements
+// for a resource.  If no requirements are configured for the resource, we can skip this
+// validation check.
+func resourceRequirements(validation *Validation, name corev1.ResourceName) (bool, error) {
+	if validation.Policy == nil || validation.Policy.ResourceRequirements == nil {
+		return true, nil
+	}
+
+	requirement := validation.Policy.ResourceRequirements.requirementFor(
+		validation.Resource.GetObjectKind().GroupVersionKind().Kind,
+		name,
+	)
+	if requirement == nil {
+		return true, nil
+	}
+
+	allContainers := []corev1.Container{}
+	allContainers = append(append(allContainers, validation.PodSpec.InitContainers...), validation.PodSpec.Containers...)
+
+	violations := []string{}
+	failedContainers := []corev1.Container{}
+
+	for i := range allContainers {
+		containerViolations := requirement.violations(allContainers[i].Resources, name)
+		if len(containerViolations) == 0 {
+			continue
+		}
+
+		for _, violation := range containerViolations {
+			violations = append(violations, fmt.Sprintf("container [%s] %s", allContainers[i].Name, violation))
+		}
+
+		failedContainers = append(failedContainers, allContainers[i])
+	}
+
+	if len(failedContainers) > 0 {
+		return validation.Failed(
+			fmt.Errorf("%w - %s", ErrContainerResourceRequirements, strings.Join(violations, ", ")),
+			failedContainers...,
+		)
+	}
+
+	return true, nil
+}
+
+// requirementFor returns the requirement for a resource of a given kind.  A requirement for the
+// kind takes precedence over the requirement for all kinds.
+func (config *ResourceRequirementsConfig) requirementFor(kind string, name corev1.ResourceName) *ResourceRequirement {
+	if kindConfig, ok := config.Kinds[kind]; ok && kindConfig != nil {
+		if requirement := kindConfig.requirement(name); requirement != nil {
+			return requirement
+		}
+	}
+
+	return config.requirement(name)
+}
+
+// requirement returns the requirement for a resource.
+func (config *ResourceRequirementsConfig) requirement(name corev1.ResourceName) *ResourceRequirement {
+	switch name {
+	case corev1.ResourceCPU:
+		return config.CPU
+	case corev1.ResourceMemory:
+		return config.Memory
+	default:
+		return nil
+	}
+}
+
+// violations returns each violation of the requirement for the requirements of a container.
+func (requirement *ResourceRequirement) violations(requirements corev1.ResourceRequirements, name corev1.ResourceName) []string {
+	violations := []string{}
+
+	request, hasRequest := requirements.Requests[name]
+	limit, hasLimit := requirements.Limits[name]
+
+	if requirement.Requests && !hasRequest {
+		violations = append(violations, fmt.Sprintf("missing resources.requests.%s", name))
+	}
+
+	if requirement.Limits && !hasLimit {
+		violations = append(violations, fmt.Sprintf("missing resources.limits.%s", name))
+	}
+
+	for _, field := range []struct {
+		name     string
+		quantity resource.Quantity
+		isSet    bool
+	}{
+		{name: "requests", quantity: request, isSet: hasRequest},
+		{name: "limits", quantity: limit, isSet: hasLimit},
+	} {
+		if !field.isSet {
+			continue
+		}
+
+		if requirement.Min != nil && field.quantity.Cmp(*requirement.Min) < 0 {
+			violations = append(violations, fmt.Sprintf(
+				"resources.%s.%s [%s] is below the minimum [%s]", field.name, name, field.quantity.String(), requirement.Min,
+			))
+		}
+
+		if requirement.Max != nil && field.quantity.Cmp(*requirement.Max) > 0 {
+			violations = append(violations, fmt.Sprintf(
+				"resources.%s.%s [%s] is above the maximum [%s]", field.name, name, field.quantity.String(), requirement.Max,
+			))
+		}
+	}
+
+	if requirement.MaxLimitRequestRatio != nil && hasRequest && hasLimit && !request.IsZero() {
+		ratio := float64(limit.MilliValue()) / float64(request.MilliValue())
+
+		if ratio > requirement.MaxLimitRequestRatio.AsApproximateFloat64() {
+			violations = append(violations, fmt.Sprintf(
+				"%s limit to request ratio [%.2f] is above the maximum [%s]", name, ratio, requirement.MaxLimitRequestRatio,
+			))
+		}
+	}
+
+	return violations
+}
+
+// validate validates the resource requirements configuration.
+func (config *ResourceRequirementsConfig) validate(nested bool) error {
+	if nested && len(config.Kinds) > 0 {
+		return fmt.Errorf("%w - kinds may not be nested", ErrResourceRequirementsInvalid)
+	}
+
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		requirement := config.requirement(name)
+		if requirement == nil {
+			continue
+		}
+
+		if requirement.Min != nil && requirement.Max != nil && requirement.Min.Cmp(*requirement.Max) > 0 {
+			return fmt.Errorf("%w - %s minimum [%s] is above the maximum [%s]", ErrResourceRequirementsInvalid, name, requirement.Min, requirement.Max)
+		}
+
+		if requirement.MaxLimitRequestRatio != nil && requirement.MaxLimitRequestRatio.AsApproximateFloat64() < 1 {
+			return fmt.Errorf("%w - %s maximum limit to request ratio [%s] must be at least 1", ErrResourceRequirementsInvalid, name, requirement.MaxLimitRequestRatio)
+		}
+	}
+
+	for kind, kindConfig := range config.Kinds {
+		if kindConfig == nil {
+			continue
+		}
+
+		if err := kindConfig.validate(true); err != nil {
+			return fmt.Errorf("%w for kind [%s]", err, kind)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package validate
+
+// ResultStatus represents the outcome of an individual validation.
+type ResultStatus string
+
+const (
+	ResultPass ResultStatus = "pass"
+	ResultFail ResultStatus = "fail"
+	ResultWarn ResultStatus = "warn"
+	ResultSkip ResultStatus = "skip"
+)
+
+// Result is the outcome of an individual validation against a resource.
+type Result struct {
+	Validation string       `json:"validation"`
+	Status     ResultStatus `json:"status"`
+	Message    string       `json:"message,omitempty"`
+	SkipReason SkipReason   `json:"skipReason,omitempty"`
+	ExemptedBy string       `json:"exemptedBy,omitempty"`
+
+	// Containers are the names of the containers which failed the validation, if any.
+	Containers []string `json:"containers,omitempty"`
+}
+
+// NewSkipResult returns a result for a validation which was skipped.
+func NewSkipResult(name string, reason SkipReason, message string) *Result {
+	return &Result{
+		Validation: name,
+		Status:     ResultSkip,
+		Message:    message,
+		SkipReason: reason,
+	}
+}
+
+// Result executes the validation logic and returns the result of the validation.
+func (validation *Validation) Result() *Result {
+	isValid, err := validation.Execute()
+	if isValid && err == nil {
+		return &Result{Validation: validation.Name, Status: ResultPass, ExemptedBy: validation.ExemptedBy}
+	}
+
+	result := &Result{
+		Validation: validation.Name,
+		Status:     ResultFail,
+		ExemptedBy: validation.ExemptedBy,
+		Containers: validation.FailedContainers,
+	}
+
+	if validation.Action == EnforcementActionWarn {
+		result.Status = ResultWarn
+	}
+
+	if err != nil {
+		result.Message = err.Error()
+	} else {
+		result.Message = "failed validation " + validation.Name
+	}
+
+	return result
+}
@@ -0,0 +1,163 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	SchedulingValidationName = "scheduling-restrictions"
+
+	AllowedTolerationsAnnotation = "pod-security-webhook.nukleros.io/allowed-tolerations"
+	NodeSelectorAnnotation       = "pod-security-webhook.nukleros.io/node-selector"
+
+	// wildcardToleration is the allowed toleration value which permits a toleration with an empty
+	// key, which tolerates all taints.
+	wildcardToleration = "*"
+)
+
+var (
+	ErrPodNodeName               = errors.New("unable to permit pod with node name set")
+	ErrPodControlPlaneToleration = errors.New("unable to permit pod tolerating control plane taints")
+	ErrPodNodeSelector           = errors.New("unable to permit pod not selecting the nodes of its namespace")
+	ErrNodeSelectorInvalid       = errors.New("invalid node selector")
+)
+
+// controlPlaneTaints are the keys of the taints applied to control plane nodes.
+//
+//nolint:gochecknoglobals
+var controlPlaneTaints = []string{
+	"node-role.kubernetes.io/control-plane",
+	"node-role.kubernetes.io/master",
+}
+
+// Scheduling validates whether a pod spec bypasses node isolation.  Pods may not be created with a node
+// name, which bypasses the scheduler, and may not tolerate the taints of control plane nodes unless
+// the toleration is allowed by an annotation on the namespace.  If the namespace has a node selector
+// annotation, the pod must also select only the nodes of its namespace using either a node selector or
+// required node affinity.  The validation fails if the namespace is unavailable, as its annotations
+// may restrict scheduling further.
+func Scheduling(validation *Validation) (bool, error) {
+	// pods which have been scheduled have a node name set, so this is only checked for pods on create and
+	// for the pod templates of workloads
+	kind := validation.Resource.GetObjectKind().GroupVersionKind().Kind
+	if validation.PodSpec.NodeName != "" && (kind != "Pod" || validation.Operation == admissionv1.Create) {
+		return validation.Failed(fmt.Errorf("%w [%s]", ErrPodNodeName, validation.PodSpec.NodeName))
+	}
+
+	if validation.Namespace == nil {
+		return validation.Failed(fmt.Errorf("%w [%s] to read scheduling restrictions", ErrNamespaceUnavailable, validation.Resource.GetNamespace()))
+	}
+
+	allowedTolerations := map[string]bool{}
+
+	for _, key := range strings.Split(validation.Namespace.GetAnnotations()[AllowedTolerationsAnnotation], ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			allowedTolerations[key] = true
+		}
+	}
+
+	for _, toleration := range validation.PodSpec.Tolerations {
+		if !toleratesControlPlane(toleration) {
+			continue
+		}
+
+		key := toleration.Key
+		if key == "" {
+			key = wildcardToleration
+		}
+
+		if !allowedTolerations[key] {
+			return validation.Failed(fmt.Errorf("%w [%s]", ErrPodControlPlaneToleration, key))
+		}
+	}
+
+	nodeSelector, err := parseNodeSelector(validation.Namespace.GetAnnotations()[NodeSelectorAnnotation])
+	if err != nil {
+		return validation.Failed(err)
+	}
+
+	for key, value := range nodeSelector {
+		if !selectsNodes(validation.PodSpec, key, value) {
+			return validation.Failed(fmt.Errorf("%w [%s=%s]", ErrPodNodeSelector, key, value))
+		}
+	}
+
+	return true, nil
+}
+
+// toleratesControlPlane returns whether a toleration tolerates the taints of control plane nodes.  A
+// toleration with an empty key and the exists operator tolerates all taints.
+func toleratesControlPlane(toleration corev1.Toleration) bool {
+	if toleration.Key == "" {
+		return toleration.Operator == corev1.TolerationOpExists
+	}
+
+	for _, taint := range controlPlaneTaints {
+		if toleration.Key == taint {
+			return true
+		}
+	}
+
+	return false
+}
+
+// selectsNodes returns whether a pod spec only selects nodes with a given label, either with its node
+// selector or with each of the terms of its required node affinity.
+func selectsNodes(podSpec *corev1.PodSpec, key, value string) bool {
+	if podSpec.NodeSelector[key] == value {
+		return true
+	}
+
+	if podSpec.Affinity == nil || podSpec.Affinity.NodeAffinity == nil {
+		return false
+	}
+
+	required := podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if required == nil || len(required.NodeSelectorTerms) == 0 {
+		return false
+	}
+
+TERMS:
+	for _, term := range required.NodeSelectorTerms {
+		for _, expression := range term.MatchExpressions {
+			if expression.Key == key &&
+				expression.Operator == corev1.NodeSelectorOpIn &&
+				len(expression.Values) == 1 &&
+				expression.Values[0] == value {
+				continue TERMS
+			}
+		}
+
+		return false
+	}
+
+	return true
+}
+
+// parseNodeSelector parses a comma-separated list of key=value node labels.
+func parseNodeSelector(value string) (map[string]string, error) {
+	nodeSelector := map[string]string{}
+
+	for _, label := range strings.Split(value, ",") {
+		if label = strings.TrimSpace(label); label == "" {
+			continue
+		}
+
+		pair := strings.SplitN(label, "=", 2)
+		if len(pair) != 2 || pair[0] == "" {
+			return nil, fmt.Errorf("%w [%s] from namespace annotation [%s]", ErrNodeSelectorInvalid, label, NodeSelectorAnnotation)
+		}
+
+		nodeSelector[strings.TrimSpace(pair[0])] = strings.TrimSpace(pair[1])
+	}
+
+	return nodeSelector, nil
+}
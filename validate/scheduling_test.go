@@ -0,0 +1,28 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package validate
+
+import (
+	"errors"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestValidateSchedulingWithoutNamespace ensures that the scheduling restrictions fail closed when
+// the namespace is unavailable, which can not be reproduced by the offline webhook used by the
+// policy test fixtures.
+func TestValidateSchedulingWithoutNamespace(t *testing.T) {
+	t.Parallel()
+
+	got, err := Scheduling(&Validation{
+		Resource: &appsv1.Deployment{TypeMeta: metav1.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"}},
+		PodSpec:  &corev1.PodSpec{},
+	})
+	if got || !errors.Is(err, ErrNamespaceUnavailable) {
+		t.Errorf("Scheduling() = %v, %v, want false, %v", got, err, ErrNamespaceUnavailable)
+	}
+}
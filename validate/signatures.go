@@ -0,0 +1,418 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package validate
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/nukleros/pod-security-webhook/registry"
+)
+
+const (
+	ImageSignatureValidationName = "image-signature"
+
+	// cosignSignatureAnnotation is the annotation on a layer of a cosign signature manifest which
+	// holds the base64 encoded signature of the layer content.
+	cosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+
+	// cosignSignatureType is the critical type of a cosign simple signing payload.
+	cosignSignatureType = "cosign container image signature"
+
+	defaultImageSignatureCacheTTL = 5 * time.Minute
+	defaultImageSignatureTimeout  = 5 * time.Second
+)
+
+var (
+	ErrPodImageSignature       = errors.New("unable to permit pod with images without a trusted signature")
+	ErrImageSignaturesInvalid  = errors.New("invalid image signatures configuration")
+	ErrImageSignatureUnsigned  = errors.New("no signatures found")
+	ErrImageSignatureUntrusted = errors.New("no signature verified by a trusted key")
+	ErrPublicKeyInvalid        = errors.New("invalid public key")
+)
+
+// ImageSignaturesConfig is the policy configuration of the image signature validation.  Images
+// are verified against cosign signatures stored in the registry alongside the image, using the
+// public keys of each policy which matches the image and namespace.
+type ImageSignaturesConfig struct {
+	Policies []*ImageSignaturePolicy `json:"policies"`
+	CacheTTL metav1.Duration         `json:"cacheTTL,omitempty"`
+	Timeout  metav1.Duration         `json:"timeout,omitempty"`
+
+	// Registry is the access layer used to fetch digests and signatures.  It defaults to accessing
+	// registries using the OCI distribution api.
+	Registry registry.Registry `json:"-"`
+
+	verified *ttlCache
+}
+
+// ImageSignaturePolicy is a set of trusted public keys for images from a registry, or a registry
+// and repository prefix, optionally limited to a set of namespaces.  An empty registry matches all
+// images.
+type ImageSignaturePolicy struct {
+	Registry   string   `json:"registry,omitempty"`
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// PublicKeys are PEM encoded public keys, or paths to files containing PEM encoded public keys.
+	PublicKeys []string `json:"publicKeys"`
+
+	keys []crypto.PublicKey
+}
+
+// simpleSigningPayload is the payload signed by cosign, which is only decoded as far as needed to
+// check the digest it refers to.
+type simpleSigningPayload struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+}
+
+// ImageSignature validates whether each image in a pod spec has a cosign signature which is
+// verified by a trusted public key.  Images which are not matched by a policy, and all images when
+// no policies are configured, are not verified.
+func ImageSignature(validation *Validation) (bool, error) {
+	if validation.Policy == nil || validation.Policy.ImageSignatures == nil {
+		return true, nil
+	}
+
+	config := validation.Policy.ImageSignatures
+
+	namespace := validation.Resource.GetNamespace()
+	if validation.Namespace != nil {
+		namespace = validation.Namespace.Name
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.Timeout.Duration)
+	defer cancel()
+
+	allContainers := []corev1.Container{}
+	allContainers = append(append(allContainers, validation.PodSpec.InitContainers...), validation.PodSpec.Containers...)
+
+	violations := []string{}
+
+	for i := range allContainers {
+		matched, keys := config.keysFor(allContainers[i].Image, namespace)
+		if len(keys) == 0 {
+			continue
+		}
+
+		if err := config.verify(ctx, allContainers[i].Image, matched, keys); err != nil {
+			violations = append(violations, fmt.Sprintf("container [%s] image [%s]: %s", allContainers[i].Name, allContainers[i].Image, err))
+		}
+	}
+
+	if len(violations) > 0 {
+		return validation.Failed(fmt.Errorf("%w - %s", ErrPodImageSignature, strings.Join(violations, ", ")))
+	}
+
+	return true, nil
+}
+
+// validate validates the image signatures configuration, parsing each of the public keys and
+// setting defaults.
+func (config *ImageSignaturesConfig) validate() error {
+	for i, policy := range config.Policies {
+		policy.Registry = strings.TrimSuffix(policy.Registry, "/")
+
+		if len(policy.PublicKeys) == 0 {
+			return fmt.Errorf("%w - policy [%d] for registry [%s] has no public keys", ErrImageSignaturesInvalid, i, policy.Registry)
+		}
+
+		policy.keys = make([]crypto.PublicKey, len(policy.PublicKeys))
+
+		for k, publicKey := range policy.PublicKeys {
+			key, err := parsePublicKey(publicKey)
+			if err != nil {
+				return fmt.Errorf("%w - policy [%d] for registry [%s] public key [%d]; %s", ErrImageSignaturesInvalid, i, policy.Registry, k, err)
+			}
+
+			policy.keys[k] = key
+		}
+	}
+
+	if config.CacheTTL.Duration == 0 {
+		config.CacheTTL.Duration = defaultImageSignatureCacheTTL
+	}
+
+	if config.Timeout.Duration == 0 {
+		config.Timeout.Duration = defaultImageSignatureTimeout
+	}
+
+	if config.Registry == nil {
+		config.Registry = registry.NewHTTPRegistry()
+	}
+
+	config.verified = newTTLCache(config.CacheTTL.Duration)
+
+	return nil
+}
+
+// keysFor returns the trusted public keys for an image in a namespace, along with an identifier
+// of the policies they were collected from.  Policies are matched against the fully qualified
+// repository name of the image, so that images without a registry match policies for docker.io.
+// Images which can not be parsed are matched as is, and fail verification.
+func (config *ImageSignaturesConfig) keysFor(image, namespace string) (string, []crypto.PublicKey) {
+	matched := []string{}
+	keys := []crypto.PublicKey{}
+
+	name := image
+	if reference, err := registry.ParseReference(image); err == nil {
+		name = reference.Name()
+	}
+
+	for i, policy := range config.Policies {
+		if policy.Registry != "" && name != policy.Registry && !strings.HasPrefix(name, policy.Registry+"/") {
+			continue
+		}
+
+		if !policy.matchesNamespace(namespace) {
+			continue
+		}
+
+		matched = append(matched, strconv.Itoa(i))
+		keys = append(keys, policy.keys...)
+	}
+
+	return strings.Join(matched, ","), keys
+}
+
+// matchesNamespace returns whether the policy applies to a namespace.
+func (policy *ImageSignaturePolicy) matchesNamespace(namespace string) bool {
+	if len(policy.Namespaces) == 0 {
+		return true
+	}
+
+	for _, policyNamespace := range policy.Namespaces {
+		if policyNamespace == namespace {
+			return true
+		}
+	}
+
+	return false
+}
+
+// verify verifies that an image has a cosign signature which is verified by one of the keys.  The
+// image is resolved to a digest first, so that a tag which is moved to an unsigned image is not
+// trusted.  Tags are resolved for each verification, as a cached digest would trust a tag after it
+// has been moved, while successful verifications are cached per digest and set of policies.
+func (config *ImageSignaturesConfig) verify(ctx context.Context, image, policies string, keys []crypto.PublicKey) error {
+	reference, err := registry.ParseReference(image)
+	if err != nil {
+		return err
+	}
+
+	digest := reference.Digest
+	if digest == "" {
+		if digest, err = config.Registry.Resolve(ctx, reference); err != nil {
+			return err
+		}
+	}
+
+	cacheKey := reference.Name() + "@" + digest + "|" + policies
+	if _, ok := config.verified.get(cacheKey); ok {
+		return nil
+	}
+
+	signatureTag := strings.Replace(digest, ":", "-", 1) + ".sig"
+
+	content, err := config.Registry.Manifest(ctx, reference, signatureTag)
+	if err != nil {
+		if errors.Is(err, registry.ErrNotFound) {
+			return fmt.Errorf("%w for digest [%s]", ErrImageSignatureUnsigned, digest)
+		}
+
+		return err
+	}
+
+	manifest := &registry.Manifest{}
+	if err := json.Unmarshal(content, manifest); err != nil {
+		return fmt.Errorf("%w - unable to decode signature manifest [%s]", err, signatureTag)
+	}
+
+	for _, layer := range manifest.Layers {
+		if verifyErr := config.verifyLayer(ctx, reference, layer, digest, keys); verifyErr == nil {
+			config.verified.set(cacheKey, digest)
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w for digest [%s]", ErrImageSignatureUntrusted, digest)
+}
+
+// verifyLayer verifies a single layer of a cosign signature manifest, which holds the signed payload
+// as its content and the signature as an annotation.
+func (config *ImageSignaturesConfig) verifyLayer(
+	ctx context.Context,
+	reference *registry.Reference,
+	layer registry.Descriptor,
+	digest string,
+	keys []crypto.PublicKey,
+) error {
+	encoded, ok := layer.Annotations[cosignSignatureAnnotation]
+	if !ok {
+		return ErrImageSignatureUnsigned
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("%w - unable to decode signature", err)
+	}
+
+	payload, err := config.Registry.Blob(ctx, reference, layer.Digest)
+	if err != nil {
+		return err
+	}
+
+	if !verifySignature(payload, signature, keys) {
+		return ErrImageSignatureUntrusted
+	}
+
+	// the signature only proves the payload is trusted, so we must also check that the payload
+	// refers to the image being verified.
+	simpleSigning := &simpleSigningPayload{}
+	if err := json.Unmarshal(payload, simpleSigning); err != nil {
+		return fmt.Errorf("%w - unable to decode signature payload", err)
+	}
+
+	if simpleSigning.Critical.Type != cosignSignatureType || simpleSigning.Critical.Image.DockerManifestDigest != digest {
+		return ErrImageSignatureUntrusted
+	}
+
+	return nil
+}
+
+// verifySignature returns whether a signature of a payload is verified by any of the keys.
+func verifySignature(payload, signature []byte, keys []crypto.PublicKey) bool {
+	hash := sha256.Sum256(payload)
+
+	for _, key := range keys {
+		switch publicKey := key.(type) {
+		case *ecdsa.PublicKey:
+			if ecdsa.VerifyASN1(publicKey, hash[:], signature) {
+				return true
+			}
+		case *rsa.PublicKey:
+			if rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, hash[:], signature) == nil {
+				return true
+			}
+		case ed25519.PublicKey:
+			if ed25519.Verify(publicKey, payload, signature) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// parsePublicKey parses a PEM encoded public key, which is read from a file if the value is not
+// PEM encoded.
+func parsePublicKey(value string) (crypto.PublicKey, error) {
+	content := []byte(value)
+
+	if !strings.HasPrefix(strings.TrimSpace(value), "-----BEGIN") {
+		fileContent, err := os.ReadFile(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w - unable to read public key file [%s]", err, value)
+		}
+
+		content = fileContent
+	}
+
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, fmt.Errorf("%w - unable to decode PEM block", ErrPublicKeyInvalid)
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w - %s", ErrPublicKeyInvalid, err)
+	}
+
+	switch key.(type) {
+	case *ecdsa.PublicKey, *rsa.PublicKey, ed25519.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("%w - unsupported key type [%T]", ErrPublicKeyInvalid, key)
+	}
+}
+
+// ttlCache is a concurrency safe cache of string values which expire after a time to live.
+type ttlCache struct {
+	ttl     time.Duration
+	now     func() time.Time
+	mutex   sync.Mutex
+	entries map[string]ttlCacheEntry
+}
+
+type ttlCacheEntry struct {
+	value   string
+	expires time.Time
+}
+
+func newTTLCache(ttl time.Duration) *ttlCache {
+	return &ttlCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: map[string]ttlCacheEntry{},
+	}
+}
+
+// get returns the value for a key if it has not expired.
+func (cache *ttlCache) get(key string) (string, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	entry, ok := cache.entries[key]
+	if !ok {
+		return "", false
+	}
+
+	if !cache.now().Before(entry.expires) {
+		delete(cache.entries, key)
+
+		return "", false
+	}
+
+	return entry.value, true
+}
+
+// set sets the value for a key, removing any expired entries so that the cache does not grow
+// without bound.
+func (cache *ttlCache) set(key, value string) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	now := cache.now()
+
+	for existing, entry := range cache.entries {
+		if !now.Before(entry.expires) {
+			delete(cache.entries, existing)
+		}
+	}
+
+	cache.entries[key] = ttlCacheEntry{value: value, expires: now.Add(cache.ttl)}
+}
@@ -0,0 +1,437 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package validate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/nukleros/pod-security-webhook/registry"
+)
+
+// ociRegistry is an in-process OCI registry which serves manifests and blobs from memory.
+type ociRegistry struct {
+	server    *httptest.Server
+	mutex     sync.Mutex
+	manifests map[string][]byte
+	blobs     map[string][]byte
+	requests  int64
+}
+
+func startOCIRegistry(t *testing.T) *ociRegistry {
+	t.Helper()
+
+	oci := &ociRegistry{
+		manifests: map[string][]byte{},
+		blobs:     map[string][]byte{},
+	}
+
+	oci.server = httptest.NewTLSServer(http.HandlerFunc(oci.serve))
+	t.Cleanup(oci.server.Close)
+
+	return oci
+}
+
+func (oci *ociRegistry) serve(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt64(&oci.requests, 1)
+
+	oci.mutex.Lock()
+	defer oci.mutex.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/v2/")
+
+	var content []byte
+
+	switch {
+	case strings.Contains(path, "/manifests/"):
+		content = oci.manifests[path]
+		w.Header().Set("Content-Type", registry.MediaTypeOCIManifest)
+	case strings.Contains(path, "/blobs/"):
+		content = oci.blobs[path[strings.LastIndex(path, "/")+1:]]
+	}
+
+	if content == nil {
+		w.WriteHeader(http.StatusNotFound)
+
+		return
+	}
+
+	w.Header().Set("Docker-Content-Digest", registry.Digest(content))
+
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(content)
+	}
+}
+
+func (oci *ociRegistry) host() string {
+	return strings.TrimPrefix(oci.server.URL, "https://")
+}
+
+func (oci *ociRegistry) pushManifest(repository, tag string, content []byte) string {
+	oci.mutex.Lock()
+	defer oci.mutex.Unlock()
+
+	digest := registry.Digest(content)
+	oci.manifests[repository+"/manifests/"+tag] = content
+	oci.manifests[repository+"/manifests/"+digest] = content
+
+	return digest
+}
+
+// pushImage pushes an image manifest with unique content and returns its digest.
+func (oci *ociRegistry) pushImage(repository, tag string) string {
+	return oci.pushManifest(repository, tag, []byte(fmt.Sprintf(
+		`{"schemaVersion":2,"config":{},"layers":[],"annotations":{"repository":%q,"tag":%q}}`,
+		repository, tag,
+	)))
+}
+
+// sign pushes a cosign signature for the digest of an image, signed by a key over a payload which
+// refers to the signed digest.
+func (oci *ociRegistry) sign(t *testing.T, repository, digest, signedDigest string, key *ecdsa.PrivateKey) {
+	t.Helper()
+
+	payload := []byte(fmt.Sprintf(
+		`{"critical":{"identity":{"docker-reference":"%s/%s"},"image":{"docker-manifest-digest":%q},"type":%q},"optional":null}`,
+		oci.host(), repository, signedDigest, cosignSignatureType,
+	))
+
+	hash := sha256.Sum256(payload)
+
+	signature, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+	if err != nil {
+		t.Fatalf("unable to sign payload: %v", err)
+	}
+
+	payloadDigest := registry.Digest(payload)
+
+	oci.mutex.Lock()
+	oci.blobs[payloadDigest] = payload
+	oci.mutex.Unlock()
+
+	manifest, err := json.Marshal(&registry.Manifest{
+		MediaType: registry.MediaTypeOCIManifest,
+		Layers: []registry.Descriptor{
+			{
+				MediaType:   "application/vnd.dev.cosign.simplesigning.v1+json",
+				Digest:      payloadDigest,
+				Size:        int64(len(payload)),
+				Annotations: map[string]string{cosignSignatureAnnotation: base64.StdEncoding.EncodeToString(signature)},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unable to encode signature manifest: %v", err)
+	}
+
+	oci.pushManifest(repository, strings.Replace(digest, ":", "-", 1)+".sig", manifest)
+}
+
+func generateKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("unable to marshal public key: %v", err)
+	}
+
+	return key, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func signaturesConfig(t *testing.T, oci *ociRegistry, policies ...*ImageSignaturePolicy) *ImageSignaturesConfig {
+	t.Helper()
+
+	config := &ImageSignaturesConfig{
+		Policies: policies,
+		Registry: &registry.HTTPRegistry{Client: oci.server.Client()},
+	}
+
+	if err := config.validate(); err != nil {
+		t.Fatalf("unable to validate image signatures configuration: %v", err)
+	}
+
+	return config
+}
+
+func signaturesPodSpec(images ...string) *corev1.PodSpec {
+	podSpec := &corev1.PodSpec{}
+
+	for i, image := range images {
+		podSpec.Containers = append(podSpec.Containers, corev1.Container{Name: fmt.Sprintf("app-%d", i), Image: image})
+	}
+
+	return podSpec
+}
+
+func TestValidateImageSignature(t *testing.T) {
+	t.Parallel()
+
+	oci := startOCIRegistry(t)
+	trustedKey, trustedPublicKey := generateKey(t)
+	untrustedKey, _ := generateKey(t)
+
+	signedDigest := oci.pushImage("team/signed", "v1")
+	oci.sign(t, "team/signed", signedDigest, signedDigest, trustedKey)
+
+	oci.pushImage("team/unsigned", "v1")
+
+	untrustedDigest := oci.pushImage("team/untrusted", "v1")
+	oci.sign(t, "team/untrusted", untrustedDigest, untrustedDigest, untrustedKey)
+
+	// a signature copied from another image refers to the digest of the other image
+	copiedDigest := oci.pushImage("team/copied", "v1")
+	oci.sign(t, "team/copied", copiedDigest, signedDigest, trustedKey)
+
+	host := oci.host()
+
+	config := signaturesConfig(t, oci,
+		&ImageSignaturePolicy{Registry: host + "/team/", PublicKeys: []string{trustedPublicKey}},
+		&ImageSignaturePolicy{Registry: host + "/restricted/", Namespaces: []string{"restricted"}, PublicKeys: []string{trustedPublicKey}},
+	)
+
+	tests := []struct {
+		name      string
+		podSpec   *corev1.PodSpec
+		namespace string
+		config    *ImageSignaturesConfig
+		want      bool
+		wantErr   string
+	}{
+		{
+			name:    "ensure an image signed by a trusted key passes validation",
+			podSpec: signaturesPodSpec(host + "/team/signed:v1"),
+			config:  config,
+			want:    true,
+		},
+		{
+			name:    "ensure an image pinned to a signed digest passes validation",
+			podSpec: signaturesPodSpec(host + "/team/signed@" + signedDigest),
+			config:  config,
+			want:    true,
+		},
+		{
+			name:    "ensure an unsigned image fails validation",
+			podSpec: signaturesPodSpec(host+"/team/signed:v1", host+"/team/unsigned:v1"),
+			config:  config,
+			want:    false,
+			wantErr: fmt.Sprintf("container [app-1] image [%s/team/unsigned:v1]: no signatures found", host),
+		},
+		{
+			name:    "ensure an image signed by an untrusted key fails validation",
+			podSpec: signaturesPodSpec(host + "/team/untrusted:v1"),
+			config:  config,
+			want:    false,
+			wantErr: "no signature verified by a trusted key",
+		},
+		{
+			name:    "ensure a signature for a different digest fails validation",
+			podSpec: signaturesPodSpec(host + "/team/copied:v1"),
+			config:  config,
+			want:    false,
+			wantErr: "no signature verified by a trusted key",
+		},
+		{
+			name:    "ensure a missing image fails validation",
+			podSpec: signaturesPodSpec(host + "/team/missing:v1"),
+			config:  config,
+			want:    false,
+			wantErr: "not found in registry",
+		},
+		{
+			name:    "ensure an image not matched by a policy passes validation",
+			podSpec: signaturesPodSpec("docker.io/library/nginx:latest"),
+			config:  config,
+			want:    true,
+		},
+		{
+			name:      "ensure an image matched by a policy for another namespace passes validation",
+			podSpec:   signaturesPodSpec(host + "/restricted/unsigned:v1"),
+			namespace: "default",
+			config:    config,
+			want:      true,
+		},
+		{
+			name:      "ensure an image matched by a policy for its namespace fails validation",
+			podSpec:   signaturesPodSpec(host + "/restricted/unsigned:v1"),
+			namespace: "restricted",
+			config:    config,
+			want:      false,
+			wantErr:   "not found in registry",
+		},
+		{
+			name:    "ensure no configured image signatures passes validation",
+			podSpec: signaturesPodSpec(host + "/team/unsigned:v1"),
+			config:  nil,
+			want:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			resource := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: tt.namespace}}
+			validation := &Validation{
+				Resource: resource,
+				PodSpec:  tt.podSpec,
+				Policy:   &PolicyConfig{ImageSignatures: tt.config},
+			}
+			got, err := ImageSignature(validation)
+			if got != tt.want {
+				t.Errorf("ImageSignature() = %v, want %v", got, tt.want)
+			}
+			if (err != nil) != (tt.wantErr != "") || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("ImageSignature() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestImageSignatureCache(t *testing.T) {
+	t.Parallel()
+
+	oci := startOCIRegistry(t)
+	key, publicKey := generateKey(t)
+
+	digest := oci.pushImage("team/app", "v1")
+	oci.sign(t, "team/app", digest, digest, key)
+
+	config := signaturesConfig(t, oci, &ImageSignaturePolicy{Registry: oci.host(), PublicKeys: []string{publicKey}})
+
+	now := time.Now()
+	config.verified.now = func() time.Time { return now }
+
+	validation := &Validation{
+		Resource: &appsv1.Deployment{},
+		PodSpec:  signaturesPodSpec(oci.host() + "/team/app:v1"),
+		Policy:   &PolicyConfig{ImageSignatures: config},
+	}
+
+	verify := func(wantRequests int64) {
+		t.Helper()
+
+		before := atomic.LoadInt64(&oci.requests)
+
+		if got, err := ImageSignature(validation); !got || err != nil {
+			t.Fatalf("ImageSignature() = %v, %v, want true", got, err)
+		}
+
+		if requests := atomic.LoadInt64(&oci.requests) - before; requests != wantRequests {
+			t.Errorf("registry requests = %d, want %d", requests, wantRequests)
+		}
+	}
+
+	// the first verification resolves the tag, then fetches the signature manifest and payload
+	verify(3)
+
+	// repeated verifications within the ttl resolve the tag, and the signature is served from the cache
+	verify(1)
+
+	// once the ttl expires, the signature is verified again
+	now = now.Add(defaultImageSignatureCacheTTL)
+	verify(3)
+
+	// a tag which is moved to an unsigned image is denied within the ttl
+	oci.pushManifest("team/app", "v1", []byte(`{"schemaVersion":2,"config":{},"layers":[],"annotations":{"moved":"true"}}`))
+
+	if got, err := ImageSignature(validation); got || err == nil {
+		t.Errorf("ImageSignature() = %v, %v, want false for a moved tag", got, err)
+	}
+}
+
+func TestImageSignatureKeysFor(t *testing.T) {
+	t.Parallel()
+
+	_, publicKey := generateKey(t)
+
+	config := &ImageSignaturesConfig{
+		Policies: []*ImageSignaturePolicy{
+			{Registry: "docker.io/library/", PublicKeys: []string{publicKey}},
+			{Registry: "ghcr.io/org", PublicKeys: []string{publicKey}},
+			{Registry: "", Namespaces: []string{"restricted"}, PublicKeys: []string{publicKey}},
+		},
+		Registry: registry.NewHTTPRegistry(),
+	}
+
+	if err := config.validate(); err != nil {
+		t.Fatalf("validate() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		image     string
+		namespace string
+		want      string
+	}{
+		{
+			name:  "ensure an image without a registry matches a policy for its default registry",
+			image: "nginx:1.23",
+			want:  "0",
+		},
+		{
+			name:  "ensure a fully qualified image matches a policy for its registry",
+			image: "docker.io/library/nginx@sha256:0000000000000000000000000000000000000000000000000000000000000000",
+			want:  "0",
+		},
+		{
+			name:  "ensure an image within a policy repository prefix matches the policy",
+			image: "ghcr.io/org/app:v1",
+			want:  "1",
+		},
+		{
+			name:  "ensure an image sharing a policy prefix without a path boundary does not match the policy",
+			image: "ghcr.io/org-evil/app:v1",
+			want:  "",
+		},
+		{
+			name:      "ensure a policy without a registry matches all images in its namespaces",
+			image:     "ghcr.io/org-evil/app:v1",
+			namespace: "restricted",
+			want:      "2",
+		},
+		{
+			name:      "ensure an image matched by more than one policy collects each policy",
+			image:     "ghcr.io/org/app:v1",
+			namespace: "restricted",
+			want:      "1,2",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			matched, keys := config.keysFor(tt.image, tt.namespace)
+			if matched != tt.want {
+				t.Errorf("keysFor() matched = %q, want %q", matched, tt.want)
+			}
+			if wantKeys := len(strings.Split(tt.want, ",")); tt.want != "" && len(keys) != wantKeys {
+				t.Errorf("keysFor() returned %d keys, want %d", len(keys), wantKeys)
+			}
+		})
+	}
+}
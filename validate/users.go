@@ -0,0 +1,135 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+)
+
+const (
+	exemptUsersEnvSuffix           = "EXEMPT_USERS"
+	exemptGroupsEnvSuffix          = "EXEMPT_GROUPS"
+	exemptServiceAccountsEnvSuffix = "EXEMPT_SERVICE_ACCOUNTS"
+	exemptActionEnvSuffix          = "EXEMPT_ACTION"
+
+	// ExemptActionSkip skips the validation for an exempt requester.
+	ExemptActionSkip = "skip"
+
+	serviceAccountUsernamePrefix = "system:serviceaccount"
+)
+
+var ErrInvalidExemptAction = errors.New("invalid exempt action")
+
+// UserExemption is a set of users, groups and service accounts which are exempt from
+// a validation, along with the action to take when the requester matches.
+type UserExemption struct {
+	Users           []string
+	Groups          []string
+	ServiceAccounts []string
+
+	// Action is either 'skip' to skip the validation entirely, or 'warn' to downgrade
+	// the validation to warn mode.
+	Action string
+}
+
+// UserExemption returns the user exemption for a validation.  It is configured via
+// environment variables using the validation environment variable as a prefix, for example:
+//
+//	VALIDATE_HOST_NETWORK_EXEMPT_USERS=admin,operator
+//	VALIDATE_HOST_NETWORK_EXEMPT_GROUPS=system:masters
+//	VALIDATE_HOST_NETWORK_EXEMPT_SERVICE_ACCOUNTS=kube-system:cni-installer
+//	VALIDATE_HOST_NETWORK_EXEMPT_ACTION=warn
+//
+// A nil user exemption is returned if none are configured.
+func (validation *Validation) UserExemption() (*UserExemption, error) {
+	exemption := &UserExemption{
+		Users:           validation.environmentList(exemptUsersEnvSuffix),
+		Groups:          validation.environmentList(exemptGroupsEnvSuffix),
+		ServiceAccounts: validation.environmentList(exemptServiceAccountsEnvSuffix),
+		Action:          os.Getenv(validation.environmentVariable(exemptActionEnvSuffix)),
+	}
+
+	if len(exemption.Users) == 0 && len(exemption.Groups) == 0 && len(exemption.ServiceAccounts) == 0 {
+		return nil, nil
+	}
+
+	switch exemption.Action {
+	case "":
+		exemption.Action = ExemptActionSkip
+	case ExemptActionSkip, WarnValidationEnvValue:
+	default:
+		return nil, fmt.Errorf(
+			"%w - [%s=%s]",
+			ErrInvalidExemptAction,
+			validation.environmentVariable(exemptActionEnvSuffix),
+			exemption.Action,
+		)
+	}
+
+	return exemption, nil
+}
+
+// Match returns the user, group or service account that matches the requester.  An empty
+// string is returned if the requester is not exempt.
+func (exemption *UserExemption) Match(userInfo *authenticationv1.UserInfo) string {
+	if exemption == nil || userInfo == nil {
+		return ""
+	}
+
+	for _, user := range exemption.Users {
+		if userInfo.Username == user {
+			return fmt.Sprintf("user=%s", user)
+		}
+	}
+
+	for _, serviceAccount := range exemption.ServiceAccounts {
+		if !strings.Contains(serviceAccount, ":") {
+			continue
+		}
+
+		if userInfo.Username == fmt.Sprintf("%s:%s", serviceAccountUsernamePrefix, serviceAccount) {
+			return fmt.Sprintf("serviceaccount=%s", serviceAccount)
+		}
+	}
+
+	for _, group := range exemption.Groups {
+		for _, userGroup := range userInfo.Groups {
+			if userGroup == group {
+				return fmt.Sprintf("group=%s", group)
+			}
+		}
+	}
+
+	return ""
+}
+
+// environmentVariable returns an environment variable specific to this validation with
+// the given suffix.
+func (validation *Validation) environmentVariable(suffix string) string {
+	return fmt.Sprintf("%s_%s", validation.EnvironmetVariableOverride(), suffix)
+}
+
+// environmentList returns a comma-separated list from an environment variable specific to
+// this validation.
+func (validation *Validation) environmentList(suffix string) []string {
+	value := os.Getenv(validation.environmentVariable(suffix))
+	if value == "" {
+		return nil
+	}
+
+	list := []string{}
+
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,144 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package validate
+
+import (
+	"testing"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+)
+
+func TestUserExemptionMatch(t *testing.T) {
+	t.Parallel()
+
+	exemption := &UserExemption{
+		Users:           []string{"admin"},
+		Groups:          []string{"system:masters"},
+		ServiceAccounts: []string{"kube-system:cni-installer"},
+		Action:          ExemptActionSkip,
+	}
+
+	tests := []struct {
+		name      string
+		exemption *UserExemption
+		userInfo  *authenticationv1.UserInfo
+		want      string
+	}{
+		{
+			name:      "ensure an exempt user matches",
+			exemption: exemption,
+			userInfo:  &authenticationv1.UserInfo{Username: "admin"},
+			want:      "user=admin",
+		},
+		{
+			name:      "ensure an exempt group matches",
+			exemption: exemption,
+			userInfo:  &authenticationv1.UserInfo{Username: "jane", Groups: []string{"system:authenticated", "system:masters"}},
+			want:      "group=system:masters",
+		},
+		{
+			name:      "ensure an exempt service account matches",
+			exemption: exemption,
+			userInfo:  &authenticationv1.UserInfo{Username: "system:serviceaccount:kube-system:cni-installer"},
+			want:      "serviceaccount=kube-system:cni-installer",
+		},
+		{
+			name:      "ensure a service account in another namespace does not match",
+			exemption: exemption,
+			userInfo:  &authenticationv1.UserInfo{Username: "system:serviceaccount:default:cni-installer"},
+			want:      "",
+		},
+		{
+			name:      "ensure a non-exempt user does not match",
+			exemption: exemption,
+			userInfo:  &authenticationv1.UserInfo{Username: "jane", Groups: []string{"system:authenticated"}},
+			want:      "",
+		},
+		{
+			name:      "ensure a nil exemption does not match",
+			exemption: nil,
+			userInfo:  &authenticationv1.UserInfo{Username: "admin"},
+			want:      "",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.exemption.Match(tt.userInfo); got != tt.want {
+				t.Errorf("UserExemption.Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+//nolint:paralleltest
+func TestValidationUserExemption(t *testing.T) {
+	tests := []struct {
+		name       string
+		env        map[string]string
+		wantNil    bool
+		wantAction string
+		wantErr    bool
+	}{
+		{
+			name:    "ensure no user exemption is returned when unset",
+			env:     map[string]string{},
+			wantNil: true,
+			wantErr: false,
+		},
+		{
+			name: "ensure the user exemption defaults to skip",
+			env: map[string]string{
+				"VALIDATE_HOST_NETWORK_EXEMPT_USERS": "admin, operator",
+			},
+			wantNil:    false,
+			wantAction: ExemptActionSkip,
+			wantErr:    false,
+		},
+		{
+			name: "ensure the user exemption may downgrade to warn",
+			env: map[string]string{
+				"VALIDATE_HOST_NETWORK_EXEMPT_GROUPS": "system:masters",
+				"VALIDATE_HOST_NETWORK_EXEMPT_ACTION": "warn",
+			},
+			wantNil:    false,
+			wantAction: WarnValidationEnvValue,
+			wantErr:    false,
+		},
+		{
+			name: "ensure an invalid action returns an error",
+			env: map[string]string{
+				"VALIDATE_HOST_NETWORK_EXEMPT_SERVICE_ACCOUNTS": "kube-system:cni-installer",
+				"VALIDATE_HOST_NETWORK_EXEMPT_ACTION":           "ignore",
+			},
+			wantNil: true,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			got, err := NewValidation(HostNetworkValidationName, HostNetwork).UserExemption()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validation.UserExemption() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+			if (got == nil) != tt.wantNil {
+				t.Errorf("Validation.UserExemption() = %v, wantNil %v", got, tt.wantNil)
+
+				return
+			}
+			if got != nil && got.Action != tt.wantAction {
+				t.Errorf("Validation.UserExemption() action = %v, want %v", got.Action, tt.wantAction)
+			}
+		})
+	}
+}
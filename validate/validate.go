@@ -4,23 +4,73 @@
 package validate
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
+	admissionv1 "k8s.io/api/admission/v1"
 	corev1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/nukleros/pod-security-webhook/resources"
 )
 
-const SkipValidationEnvValue = "false"
+const (
+	SkipValidationEnvValue = "false"
+	WarnValidationEnvValue = "warn"
+)
+
+// ErrNamespaceUnavailable is returned by validations which depend on the annotations of the
+// namespace of a resource when the namespace could not be retrieved.  These validations fail closed,
+// as the namespace may restrict what is permitted.
+var ErrNamespaceUnavailable = errors.New("unable to retrieve namespace")
+
+// EnforcementAction represents the action taken when a validation fails.
+type EnforcementAction string
+
+const (
+	// EnforcementActionDeny denies the admission request when the validation fails.
+	EnforcementActionDeny EnforcementAction = "deny"
+
+	// EnforcementActionWarn permits the admission request when the validation fails, but
+	// returns a warning to the requester.
+	EnforcementActionWarn EnforcementAction = "warn"
+)
+
+// SkipReason represents the reason a validation was skipped or downgraded.
+type SkipReason string
+
+const (
+	SkipReasonEnv        SkipReason = "env"
+	SkipReasonOwner      SkipReason = "owner"
+	SkipReasonAnnotation SkipReason = "annotation"
+	SkipReasonExemption  SkipReason = "exemption"
+	SkipReasonUser       SkipReason = "user"
+)
 
 type Validation struct {
 	Name     string
 	Resource client.Object
 	PodSpec  *corev1.PodSpec
+	Policy   *PolicyConfig
 	Run      ValidationLogic
 	Skip     bool
+	Action   EnforcementAction
+
+	// Namespace is the namespace of the resource, if it could be retrieved.  Validations which
+	// depend on the namespace fail with ErrNamespaceUnavailable if it is nil.
+	Namespace *corev1.Namespace
+
+	// Operation is the admission operation for the resource.  It is empty when validating resources
+	// outside of an admission request.
+	Operation admissionv1.Operation
+
+	// ExemptedBy describes the exemption which downgraded the validation to warn mode, if any.
+	ExemptedBy string
+
+	// FailedContainers are the names of the containers which failed the validation, if the
+	// failure applies to individual containers.
+	FailedContainers []string
 }
 
 type ValidationLogic func(*Validation) (bool, error)
@@ -28,8 +78,9 @@ type ValidationLogic func(*Validation) (bool, error)
 // NewValidation return an instance of a new validation.
 func NewValidation(name string, validateLogic ValidationLogic) *Validation {
 	return &Validation{
-		Name: name,
-		Run:  validateLogic,
+		Name:   name,
+		Run:    validateLogic,
+		Action: EnforcementActionDeny,
 	}
 }
 
@@ -42,6 +93,10 @@ func (validation *Validation) Execute() (bool, error) {
 // indicate that the validation logic has failed for a ValidationLogic function.
 func (validation *Validation) Failed(parentErr error, failedContainers ...corev1.Container) (bool, error) {
 	if len(failedContainers) > 0 {
+		for i := range failedContainers {
+			validation.FailedContainers = append(validation.FailedContainers, failedContainers[i].Name)
+		}
+
 		return false, fmt.Errorf(
 			"failed validation %s for %s - %w for containers %s",
 			validation.Name,
@@ -11,7 +11,6 @@ var (
 	truePointer  bool = true
 	falsePointer bool = false
 
-	rootUser    int64 = 0
 	nonRootUser int64 = 1234
 )
 
@@ -26,12 +25,6 @@ func defaultServiceAccountPodSpec() *corev1.PodSpec {
 	}
 }
 
-func emptyPodSpec() *corev1.PodSpec {
-	return &corev1.PodSpec{
-		Containers: []corev1.Container{{Name: "empty"}},
-	}
-}
-
 func validPodSpec() *corev1.PodSpec {
 	return &corev1.PodSpec{
 		HostPID:     false,
@@ -113,38 +106,3 @@ func invalidPodSpec() *corev1.PodSpec {
 		},
 	}
 }
-
-func validPodSpecExplicitRunAsNonRoot() *corev1.PodSpec {
-	return &corev1.PodSpec{
-		SecurityContext: &corev1.PodSecurityContext{
-			RunAsNonRoot: &falsePointer,
-			RunAsUser:    &rootUser,
-		},
-		Containers: []corev1.Container{
-			{
-				Name: "valid-explicit",
-				SecurityContext: &corev1.SecurityContext{
-					RunAsNonRoot: &truePointer,
-					RunAsUser:    &nonRootUser,
-				},
-			},
-		},
-	}
-}
-
-func invalidPodSpecExplicitRunAsNonRoot() *corev1.PodSpec {
-	return &corev1.PodSpec{
-		SecurityContext: &corev1.PodSecurityContext{
-			RunAsNonRoot: &truePointer,
-			RunAsUser:    &rootUser,
-		},
-		Containers: []corev1.Container{
-			{
-				Name: "invalid-explicit",
-				SecurityContext: &corev1.SecurityContext{
-					RunAsNonRoot: &falsePointer,
-				},
-			},
-		},
-	}
-}
@@ -0,0 +1,83 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	AllowedVolumeTypesValidationName = "allowed-volume-types"
+	AllowedVolumeTypesEnv            = "ALLOWED_VOLUME_TYPES"
+)
+
+var ErrPodForbiddenVolumeTypes = errors.New("unable to permit pod with forbidden volume types")
+
+// DefaultAllowedVolumeTypes returns the volume types allowed by the restricted pod security
+// standard, which are used when no volume types are configured.
+func DefaultAllowedVolumeTypes() []string {
+	return []string{
+		"configMap",
+		"csi",
+		"downwardAPI",
+		"emptyDir",
+		"ephemeral",
+		"persistentVolumeClaim",
+		"projected",
+		"secret",
+	}
+}
+
+// AllowedVolumeTypes validates whether each volume in a pod spec is of an allowed type.  The allowed
+// types are a comma-separated list of volume source fields, such as hostPath or awsElasticBlockStore,
+// which default to the restricted pod security standard.
+func AllowedVolumeTypes(validation *Validation) (bool, error) {
+	allowedTypes := DefaultAllowedVolumeTypes()
+	if allowed := os.Getenv(AllowedVolumeTypesEnv); allowed != "" {
+		allowedTypes = strings.Split(allowed, ",")
+	}
+
+	forbiddenVolumes := []string{}
+
+VOLUMES:
+	for i := range validation.PodSpec.Volumes {
+		volumeType := GetVolumeType(&validation.PodSpec.Volumes[i].VolumeSource)
+
+		for _, allowedType := range allowedTypes {
+			if strings.EqualFold(strings.TrimSpace(allowedType), volumeType) {
+				continue VOLUMES
+			}
+		}
+
+		forbiddenVolumes = append(forbiddenVolumes, fmt.Sprintf("volume [%s] of type [%s]", validation.PodSpec.Volumes[i].Name, volumeType))
+	}
+
+	if len(forbiddenVolumes) > 0 {
+		return validation.Failed(fmt.Errorf("%w - %s", ErrPodForbiddenVolumeTypes, strings.Join(forbiddenVolumes, ", ")))
+	}
+
+	return true, nil
+}
+
+// GetVolumeType returns the type of a volume, which is the json name of the field that is set in
+// its volume source, for example hostPath.  An empty string is returned if no field is set.
+func GetVolumeType(source *corev1.VolumeSource) string {
+	value := reflect.ValueOf(source).Elem()
+
+	for i := 0; i < value.NumField(); i++ {
+		if value.Field(i).Kind() != reflect.Ptr || value.Field(i).IsNil() {
+			continue
+		}
+
+		return strings.Split(value.Type().Field(i).Tag.Get("json"), ",")[0]
+	}
+
+	return ""
+}
@@ -0,0 +1,82 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/nukleros/pod-security-webhook/resources"
+)
+
+const (
+	WindowsHostProcessValidationName = "windows-host-process"
+	AllowedGMSACredentialSpecsEnv    = "ALLOWED_GMSA_CREDENTIAL_SPECS"
+)
+
+var (
+	ErrPodWindowsHostProcess       = errors.New("unable to permit pod with windows host process")
+	ErrContainerWindowsHostProcess = errors.New("unable to permit container with windows host process")
+	ErrContainerGMSACredentialSpec = errors.New("unable to permit container with gmsa credential spec not in the allowed list")
+)
+
+// WindowsHostProcess validates whether a pod spec requests windows host process containers, which
+// have access to the node, at either the pod or the container level.  It also validates whether
+// each container using a gmsa credential spec references a credential spec from a comma-separated
+// list of allowed credential specs.  Credential specs which are set inline, rather than by name,
+// are not permitted.  Credential specs are not validated if the list of allowed credential specs
+// is unset.
+func WindowsHostProcess(validation *Validation) (bool, error) {
+	podSec := validation.PodSpec.SecurityContext
+	if podSec != nil && podSec.WindowsOptions != nil && podSec.WindowsOptions.HostProcess != nil && *podSec.WindowsOptions.HostProcess {
+		return validation.Failed(ErrPodWindowsHostProcess)
+	}
+
+	allowedCredentialSpecs := map[string]bool{}
+
+	for _, name := range strings.Split(os.Getenv(AllowedGMSACredentialSpecsEnv), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			allowedCredentialSpecs[name] = true
+		}
+	}
+
+	containersWithHostProcess := []corev1.Container{}
+	containersWithCredentialSpec := []corev1.Container{}
+
+	allContainers := []corev1.Container{}
+	allContainers = append(append(allContainers, validation.PodSpec.InitContainers...), validation.PodSpec.Containers...)
+
+	for i := range allContainers {
+		options := resources.EffectiveWindowsOptions(podSec, allContainers[i].SecurityContext)
+
+		if options.HostProcess != nil && *options.HostProcess {
+			containersWithHostProcess = append(containersWithHostProcess, allContainers[i])
+		}
+
+		if len(allowedCredentialSpecs) == 0 || (options.GMSACredentialSpecName == nil && options.GMSACredentialSpec == nil) {
+			continue
+		}
+
+		if options.GMSACredentialSpecName == nil || !allowedCredentialSpecs[*options.GMSACredentialSpecName] {
+			containersWithCredentialSpec = append(containersWithCredentialSpec, allContainers[i])
+		}
+	}
+
+	if len(containersWithHostProcess) > 0 {
+		return validation.Failed(ErrContainerWindowsHostProcess, containersWithHostProcess...)
+	}
+
+	if len(containersWithCredentialSpec) > 0 {
+		return validation.Failed(
+			fmt.Errorf("%w [%s]", ErrContainerGMSACredentialSpec, os.Getenv(AllowedGMSACredentialSpecsEnv)),
+			containersWithCredentialSpec...,
+		)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nukleros/pod-security-webhook/validate"
+)
+
+// The keys of the audit annotations returned with each admission response.  The API server
+// prefixes each key with the name of the webhook, for example pod-security-webhook.nukleros.io/violations.
+const (
+	AuditAnnotationViolations = "violations"
+	AuditAnnotationSkipped    = "skipped"
+	AuditAnnotationExemptedBy = "exempted-by"
+	AuditAnnotationProfile    = "profile"
+
+	policyProfileEnv     = "POLICY_PROFILE"
+	defaultPolicyProfile = "default"
+)
+
+// auditAnnotations returns the audit annotations for an operation, which are recorded by the
+// API server in the audit event for the request.  This provides a record of which validations
+// were violated, and which were bypassed and how.
+func (operation *Operation) auditAnnotations() map[string]string {
+	profile := os.Getenv(policyProfileEnv)
+	if profile == "" {
+		profile = defaultPolicyProfile
+	}
+
+	annotations := map[string]string{
+		AuditAnnotationProfile: profile,
+	}
+
+	skipped := []string{}
+	exemptedBy := map[string]string{}
+
+	for _, result := range operation.Results {
+		if result.Status == validate.ResultSkip {
+			skipped = append(skipped, fmt.Sprintf("%s=%s", result.Validation, result.SkipReason))
+		}
+
+		if result.ExemptedBy != "" {
+			exemptedBy[result.Validation] = result.ExemptedBy
+		}
+	}
+
+	if violations := operation.violations(); len(violations) > 0 {
+		annotations[AuditAnnotationViolations] = strings.Join(violations, ",")
+	}
+
+	if len(skipped) > 0 {
+		annotations[AuditAnnotationSkipped] = strings.Join(skipped, ",")
+	}
+
+	if len(exemptedBy) > 0 {
+		if value, err := json.Marshal(exemptedBy); err == nil {
+			annotations[AuditAnnotationExemptedBy] = string(value)
+		}
+	}
+
+	return annotations
+}
@@ -0,0 +1,182 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package webhook
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/nukleros/pod-security-webhook/validate"
+)
+
+// respondAnnotations sends the response for an operation with the given results and returns the
+// audit annotations of the admission response.
+func respondAnnotations(t *testing.T, results []*validate.Result) map[string]string {
+	t.Helper()
+
+	webhook, err := NewOfflineWebhook(nil)
+	if err != nil {
+		t.Fatalf("NewOfflineWebhook() error = %v", err)
+	}
+
+	operation := &Operation{
+		Log:       webhook.Log,
+		Results:   results,
+		Permitted: true,
+		Review: &admissionv1.AdmissionReview{
+			Request: &admissionv1.AdmissionRequest{UID: "annotations"},
+		},
+	}
+
+	recorder := httptest.NewRecorder()
+	if err := webhook.respond(recorder, operation); err != nil {
+		t.Fatalf("respond() error = %v", err)
+	}
+
+	review := &admissionv1.AdmissionReview{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), review); err != nil {
+		t.Fatalf("unable to parse response: %v", err)
+	}
+
+	return review.Response.AuditAnnotations
+}
+
+func TestAuditAnnotations(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		results []*validate.Result
+		want    map[string]string
+	}{
+		{
+			name: "ensure passed validations only record the profile",
+			results: []*validate.Result{
+				{Validation: validate.PrivilegedValidationName, Status: validate.ResultPass},
+			},
+			want: map[string]string{
+				AuditAnnotationProfile: defaultPolicyProfile,
+			},
+		},
+		{
+			name: "ensure failed and warned validations are recorded as violations",
+			results: []*validate.Result{
+				{Validation: validate.PrivilegedValidationName, Status: validate.ResultFail},
+				{Validation: validate.HostPIDValidationName, Status: validate.ResultPass},
+				{Validation: validate.HostIPCValidationName, Status: validate.ResultWarn},
+			},
+			want: map[string]string{
+				AuditAnnotationProfile:    defaultPolicyProfile,
+				AuditAnnotationViolations: validate.PrivilegedValidationName + "," + validate.HostIPCValidationName,
+			},
+		},
+		{
+			name: "ensure a validation downgraded by an exemption records the exemption",
+			results: []*validate.Result{
+				{
+					Validation: validate.HostPIDValidationName,
+					Status:     validate.ResultWarn,
+					ExemptedBy: "ticket=SEC-1 approver=security expires=2022-06-01T00:00:00Z",
+				},
+			},
+			want: map[string]string{
+				AuditAnnotationProfile:    defaultPolicyProfile,
+				AuditAnnotationViolations: validate.HostPIDValidationName,
+				AuditAnnotationExemptedBy: `{"` + validate.HostPIDValidationName +
+					`":"ticket=SEC-1 approver=security expires=2022-06-01T00:00:00Z"}`,
+			},
+		},
+		{
+			name: "ensure skipped validations are recorded with the reason they were skipped",
+			results: []*validate.Result{
+				validate.NewSkipResult(validate.HostNetworkValidationName, validate.SkipReasonAnnotation, "skipped"),
+				validate.NewSkipResult(validate.HostPIDValidationName, validate.SkipReasonEnv, "disabled"),
+			},
+			want: map[string]string{
+				AuditAnnotationProfile: defaultPolicyProfile,
+				AuditAnnotationSkipped: validate.HostNetworkValidationName + "=annotation," +
+					validate.HostPIDValidationName + "=env",
+			},
+		},
+		{
+			name: "ensure validations skipped via owner references are recorded with the owner reason",
+			results: []*validate.Result{
+				validate.NewSkipResult(validate.PrivilegedValidationName, validate.SkipReasonOwner, "validated via owner"),
+				validate.NewSkipResult(validate.HostPIDValidationName, validate.SkipReasonOwner, "validated via owner"),
+			},
+			want: map[string]string{
+				AuditAnnotationProfile: defaultPolicyProfile,
+				AuditAnnotationSkipped: validate.PrivilegedValidationName + "=owner," +
+					validate.HostPIDValidationName + "=owner",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := respondAnnotations(t, tt.results); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AuditAnnotations = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+//nolint:paralleltest
+func TestAuditAnnotationsProfile(t *testing.T) {
+	t.Setenv(policyProfileEnv, "restricted")
+
+	want := map[string]string{AuditAnnotationProfile: "restricted"}
+
+	if got := respondAnnotations(t, nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("AuditAnnotations = %v, want %v", got, want)
+	}
+}
+
+func TestAuditAnnotationsOwnerReferences(t *testing.T) {
+	t.Parallel()
+
+	webhook, err := NewOfflineWebhook(nil)
+	if err != nil {
+		t.Fatalf("NewOfflineWebhook() error = %v", err)
+	}
+
+	// a privileged pod which is managed by a replica set is validated via the replica set
+	pod := auditPod("1", 1, "nginx", true)
+	controller := true
+	pod.OwnerReferences = []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "audit", Controller: &controller}}
+	pod.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Pod"))
+
+	results, err := webhook.Evaluate(pod)
+	if err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+
+	annotations := respondAnnotations(t, results)
+
+	if violations, ok := annotations[AuditAnnotationViolations]; ok {
+		t.Errorf("AuditAnnotations violations = %s, want none for a pod validated via its owner", violations)
+	}
+
+	skipped := strings.Split(annotations[AuditAnnotationSkipped], ",")
+	if len(skipped) != len(results) {
+		t.Fatalf("AuditAnnotations skipped = %v, want each of the %d validations", skipped, len(results))
+	}
+
+	for i, result := range results {
+		if want := result.Validation + "=owner"; skipped[i] != want {
+			t.Errorf("AuditAnnotations skipped[%d] = %s, want %s", i, skipped[i], want)
+		}
+	}
+}
@@ -0,0 +1,349 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/nukleros/pod-security-webhook/resources"
+	"github.com/nukleros/pod-security-webhook/validate"
+)
+
+const (
+	auditEnabledEnv = "AUDIT_ENABLED"
+	auditWorkers    = 2
+
+	policyReportName   = "pod-security-webhook"
+	policyReportSource = "pod-security-webhook"
+)
+
+//nolint:gochecknoglobals
+var policyReportResource = schema.GroupVersionResource{
+	Group:    "wgpolicyk8s.io",
+	Version:  "v1alpha2",
+	Resource: "policyreports",
+}
+
+// Auditor audits existing workloads in the cluster and writes the results for each namespace
+// as a wg-policy PolicyReport resource.  Reports are updated as workloads change.  The auditor is
+// a worker, so that only the leader writes reports.
+type Auditor struct {
+	webhook *Webhook
+
+	// queue is nil unless the auditor is running
+	mutex sync.RWMutex
+	queue workqueue.RateLimitingInterface
+}
+
+// NewAuditor returns a new auditor for the webhook.  A nil auditor is returned if audit mode
+// is disabled.
+func NewAuditor(webhook *Webhook) (*Auditor, error) {
+	if os.Getenv(auditEnabledEnv) != "true" {
+		return nil, nil
+	}
+
+	auditor := &Auditor{webhook: webhook}
+
+	for _, informer := range webhook.Workloads {
+		informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    auditor.enqueue,
+			UpdateFunc: auditor.enqueueUpdate,
+			DeleteFunc: auditor.enqueue,
+		})
+	}
+
+	return auditor, nil
+}
+
+// Name returns the name of the auditor as a worker.
+func (auditor *Auditor) Name() string {
+	return "audit"
+}
+
+// Run runs the auditor until the context is cancelled.  Each namespace is audited when the
+// auditor starts, as workloads may have changed while another replica was the leader.
+func (auditor *Auditor) Run(ctx context.Context) {
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "audit")
+
+	auditor.mutex.Lock()
+	auditor.queue = queue
+	auditor.mutex.Unlock()
+
+	namespaces, err := auditor.webhook.Namespaces.List(labels.Everything())
+	if err != nil {
+		auditor.webhook.Log.Errorf("%s - unable to list namespaces to audit", err)
+	}
+
+	for _, namespace := range namespaces {
+		queue.Add(namespace.Name)
+	}
+
+	group := sync.WaitGroup{}
+
+	for i := 0; i < auditWorkers; i++ {
+		group.Add(1)
+
+		go func() {
+			defer group.Done()
+
+			wait.UntilWithContext(ctx, func(ctx context.Context) { auditor.worker(ctx, queue) }, time.Second)
+		}()
+	}
+
+	<-ctx.Done()
+
+	auditor.mutex.Lock()
+	auditor.queue = nil
+	auditor.mutex.Unlock()
+
+	queue.ShutDown()
+	group.Wait()
+}
+
+// enqueue enqueues the namespace of an object for auditing, if the auditor is running.
+func (auditor *Auditor) enqueue(object interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(object)
+	if err != nil {
+		auditor.webhook.Log.Errorf("%s - unable to retrieve key for audit object", err)
+
+		return
+	}
+
+	namespace, _, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		auditor.webhook.Log.Errorf("%s - unable to retrieve namespace for audit object [%s]", err, key)
+
+		return
+	}
+
+	auditor.mutex.RLock()
+	defer auditor.mutex.RUnlock()
+
+	if auditor.queue != nil {
+		auditor.queue.Add(namespace)
+	}
+}
+
+// enqueueUpdate enqueues the namespace of an updated object for auditing.  Updates which do
+// not affect validation, such as status updates, are ignored unless they are periodic resyncs.
+func (auditor *Auditor) enqueueUpdate(oldObject, newObject interface{}) {
+	if auditUnchanged(oldObject, newObject) {
+		return
+	}
+
+	auditor.enqueue(newObject)
+}
+
+// auditUnchanged returns whether an update to an object does not affect its validation.  Changes
+// to the spec of a workload are detected by its generation, other than for pods, which do not
+// bump their generation when their spec changes, so their spec is compared instead.
+func auditUnchanged(oldObject, newObject interface{}) bool {
+	oldResource, oldOK := oldObject.(client.Object)
+	newResource, newOK := newObject.(client.Object)
+
+	if !oldOK || !newOK {
+		return false
+	}
+
+	// periodic resyncs do not change the resource version and are always audited
+	if oldResource.GetResourceVersion() == newResource.GetResourceVersion() ||
+		!reflect.DeepEqual(oldResource.GetAnnotations(), newResource.GetAnnotations()) {
+		return false
+	}
+
+	if oldPod, ok := oldObject.(*corev1.Pod); ok {
+		newPod, ok := newObject.(*corev1.Pod)
+
+		return ok && reflect.DeepEqual(oldPod.Spec, newPod.Spec)
+	}
+
+	return oldResource.GetGeneration() == newResource.GetGeneration()
+}
+
+// worker processes namespaces from the queue until the queue is shut down.
+func (auditor *Auditor) worker(ctx context.Context, queue workqueue.RateLimitingInterface) {
+	for auditor.processNext(ctx, queue) {
+	}
+}
+
+// processNext processes the next namespace from the queue.  It returns false if the queue
+// has been shut down.
+func (auditor *Auditor) processNext(ctx context.Context, queue workqueue.RateLimitingInterface) bool {
+	item, shutdown := queue.Get()
+	if shutdown {
+		return false
+	}
+
+	defer queue.Done(item)
+
+	namespace, ok := item.(string)
+	if !ok {
+		queue.Forget(item)
+
+		return true
+	}
+
+	if err := auditor.reconcile(ctx, namespace); err != nil {
+		auditor.webhook.Log.Errorf("%s - error writing policy report for namespace [%s]", err, namespace)
+		queue.AddRateLimited(item)
+
+		return true
+	}
+
+	queue.Forget(item)
+
+	return true
+}
+
+// reconcile audits the workloads in a namespace and writes the results to the policy report for
+// the namespace.  The policy report is removed if there are no workloads in the namespace.
+func (auditor *Auditor) reconcile(ctx context.Context, namespace string) error {
+	workloads, err := auditor.webhook.listWorkloads(namespace)
+	if err != nil {
+		return err
+	}
+
+	reports := auditor.webhook.DynamicClient.Resource(policyReportResource).Namespace(namespace)
+
+	if len(workloads) == 0 {
+		if err := reports.Delete(ctx, policyReportName, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("%w - unable to delete policy report", err)
+		}
+
+		return nil
+	}
+
+	report := auditor.policyReport(namespace, workloads)
+
+	existing, err := reports.Get(ctx, policyReportName, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return fmt.Errorf("%w - unable to get policy report", err)
+		}
+
+		if _, err := reports.Create(ctx, report, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("%w - unable to create policy report", err)
+		}
+
+		return nil
+	}
+
+	report.SetResourceVersion(existing.GetResourceVersion())
+
+	if _, err := reports.Update(ctx, report, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("%w - unable to update policy report", err)
+	}
+
+	return nil
+}
+
+// policyReport returns the policy report for a set of workloads in a namespace.
+func (auditor *Auditor) policyReport(namespace string, workloads []client.Object) *unstructured.Unstructured {
+	now := time.Now()
+	summary := map[validate.ResultStatus]int64{}
+	results := []interface{}{}
+
+	for _, workload := range workloads {
+		workloadResults, err := auditor.webhook.Evaluate(workload)
+		if err != nil {
+			auditor.webhook.Log.Errorf("%s - unable to audit %s", err, strings.ToLower(resources.ToString(workload)))
+
+			continue
+		}
+
+		for _, result := range workloadResults {
+			summary[result.Status]++
+			results = append(results, policyReportResult(workload, result, now))
+		}
+	}
+
+	report := &unstructured.Unstructured{Object: map[string]interface{}{
+		"scope": map[string]interface{}{
+			"kind":       "Namespace",
+			"apiVersion": "v1",
+			"name":       namespace,
+		},
+		"summary": map[string]interface{}{
+			"pass":  summary[validate.ResultPass],
+			"fail":  summary[validate.ResultFail],
+			"warn":  summary[validate.ResultWarn],
+			"error": int64(0),
+			"skip":  summary[validate.ResultSkip],
+		},
+		"results": results,
+	}}
+
+	report.SetGroupVersionKind(policyReportResource.GroupVersion().WithKind("PolicyReport"))
+	report.SetName(policyReportName)
+	report.SetNamespace(namespace)
+	report.SetLabels(map[string]string{
+		"app.kubernetes.io/managed-by": policyReportSource,
+	})
+
+	return report
+}
+
+// policyReportResult returns an individual policy report result for a validation result.
+func policyReportResult(workload client.Object, result *validate.Result, now time.Time) map[string]interface{} {
+	metadata := validate.MetadataFor(result.Validation)
+	apiVersion, kind := workload.GetObjectKind().GroupVersionKind().ToAPIVersionAndKind()
+
+	properties := map[string]interface{}{}
+	if len(metadata.Tags) > 0 {
+		properties["tags"] = strings.Join(metadata.Tags, ",")
+	}
+
+	if result.SkipReason != "" {
+		properties["skipReason"] = string(result.SkipReason)
+	}
+
+	reportResult := map[string]interface{}{
+		"policy":   result.Validation,
+		"category": metadata.Category,
+		"severity": metadata.Severity,
+		"result":   string(result.Status),
+		"scored":   true,
+		"source":   policyReportSource,
+		"timestamp": map[string]interface{}{
+			"seconds": now.Unix(),
+			"nanos":   int64(0),
+		},
+		"resources": []interface{}{
+			map[string]interface{}{
+				"apiVersion": apiVersion,
+				"kind":       kind,
+				"name":       workload.GetName(),
+				"namespace":  workload.GetNamespace(),
+				"uid":        string(workload.GetUID()),
+			},
+		},
+	}
+
+	if result.Message != "" {
+		reportResult["message"] = result.Message
+	}
+
+	if len(properties) > 0 {
+		reportResult["properties"] = properties
+	}
+
+	return reportResult
+}
@@ -0,0 +1,291 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package webhook
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/nukleros/pod-security-webhook/validate"
+)
+
+// auditPod returns a pod in the apps namespace at a resource version and generation with a
+// container running the given image.
+func auditPod(resourceVersion string, generation int64, image string, privileged bool) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "audit",
+			Namespace:       "apps",
+			UID:             "audit-uid",
+			ResourceVersion: resourceVersion,
+			Generation:      generation,
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name:            "audit",
+					Image:           image,
+					SecurityContext: &corev1.SecurityContext{Privileged: &privileged},
+				},
+			},
+		},
+	}
+}
+
+// auditDeployment returns a deployment in the apps namespace at a resource version and generation.
+func auditDeployment(resourceVersion string, generation int64, annotations map[string]string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "audit",
+			Namespace:       "apps",
+			ResourceVersion: resourceVersion,
+			Generation:      generation,
+			Annotations:     annotations,
+		},
+	}
+}
+
+func TestAuditorEnqueueUpdate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		oldObject   client.Object
+		newObject   client.Object
+		wantEnqueue bool
+	}{
+		{
+			name:        "ensure periodic resyncs are audited",
+			oldObject:   auditDeployment("1", 1, nil),
+			newObject:   auditDeployment("1", 1, nil),
+			wantEnqueue: true,
+		},
+		{
+			name:        "ensure status updates to a workload are not audited",
+			oldObject:   auditDeployment("1", 1, nil),
+			newObject:   auditDeployment("2", 1, nil),
+			wantEnqueue: false,
+		},
+		{
+			name:        "ensure spec updates to a workload are audited",
+			oldObject:   auditDeployment("1", 1, nil),
+			newObject:   auditDeployment("2", 2, nil),
+			wantEnqueue: true,
+		},
+		{
+			name:        "ensure annotation updates to a workload are audited",
+			oldObject:   auditDeployment("1", 1, nil),
+			newObject:   auditDeployment("2", 1, map[string]string{"exemption": "added"}),
+			wantEnqueue: true,
+		},
+		{
+			name:        "ensure spec updates to a pod are audited without a generation change",
+			oldObject:   auditPod("1", 1, "nginx:1.22", false),
+			newObject:   auditPod("2", 1, "nginx:1.23", false),
+			wantEnqueue: true,
+		},
+		{
+			name:        "ensure status updates to a pod are not audited",
+			oldObject:   auditPod("1", 1, "nginx:1.22", false),
+			newObject:   auditPod("2", 1, "nginx:1.22", false),
+			wantEnqueue: false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+			defer queue.ShutDown()
+
+			auditor := &Auditor{webhook: testWorkloads(t, nil), queue: queue}
+			auditor.enqueueUpdate(tt.oldObject, tt.newObject)
+
+			if enqueued := queue.Len() == 1; enqueued != tt.wantEnqueue {
+				t.Errorf("enqueueUpdate() enqueued = %t, want %t", enqueued, tt.wantEnqueue)
+			}
+		})
+	}
+}
+
+func TestAuditorReconcile(t *testing.T) {
+	t.Parallel()
+
+	webhook := testWorkloads(t, map[string][]client.Object{
+		"Pod": {auditPod("1", 1, "nginx", true)},
+	})
+
+	webhook.DynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(
+		runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{policyReportResource: "PolicyReportList"},
+	)
+
+	auditor := &Auditor{webhook: webhook}
+	reports := webhook.DynamicClient.Resource(policyReportResource).Namespace("apps")
+	ctx := context.Background()
+
+	// the report is created, and then updated when it already exists
+	for i := 0; i < 2; i++ {
+		if err := auditor.reconcile(ctx, "apps"); err != nil {
+			t.Fatalf("reconcile() error = %v", err)
+		}
+	}
+
+	report, err := reports.Get(ctx, policyReportName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unable to get policy report: %v", err)
+	}
+
+	if failed, _, _ := unstructured.NestedInt64(report.Object, "summary", "fail"); failed == 0 {
+		t.Errorf("policy report summary fail = %d, want failures for the privileged pod", failed)
+	}
+
+	results, _, _ := unstructured.NestedSlice(report.Object, "results")
+
+	found := false
+
+	for _, result := range results {
+		result, ok := result.(map[string]interface{})
+		if ok && result["policy"] == validate.PrivilegedValidationName {
+			found = result["result"] == string(validate.ResultFail)
+		}
+	}
+
+	if !found {
+		t.Errorf("policy report has no failed result for [%s]", validate.PrivilegedValidationName)
+	}
+
+	// the report is removed once there are no workloads in the namespace
+	if err := webhook.Workloads["Pod"].GetIndexer().Delete(auditPod("1", 1, "nginx", true)); err != nil {
+		t.Fatalf("unable to delete pod: %v", err)
+	}
+
+	if err := auditor.reconcile(ctx, "apps"); err != nil {
+		t.Fatalf("reconcile() error = %v", err)
+	}
+
+	if _, err := reports.Get(ctx, policyReportName, metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("policy report get error = %v, want not found", err)
+	}
+}
+
+func TestPolicyReportResult(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2022, time.June, 1, 0, 0, 0, 0, time.UTC)
+	workload := auditPod("1", 1, "nginx", true)
+	workload.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Pod"))
+
+	tests := []struct {
+		name           string
+		result         *validate.Result
+		wantMessage    interface{}
+		wantSkipReason interface{}
+	}{
+		{
+			name: "ensure a failed result includes its message",
+			result: &validate.Result{
+				Validation: validate.PrivilegedValidationName,
+				Status:     validate.ResultFail,
+				Message:    "container [audit] is privileged",
+			},
+			wantMessage: "container [audit] is privileged",
+		},
+		{
+			name: "ensure a skipped result includes its skip reason",
+			result: validate.NewSkipResult(
+				validate.PrivilegedValidationName, validate.SkipReasonOwner, "validated via owner",
+			),
+			wantMessage:    "validated via owner",
+			wantSkipReason: string(validate.SkipReasonOwner),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			result := policyReportResult(workload, tt.result, now)
+
+			if result["policy"] != tt.result.Validation || result["result"] != string(tt.result.Status) {
+				t.Errorf("policyReportResult() policy = %v, result = %v", result["policy"], result["result"])
+			}
+
+			if result["severity"] != validate.SeverityHigh {
+				t.Errorf("policyReportResult() severity = %v, want %s", result["severity"], validate.SeverityHigh)
+			}
+
+			if result["message"] != tt.wantMessage {
+				t.Errorf("policyReportResult() message = %v, want %v", result["message"], tt.wantMessage)
+			}
+
+			properties, _ := result["properties"].(map[string]interface{})
+			if properties["skipReason"] != tt.wantSkipReason {
+				t.Errorf("policyReportResult() skipReason = %v, want %v", properties["skipReason"], tt.wantSkipReason)
+			}
+
+			resources, _ := result["resources"].([]interface{})
+			if len(resources) != 1 {
+				t.Fatalf("policyReportResult() resources = %v, want the workload", resources)
+			}
+
+			resource, _ := resources[0].(map[string]interface{})
+			if resource["kind"] != "Pod" || resource["apiVersion"] != "v1" || resource["uid"] != "audit-uid" {
+				t.Errorf("policyReportResult() resource = %v, want the pod", resource)
+			}
+		})
+	}
+}
+
+//nolint:paralleltest
+func TestNewAuditor(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled string
+		wantNil bool
+	}{
+		{
+			name:    "ensure audit mode is disabled by default",
+			enabled: "",
+			wantNil: true,
+		},
+		{
+			name:    "ensure audit mode may be enabled",
+			enabled: "true",
+			wantNil: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(auditEnabledEnv, tt.enabled)
+
+			auditor, err := NewAuditor(testWorkloads(t, nil))
+			if err != nil {
+				t.Fatalf("NewAuditor() error = %v", err)
+			}
+
+			if (auditor == nil) != tt.wantNil {
+				t.Errorf("NewAuditor() = %v, want nil %t", auditor, tt.wantNil)
+			}
+		})
+	}
+}
@@ -0,0 +1,300 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nukleros/pod-security-webhook/logging"
+	"github.com/nukleros/pod-security-webhook/validate"
+)
+
+const (
+	decisionLogSinksEnv         = "DECISION_LOG_SINKS"
+	decisionLogBufferSizeEnv    = "DECISION_LOG_BUFFER_SIZE"
+	decisionLogBatchSizeEnv     = "DECISION_LOG_BATCH_SIZE"
+	decisionLogFlushIntervalEnv = "DECISION_LOG_FLUSH_INTERVAL"
+
+	defaultDecisionLogBufferSize    = 1024
+	defaultDecisionLogBatchSize     = 100
+	defaultDecisionLogFlushInterval = 5 * time.Second
+
+	DecisionLogSinkStdout = "stdout"
+	DecisionLogSinkFile   = "file"
+	DecisionLogSinkHTTP   = "http"
+)
+
+var ErrDecisionLogInvalidConfig = errors.New("invalid decision log configuration")
+
+// DecisionRecord is a record of an individual admission decision.
+type DecisionRecord struct {
+	Time        time.Time          `json:"time"`
+	UID         string             `json:"uid"`
+	User        string             `json:"user"`
+	Groups      []string           `json:"groups,omitempty"`
+	Operation   string             `json:"operation"`
+	Kind        string             `json:"kind"`
+	Namespace   string             `json:"namespace"`
+	Name        string             `json:"name"`
+	Decision    string             `json:"decision"`
+	Message     string             `json:"message,omitempty"`
+	Validations []string           `json:"validations"`
+	Skipped     []*validate.Result `json:"skipped"`
+	Violations  []*validate.Result `json:"violations"`
+	LatencyMS   float64            `json:"latencyMs"`
+}
+
+// DecisionSink is a destination for decision records.
+type DecisionSink interface {
+	// Name returns the name of the sink, used for logging and metrics.
+	Name() string
+
+	// Write writes a batch of decision records to the sink.
+	Write(records []*DecisionRecord) error
+
+	// Close flushes and closes the sink.
+	Close() error
+}
+
+// DecisionLog records admission decisions to a set of sinks.  Records are buffered and written
+// asynchronously by a worker per sink so that admission latency is not affected.  Records are
+// dropped, rather than blocking the admission request, when the buffer for a sink is full.
+type DecisionLog struct {
+	Log     *logging.Logger
+	Metrics *Metrics
+
+	BatchSize     int
+	FlushInterval time.Duration
+
+	queues []*decisionQueue
+	wait   sync.WaitGroup
+	mutex  sync.RWMutex
+	closed bool
+}
+
+// decisionQueue is the buffer of decision records for an individual sink.
+type decisionQueue struct {
+	sink    DecisionSink
+	records chan *DecisionRecord
+}
+
+// NewDecisionLog returns a new decision log which writes to the given sinks.
+func NewDecisionLog(log *logging.Logger, metrics *Metrics, bufferSize int, sinks ...DecisionSink) *DecisionLog {
+	decisionLog := &DecisionLog{
+		Log:           log,
+		Metrics:       metrics,
+		BatchSize:     defaultDecisionLogBatchSize,
+		FlushInterval: defaultDecisionLogFlushInterval,
+	}
+
+	for _, sink := range sinks {
+		decisionLog.queues = append(decisionLog.queues, &decisionQueue{
+			sink:    sink,
+			records: make(chan *DecisionRecord, bufferSize),
+		})
+	}
+
+	return decisionLog
+}
+
+// newDecisionLogFromEnv returns a new decision log from the environment.  A nil decision
+// log is returned if no sinks are configured.
+func newDecisionLogFromEnv(log *logging.Logger, metrics *Metrics) (*DecisionLog, error) {
+	sinkNames := os.Getenv(decisionLogSinksEnv)
+	if sinkNames == "" {
+		return nil, nil
+	}
+
+	bufferSize, err := intFromEnv(decisionLogBufferSizeEnv, defaultDecisionLogBufferSize)
+	if err != nil {
+		return nil, err
+	}
+
+	batchSize, err := intFromEnv(decisionLogBatchSizeEnv, defaultDecisionLogBatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	flushInterval, err := durationFromEnv(decisionLogFlushIntervalEnv, defaultDecisionLogFlushInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	sinks := []DecisionSink{}
+
+	for _, sinkName := range strings.Split(sinkNames, ",") {
+		var sink DecisionSink
+
+		switch strings.TrimSpace(sinkName) {
+		case DecisionLogSinkStdout:
+			sink = NewStdoutDecisionSink()
+		case DecisionLogSinkFile:
+			sink, err = newFileDecisionSinkFromEnv()
+		case DecisionLogSinkHTTP:
+			sink, err = newHTTPDecisionSinkFromEnv()
+		default:
+			err = fmt.Errorf("%w - unknown sink [%s=%s]", ErrDecisionLogInvalidConfig, decisionLogSinksEnv, sinkName)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		sinks = append(sinks, sink)
+	}
+
+	decisionLog := NewDecisionLog(log, metrics, bufferSize, sinks...)
+	decisionLog.BatchSize = batchSize
+	decisionLog.FlushInterval = flushInterval
+
+	return decisionLog, nil
+}
+
+// Start starts a worker for each sink of the decision log.
+func (decisionLog *DecisionLog) Start() {
+	for _, queue := range decisionLog.queues {
+		decisionLog.wait.Add(1)
+
+		go decisionLog.worker(queue)
+	}
+}
+
+// Record records a decision to each sink without blocking.  The record is dropped for a sink
+// if the buffer for the sink is full.
+func (decisionLog *DecisionLog) Record(record *DecisionRecord) {
+	decisionLog.mutex.RLock()
+	defer decisionLog.mutex.RUnlock()
+
+	if decisionLog.closed {
+		return
+	}
+
+	for _, queue := range decisionLog.queues {
+		select {
+		case queue.records <- record:
+		default:
+			decisionLog.Metrics.decisionDropped(queue.sink.Name())
+		}
+	}
+}
+
+// Close stops accepting records, flushes any buffered records and closes each sink.
+func (decisionLog *DecisionLog) Close() {
+	decisionLog.mutex.Lock()
+	if decisionLog.closed {
+		decisionLog.mutex.Unlock()
+
+		return
+	}
+
+	decisionLog.closed = true
+	decisionLog.mutex.Unlock()
+
+	for _, queue := range decisionLog.queues {
+		close(queue.records)
+	}
+
+	decisionLog.wait.Wait()
+}
+
+// worker writes batches of records to a sink.  A batch is written when it reaches the batch
+// size or when the flush interval elapses, whichever comes first.
+func (decisionLog *DecisionLog) worker(queue *decisionQueue) {
+	defer decisionLog.wait.Done()
+
+	ticker := time.NewTicker(decisionLog.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*DecisionRecord, 0, decisionLog.BatchSize)
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+
+		if err := queue.sink.Write(batch); err != nil {
+			decisionLog.Log.Errorf("%s - unable to write %d decision records to sink [%s]", err, len(batch), queue.sink.Name())
+			decisionLog.Metrics.decisionErrored(queue.sink.Name(), len(batch))
+		}
+
+		batch = make([]*DecisionRecord, 0, decisionLog.BatchSize)
+	}
+
+	for {
+		select {
+		case record, open := <-queue.records:
+			if !open {
+				flush()
+
+				if err := queue.sink.Close(); err != nil {
+					decisionLog.Log.Errorf("%s - unable to close decision sink [%s]", err, queue.sink.Name())
+				}
+
+				return
+			}
+
+			batch = append(batch, record)
+
+			if len(batch) >= decisionLog.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// decisionRecord returns the decision record for an operation.
+func (operation *Operation) decisionRecord() *DecisionRecord {
+	record := &DecisionRecord{
+		Time:        operation.Started,
+		Decision:    "denied",
+		Validations: []string{},
+		Skipped:     []*validate.Result{},
+		Violations:  []*validate.Result{},
+		LatencyMS:   float64(time.Since(operation.Started)) / float64(time.Millisecond),
+	}
+
+	if operation.Permitted {
+		record.Decision = "allowed"
+	}
+
+	if operation.ResponseError != nil {
+		record.Message = operation.ResponseError.Error()
+	}
+
+	if operation.Review != nil && operation.Review.Request != nil {
+		request := operation.Review.Request
+		record.UID = string(request.UID)
+		record.User = request.UserInfo.Username
+		record.Groups = request.UserInfo.Groups
+		record.Operation = string(request.Operation)
+		record.Kind = request.Kind.Kind
+		record.Namespace = request.Namespace
+		record.Name = request.Name
+	}
+
+	if operation.Resource != nil && record.Name == "" {
+		record.Name = operation.Resource.GetName()
+	}
+
+	for _, result := range operation.Results {
+		switch result.Status {
+		case validate.ResultSkip:
+			record.Skipped = append(record.Skipped, result)
+		case validate.ResultFail, validate.ResultWarn:
+			record.Validations = append(record.Validations, result.Validation)
+			record.Violations = append(record.Violations, result)
+		case validate.ResultPass:
+			record.Validations = append(record.Validations, result.Validation)
+		}
+	}
+
+	return record
+}
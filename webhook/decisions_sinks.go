@@ -0,0 +1,344 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	decisionLogFilePathEnv       = "DECISION_LOG_FILE_PATH"
+	decisionLogFileMaxSizeEnv    = "DECISION_LOG_FILE_MAX_SIZE_MB"
+	decisionLogFileMaxBackupsEnv = "DECISION_LOG_FILE_MAX_BACKUPS"
+
+	decisionLogHTTPURLEnv        = "DECISION_LOG_HTTP_URL"
+	decisionLogHTTPTimeoutEnv    = "DECISION_LOG_HTTP_TIMEOUT"
+	decisionLogHTTPMaxRetriesEnv = "DECISION_LOG_HTTP_MAX_RETRIES"
+
+	defaultDecisionLogFilePath       = "/var/log/pod-security-webhook/decisions.log"
+	defaultDecisionLogFileMaxSize    = 100
+	defaultDecisionLogFileMaxBackups = 3
+
+	defaultDecisionLogHTTPTimeout    = 5 * time.Second
+	defaultDecisionLogHTTPMaxRetries = 3
+	defaultDecisionLogHTTPBackoff    = 500 * time.Millisecond
+
+	bytesPerMegabyte = 1024 * 1024
+)
+
+var ErrDecisionLogHTTPStatus = errors.New("unexpected response status from decision log endpoint")
+
+// writeRecords writes decision records as newline-delimited json.
+func writeRecords(writer io.Writer, records []*DecisionRecord) (int, error) {
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return 0, fmt.Errorf("%w - unable to encode decision record", err)
+		}
+	}
+
+	written, err := writer.Write(buffer.Bytes())
+	if err != nil {
+		return written, fmt.Errorf("%w - unable to write decision records", err)
+	}
+
+	return written, nil
+}
+
+// StdoutDecisionSink writes decision records to stdout as newline-delimited json.
+type StdoutDecisionSink struct {
+	writer io.Writer
+}
+
+// NewStdoutDecisionSink returns a new decision sink which writes to stdout.
+func NewStdoutDecisionSink() *StdoutDecisionSink {
+	return &StdoutDecisionSink{writer: os.Stdout}
+}
+
+// Name returns the name of the sink.
+func (sink *StdoutDecisionSink) Name() string { return DecisionLogSinkStdout }
+
+// Write writes a batch of decision records to stdout.
+func (sink *StdoutDecisionSink) Write(records []*DecisionRecord) error {
+	_, err := writeRecords(sink.writer, records)
+
+	return err
+}
+
+// Close closes the sink.
+func (sink *StdoutDecisionSink) Close() error { return nil }
+
+// FileDecisionSink writes decision records to a local file as newline-delimited json.  The file
+// is rotated when it reaches its maximum size, keeping a maximum number of backups named with
+// a numeric suffix, for example decisions.log.1.
+type FileDecisionSink struct {
+	Path       string
+	MaxSize    int64
+	MaxBackups int
+
+	file *os.File
+	size int64
+}
+
+// NewFileDecisionSink returns a new decision sink which writes to a rotating local file.
+func NewFileDecisionSink(path string, maxSize int64, maxBackups int) (*FileDecisionSink, error) {
+	sink := &FileDecisionSink{
+		Path:       path,
+		MaxSize:    maxSize,
+		MaxBackups: maxBackups,
+	}
+
+	if err := sink.open(); err != nil {
+		return nil, err
+	}
+
+	return sink, nil
+}
+
+// newFileDecisionSinkFromEnv returns a new file decision sink from the environment.
+func newFileDecisionSinkFromEnv() (*FileDecisionSink, error) {
+	path := os.Getenv(decisionLogFilePathEnv)
+	if path == "" {
+		path = defaultDecisionLogFilePath
+	}
+
+	maxSize, err := intFromEnv(decisionLogFileMaxSizeEnv, defaultDecisionLogFileMaxSize)
+	if err != nil {
+		return nil, err
+	}
+
+	maxBackups, err := nonNegativeIntFromEnv(decisionLogFileMaxBackupsEnv, defaultDecisionLogFileMaxBackups)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFileDecisionSink(path, int64(maxSize)*bytesPerMegabyte, maxBackups)
+}
+
+// Name returns the name of the sink.
+func (sink *FileDecisionSink) Name() string { return DecisionLogSinkFile }
+
+// Write writes a batch of decision records to the file, rotating the file first if the
+// batch would cause the file to exceed its maximum size.  The file is reopened if it could not be
+// reopened after a previous rotation.
+func (sink *FileDecisionSink) Write(records []*DecisionRecord) error {
+	buffer := &bytes.Buffer{}
+	if _, err := writeRecords(buffer, records); err != nil {
+		return err
+	}
+
+	if sink.file == nil {
+		if err := sink.open(); err != nil {
+			return err
+		}
+	}
+
+	if sink.size > 0 && sink.size+int64(buffer.Len()) > sink.MaxSize {
+		if err := sink.rotate(); err != nil {
+			return err
+		}
+	}
+
+	written, err := sink.file.Write(buffer.Bytes())
+	sink.size += int64(written)
+
+	if err != nil {
+		return fmt.Errorf("%w - unable to write decision records to file [%s]", err, sink.Path)
+	}
+
+	return nil
+}
+
+// Close closes the file.
+func (sink *FileDecisionSink) Close() error {
+	if sink.file == nil {
+		return nil
+	}
+
+	file := sink.file
+	sink.file = nil
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("%w - unable to close decision log file [%s]", err, sink.Path)
+	}
+
+	return nil
+}
+
+// open opens the file for appending.
+func (sink *FileDecisionSink) open() error {
+	if err := os.MkdirAll(filepath.Dir(sink.Path), 0o750); err != nil {
+		return fmt.Errorf("%w - unable to create decision log directory for [%s]", err, sink.Path)
+	}
+
+	file, err := os.OpenFile(sink.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("%w - unable to open decision log file [%s]", err, sink.Path)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+
+		return fmt.Errorf("%w - unable to stat decision log file [%s]", err, sink.Path)
+	}
+
+	sink.file = file
+	sink.size = info.Size()
+
+	return nil
+}
+
+// rotate closes the current file, shifts each backup file up by one, discarding the oldest, and
+// opens a new file.  If the files can not be shifted, the current file is reopened so that records
+// are still written to it, and rotation is attempted again on the next write.
+func (sink *FileDecisionSink) rotate() error {
+	if err := sink.Close(); err != nil {
+		return err
+	}
+
+	if err := sink.shift(); err != nil {
+		if openErr := sink.open(); openErr != nil {
+			return fmt.Errorf("%w - %s", err, openErr)
+		}
+
+		return err
+	}
+
+	return sink.open()
+}
+
+// shift shifts each backup file up by one, discarding the oldest, or removes the current file if
+// there are no backups.
+func (sink *FileDecisionSink) shift() error {
+	for i := sink.MaxBackups - 1; i >= 0; i-- {
+		source := sink.Path
+		if i > 0 {
+			source = fmt.Sprintf("%s.%d", sink.Path, i)
+		}
+
+		if _, err := os.Stat(source); err != nil {
+			continue
+		}
+
+		if err := os.Rename(source, fmt.Sprintf("%s.%d", sink.Path, i+1)); err != nil {
+			return fmt.Errorf("%w - unable to rotate decision log file [%s]", err, source)
+		}
+	}
+
+	// without backups, the current file is simply truncated
+	if sink.MaxBackups == 0 {
+		if err := os.Remove(sink.Path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("%w - unable to remove decision log file [%s]", err, sink.Path)
+		}
+	}
+
+	return nil
+}
+
+// HTTPDecisionSink writes batches of decision records to an http endpoint as a json array.
+// Failed requests are retried with an exponential backoff.
+type HTTPDecisionSink struct {
+	URL        string
+	Client     *http.Client
+	MaxRetries int
+	Backoff    time.Duration
+}
+
+// NewHTTPDecisionSink returns a new decision sink which posts to an http endpoint.
+func NewHTTPDecisionSink(url string, timeout time.Duration, maxRetries int) *HTTPDecisionSink {
+	return &HTTPDecisionSink{
+		URL:        url,
+		Client:     &http.Client{Timeout: timeout},
+		MaxRetries: maxRetries,
+		Backoff:    defaultDecisionLogHTTPBackoff,
+	}
+}
+
+// newHTTPDecisionSinkFromEnv returns a new http decision sink from the environment.
+func newHTTPDecisionSinkFromEnv() (*HTTPDecisionSink, error) {
+	url := os.Getenv(decisionLogHTTPURLEnv)
+	if url == "" {
+		return nil, fmt.Errorf("%w - missing [%s]", ErrDecisionLogInvalidConfig, decisionLogHTTPURLEnv)
+	}
+
+	timeout, err := durationFromEnv(decisionLogHTTPTimeoutEnv, defaultDecisionLogHTTPTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	maxRetries, err := nonNegativeIntFromEnv(decisionLogHTTPMaxRetriesEnv, defaultDecisionLogHTTPMaxRetries)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewHTTPDecisionSink(url, timeout, maxRetries), nil
+}
+
+// Name returns the name of the sink.
+func (sink *HTTPDecisionSink) Name() string { return DecisionLogSinkHTTP }
+
+// Write posts a batch of decision records to the http endpoint, retrying on failure.
+func (sink *HTTPDecisionSink) Write(records []*DecisionRecord) error {
+	body, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("%w - unable to encode decision records", err)
+	}
+
+	backoff := sink.Backoff
+
+	for attempt := 0; ; attempt++ {
+		err = sink.post(body)
+		if err == nil || attempt >= sink.MaxRetries {
+			return err
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// Close closes the sink.
+func (sink *HTTPDecisionSink) Close() error {
+	sink.Client.CloseIdleConnections()
+
+	return nil
+}
+
+// post posts a body to the http endpoint.
+func (sink *HTTPDecisionSink) post(body []byte) error {
+	request, err := http.NewRequestWithContext(context.Background(), http.MethodPost, sink.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w - unable to create decision log request", err)
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := sink.Client.Do(request)
+	if err != nil {
+		return fmt.Errorf("%w - unable to send decision log request", err)
+	}
+
+	defer response.Body.Close()
+
+	//nolint:errcheck
+	_, _ = io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%w - [%d]", ErrDecisionLogHTTPStatus, response.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,249 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/nukleros/pod-security-webhook/logging"
+)
+
+type memoryDecisionSink struct {
+	records []*DecisionRecord
+	closed  bool
+}
+
+func (sink *memoryDecisionSink) Name() string { return "memory" }
+
+func (sink *memoryDecisionSink) Write(records []*DecisionRecord) error {
+	sink.records = append(sink.records, records...)
+
+	return nil
+}
+
+func (sink *memoryDecisionSink) Close() error {
+	sink.closed = true
+
+	return nil
+}
+
+func testDecisionLogger() *logging.Logger {
+	return logging.New("test", logging.FormatJSON, logging.ErrorLevel, &bytes.Buffer{})
+}
+
+func TestDecisionLogDropsWhenFull(t *testing.T) {
+	t.Parallel()
+
+	sink := &memoryDecisionSink{}
+	metrics := NewMetrics()
+	decisionLog := NewDecisionLog(testDecisionLogger(), metrics, 2, sink)
+
+	// records are buffered but not written until the workers are started
+	for i := 0; i < 5; i++ {
+		decisionLog.Record(&DecisionRecord{UID: "uid"})
+	}
+
+	if dropped := testutil.ToFloat64(metrics.DecisionsDropped.WithLabelValues("memory")); dropped != 3 {
+		t.Errorf("DecisionLog.Record() dropped = %v, want %v", dropped, 3)
+	}
+
+	decisionLog.Start()
+	decisionLog.Close()
+
+	if len(sink.records) != 2 {
+		t.Errorf("DecisionLog.Close() flushed records = %v, want %v", len(sink.records), 2)
+	}
+
+	if !sink.closed {
+		t.Errorf("DecisionLog.Close() did not close sink")
+	}
+
+	// records after close are ignored rather than panicking
+	decisionLog.Record(&DecisionRecord{UID: "uid"})
+}
+
+func TestFileDecisionSinkRotates(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "decisions.log")
+
+	sink, err := NewFileDecisionSink(path, 200, 2)
+	if err != nil {
+		t.Fatalf("NewFileDecisionSink() error = %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		if err := sink.Write([]*DecisionRecord{{UID: "uid", Decision: "allowed"}}); err != nil {
+			t.Fatalf("FileDecisionSink.Write() error = %v", err)
+		}
+	}
+
+	if err := sink.Close(); err != nil {
+		t.Fatalf("FileDecisionSink.Close() error = %v", err)
+	}
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Errorf("FileDecisionSink.Write() missing file %s", name)
+
+			continue
+		}
+
+		if info.Size() > 200 {
+			t.Errorf("FileDecisionSink.Write() file %s size = %v, want <= %v", name, info.Size(), 200)
+		}
+	}
+
+	if _, err := os.Stat(path + ".3"); err == nil {
+		t.Errorf("FileDecisionSink.Write() kept more than the maximum backups")
+	}
+}
+
+func TestFileDecisionSinkRotationFailure(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "decisions.log")
+
+	sink, err := NewFileDecisionSink(path, 100, 1)
+	if err != nil {
+		t.Fatalf("NewFileDecisionSink() error = %v", err)
+	}
+	defer sink.Close()
+
+	records := []*DecisionRecord{{UID: "uid", Decision: "allowed"}}
+
+	if err := sink.Write(records); err != nil {
+		t.Fatalf("FileDecisionSink.Write() error = %v", err)
+	}
+
+	// a directory in place of the backup file prevents the current file from being rotated
+	if err := os.MkdirAll(filepath.Join(path+".1", "blocked"), 0o750); err != nil {
+		t.Fatalf("unable to create directory: %v", err)
+	}
+
+	if err := sink.Write(records); err == nil {
+		t.Fatalf("FileDecisionSink.Write() error = nil, want a rotation error")
+	}
+
+	if sink.file == nil {
+		t.Fatalf("FileDecisionSink.Write() left the file closed after a failed rotation")
+	}
+
+	// once the backup can be written, the file is rotated and records are written again
+	if err := os.RemoveAll(path + ".1"); err != nil {
+		t.Fatalf("unable to remove directory: %v", err)
+	}
+
+	if err := sink.Write(records); err != nil {
+		t.Fatalf("FileDecisionSink.Write() error = %v after the rotation failure was resolved", err)
+	}
+
+	for _, name := range []string{path, path + ".1"} {
+		if info, err := os.Stat(name); err != nil || info.Size() == 0 {
+			t.Errorf("FileDecisionSink.Write() file %s is missing or empty", name)
+		}
+	}
+}
+
+func TestHTTPDecisionSinkRetries(t *testing.T) {
+	t.Parallel()
+
+	var attempts int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		records := []*DecisionRecord{}
+		if err := json.NewDecoder(r.Body).Decode(&records); err != nil || len(records) != 2 {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sink := NewHTTPDecisionSink(server.URL, time.Second, 3)
+	sink.Backoff = time.Millisecond
+
+	if err := sink.Write([]*DecisionRecord{{UID: "one"}, {UID: "two"}}); err != nil {
+		t.Errorf("HTTPDecisionSink.Write() error = %v", err)
+	}
+
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Errorf("HTTPDecisionSink.Write() attempts = %v, want %v", got, 3)
+	}
+
+	sink.MaxRetries = 0
+	atomic.StoreInt32(&attempts, 0)
+
+	if err := sink.Write([]*DecisionRecord{{UID: "one"}, {UID: "two"}}); err == nil {
+		t.Errorf("HTTPDecisionSink.Write() expected error without retries")
+	}
+}
+
+//nolint:paralleltest
+func TestNewFileDecisionSinkFromEnv(t *testing.T) {
+	tests := []struct {
+		name           string
+		maxBackups     string
+		wantMaxBackups int
+		wantErr        error
+	}{
+		{
+			name:           "ensure the default number of backups is kept",
+			maxBackups:     "",
+			wantMaxBackups: defaultDecisionLogFileMaxBackups,
+		},
+		{
+			name:           "ensure backups may be disabled",
+			maxBackups:     "0",
+			wantMaxBackups: 0,
+		},
+		{
+			name:       "ensure a negative number of backups is invalid",
+			maxBackups: "-1",
+			wantErr:    ErrInvalidEnv,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(decisionLogFilePathEnv, filepath.Join(t.TempDir(), "decisions.log"))
+			t.Setenv(decisionLogFileMaxBackupsEnv, tt.maxBackups)
+
+			sink, err := newFileDecisionSinkFromEnv()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("newFileDecisionSinkFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			defer sink.Close()
+
+			if sink.MaxBackups != tt.wantMaxBackups {
+				t.Errorf("newFileDecisionSinkFromEnv() MaxBackups = %d, want %d", sink.MaxBackups, tt.wantMaxBackups)
+			}
+		})
+	}
+}
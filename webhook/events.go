@@ -0,0 +1,142 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	eventsEnabledEnv = "EVENTS_ENABLED"
+	eventsQPSEnv     = "EVENTS_QPS"
+	eventsBurstEnv   = "EVENTS_BURST"
+
+	eventsComponent = "pod-security-webhook"
+
+	// EventReasonValidationFailed is the event reason for a rejected resource.
+	EventReasonValidationFailed = "ValidationFailed"
+
+	// EventReasonValidationWarning is the event reason for a resource permitted with warnings.
+	EventReasonValidationWarning = "ValidationWarning"
+)
+
+var ErrEventsInvalidConfig = errors.New("invalid events configuration")
+
+// newEventBroadcaster returns a new event broadcaster which records events to the cluster.  Similar
+// events are deduplicated and aggregated, and events are rate limited per object, using the event
+// correlator.  A nil broadcaster is returned if events are disabled.
+func newEventBroadcaster(kubernetesClient kubernetes.Interface) (record.EventBroadcaster, error) {
+	if os.Getenv(eventsEnabledEnv) == "false" {
+		return nil, nil
+	}
+
+	options := record.CorrelatorOptions{}
+
+	if qps := os.Getenv(eventsQPSEnv); qps != "" {
+		qpsFloat, err := strconv.ParseFloat(qps, 32)
+		if err != nil || qpsFloat <= 0 {
+			return nil, fmt.Errorf("%w - [%s=%s]", ErrEventsInvalidConfig, eventsQPSEnv, qps)
+		}
+
+		options.QPS = float32(qpsFloat)
+	}
+
+	if burst := os.Getenv(eventsBurstEnv); burst != "" {
+		burstInt, err := strconv.Atoi(burst)
+		if err != nil || burstInt <= 0 {
+			return nil, fmt.Errorf("%w - [%s=%s]", ErrEventsInvalidConfig, eventsBurstEnv, burst)
+		}
+
+		options.BurstSize = burstInt
+	}
+
+	broadcaster := record.NewBroadcasterWithCorrelatorOptions(options)
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{
+		Interface: kubernetesClient.CoreV1().Events(metav1.NamespaceAll),
+	})
+
+	return broadcaster, nil
+}
+
+// newEventRecorder returns a new event recorder from a broadcaster.
+func newEventRecorder(broadcaster record.EventBroadcaster) record.EventRecorder {
+	if broadcaster == nil {
+		return nil
+	}
+
+	return broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: eventsComponent})
+}
+
+// recordEvents records an event for a rejected resource, or a resource which was permitted with
+// warnings.
+func (webhook *Webhook) recordEvents(operation *Operation) {
+	if webhook.Recorder == nil || operation.Resource == nil || operation.Review == nil || operation.Review.Request == nil {
+		return
+	}
+
+	// do not record events for dry run requests as nothing is actually persisted
+	if operation.Review.Request.DryRun != nil && *operation.Review.Request.DryRun {
+		return
+	}
+
+	reference := eventReference(operation.Resource, operation.Review.Request.Operation)
+	if reference == nil {
+		return
+	}
+
+	if !operation.Permitted && operation.ResponseError != nil {
+		webhook.Recorder.Event(reference, corev1.EventTypeWarning, EventReasonValidationFailed, operation.ResponseError.Error())
+
+		return
+	}
+
+	if len(operation.Warnings) > 0 {
+		webhook.Recorder.Event(reference, corev1.EventTypeWarning, EventReasonValidationWarning, strings.Join(operation.Warnings, "; "))
+	}
+}
+
+// eventReference returns the object reference that an event should be attached to.  Resources
+// which are being created do not yet exist, so events are attached to the controlling owner of
+// the resource if it has one, such as the cron job which created a job.  A nil reference is
+// returned if there is no suitable object to attach the event to.
+func eventReference(resource client.Object, operation admissionv1.Operation) *corev1.ObjectReference {
+	if operation == admissionv1.Create {
+		if owner := metav1.GetControllerOfNoCopy(resource); owner != nil {
+			return &corev1.ObjectReference{
+				APIVersion: owner.APIVersion,
+				Kind:       owner.Kind,
+				Name:       owner.Name,
+				Namespace:  resource.GetNamespace(),
+				UID:        owner.UID,
+			}
+		}
+	}
+
+	if resource.GetName() == "" {
+		return nil
+	}
+
+	apiVersion, kind := resource.GetObjectKind().GroupVersionKind().ToAPIVersionAndKind()
+
+	return &corev1.ObjectReference{
+		APIVersion: apiVersion,
+		Kind:       kind,
+		Name:       resource.GetName(),
+		Namespace:  resource.GetNamespace(),
+		UID:        resource.GetUID(),
+	}
+}
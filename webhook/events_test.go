@@ -0,0 +1,261 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package webhook
+
+import (
+	"errors"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+var errEventsDenied = errors.New("denied by validation [host-pid]")
+
+// eventResource returns a resource for an event, owned by a controller if the owner kind is set.
+func eventResource(apiVersion, kind, name, ownerAPIVersion, ownerKind string) *unstructured.Unstructured {
+	resource := &unstructured.Unstructured{}
+	resource.SetAPIVersion(apiVersion)
+	resource.SetKind(kind)
+	resource.SetName(name)
+	resource.SetNamespace("apps")
+	resource.SetUID(types.UID(name + "-uid"))
+
+	if ownerKind != "" {
+		controller := true
+
+		resource.SetOwnerReferences([]metav1.OwnerReference{
+			{
+				APIVersion: ownerAPIVersion,
+				Kind:       ownerKind,
+				Name:       "owner",
+				UID:        types.UID("owner-uid"),
+				Controller: &controller,
+			},
+		})
+	}
+
+	return resource
+}
+
+func TestRecordEvents(t *testing.T) {
+	t.Parallel()
+
+	dryRun := true
+
+	tests := []struct {
+		name      string
+		resource  *unstructured.Unstructured
+		operation admissionv1.Operation
+		dryRun    *bool
+		permitted bool
+		err       error
+		warnings  []string
+		want      string
+	}{
+		{
+			name:      "ensure a rejected resource which is being created records a failed event",
+			resource:  eventResource("v1", "Pod", "denied", "", ""),
+			operation: admissionv1.Create,
+			err:       errEventsDenied,
+			want:      "Warning ValidationFailed " + errEventsDenied.Error() + " involvedObject{kind=Pod,apiVersion=v1}",
+		},
+		{
+			name:      "ensure a resource permitted with warnings on update records a warning event",
+			resource:  eventResource("apps/v1", "Deployment", "warned", "", ""),
+			operation: admissionv1.Update,
+			permitted: true,
+			warnings:  []string{"first warning", "second warning"},
+			want:      "Warning ValidationWarning first warning; second warning involvedObject{kind=Deployment,apiVersion=apps/v1}",
+		},
+		{
+			name:      "ensure events for a resource being created are attached to its owner",
+			resource:  eventResource("batch/v1", "Job", "owned", "batch/v1", "CronJob"),
+			operation: admissionv1.Create,
+			err:       errEventsDenied,
+			want:      "Warning ValidationFailed " + errEventsDenied.Error() + " involvedObject{kind=CronJob,apiVersion=batch/v1}",
+		},
+		{
+			name:      "ensure events for an owned resource being updated are attached to the resource",
+			resource:  eventResource("batch/v1", "Job", "owned", "batch/v1", "CronJob"),
+			operation: admissionv1.Update,
+			err:       errEventsDenied,
+			want:      "Warning ValidationFailed " + errEventsDenied.Error() + " involvedObject{kind=Job,apiVersion=batch/v1}",
+		},
+		{
+			name:      "ensure no event is recorded for a permitted resource without warnings",
+			resource:  eventResource("v1", "Pod", "permitted", "", ""),
+			operation: admissionv1.Create,
+			permitted: true,
+		},
+		{
+			name:      "ensure no event is recorded for a dry run request",
+			resource:  eventResource("v1", "Pod", "dry-run", "", ""),
+			operation: admissionv1.Create,
+			dryRun:    &dryRun,
+			err:       errEventsDenied,
+		},
+		{
+			name:      "ensure no event is recorded for an unnamed resource without an owner",
+			resource:  eventResource("v1", "Pod", "", "", ""),
+			operation: admissionv1.Create,
+			err:       errEventsDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			recorder := record.NewFakeRecorder(10)
+			recorder.IncludeObject = true
+
+			webhook := &Webhook{Recorder: recorder}
+			webhook.recordEvents(&Operation{
+				Resource: tt.resource,
+				Review: &admissionv1.AdmissionReview{
+					Request: &admissionv1.AdmissionRequest{Operation: tt.operation, DryRun: tt.dryRun},
+				},
+				Permitted:     tt.permitted,
+				ResponseError: tt.err,
+				Warnings:      tt.warnings,
+			})
+
+			close(recorder.Events)
+
+			events := []string{}
+			for event := range recorder.Events {
+				events = append(events, event)
+			}
+
+			if tt.want == "" {
+				if len(events) > 0 {
+					t.Errorf("recordEvents() recorded %v, want no events", events)
+				}
+
+				return
+			}
+
+			if len(events) != 1 || events[0] != tt.want {
+				t.Errorf("recordEvents() recorded %v, want [%s]", events, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventReference(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		resource  *unstructured.Unstructured
+		operation admissionv1.Operation
+		wantName  string
+		wantUID   types.UID
+	}{
+		{
+			name:      "ensure a resource being created is referenced via its controller",
+			resource:  eventResource("apps/v1", "ReplicaSet", "replicas", "apps/v1", "Deployment"),
+			operation: admissionv1.Create,
+			wantName:  "owner",
+			wantUID:   "owner-uid",
+		},
+		{
+			name:      "ensure a resource without a controller is referenced directly",
+			resource:  eventResource("apps/v1", "Deployment", "deployment", "", ""),
+			operation: admissionv1.Create,
+			wantName:  "deployment",
+			wantUID:   "deployment-uid",
+		},
+		{
+			name:      "ensure a resource being updated is referenced directly",
+			resource:  eventResource("apps/v1", "ReplicaSet", "replicas", "apps/v1", "Deployment"),
+			operation: admissionv1.Update,
+			wantName:  "replicas",
+			wantUID:   "replicas-uid",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			reference := eventReference(tt.resource, tt.operation)
+			if reference == nil {
+				t.Fatalf("eventReference() = nil, want reference to [%s]", tt.wantName)
+			}
+
+			if reference.Name != tt.wantName || reference.UID != tt.wantUID || reference.Namespace != "apps" {
+				t.Errorf("eventReference() = %+v, want [apps/%s] with uid [%s]", reference, tt.wantName, tt.wantUID)
+			}
+		})
+	}
+}
+
+//nolint:paralleltest
+func TestNewEventBroadcaster(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantNil bool
+		wantErr error
+	}{
+		{
+			name:    "ensure events may be disabled",
+			env:     map[string]string{eventsEnabledEnv: "false"},
+			wantNil: true,
+		},
+		{
+			name: "ensure events are rate limited from the environment",
+			env:  map[string]string{eventsQPSEnv: "0.5", eventsBurstEnv: "10"},
+		},
+		{
+			name:    "ensure an invalid qps is invalid",
+			env:     map[string]string{eventsQPSEnv: "-1"},
+			wantErr: ErrEventsInvalidConfig,
+		},
+		{
+			name:    "ensure an invalid burst is invalid",
+			env:     map[string]string{eventsBurstEnv: "many"},
+			wantErr: ErrEventsInvalidConfig,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			broadcaster, err := newEventBroadcaster(fake.NewSimpleClientset())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("newEventBroadcaster() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if broadcaster != nil {
+				defer broadcaster.Shutdown()
+			}
+
+			if err != nil {
+				return
+			}
+
+			if (broadcaster == nil) != tt.wantNil {
+				t.Errorf("newEventBroadcaster() = %v, want nil %t", broadcaster, tt.wantNil)
+			}
+
+			if (newEventRecorder(broadcaster) == nil) != tt.wantNil {
+				t.Errorf("newEventRecorder() nil = %t, want nil %t", !tt.wantNil, tt.wantNil)
+			}
+		})
+	}
+}
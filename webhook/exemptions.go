@@ -0,0 +1,194 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/nukleros/pod-security-webhook/resources"
+	"github.com/nukleros/pod-security-webhook/validate"
+)
+
+const (
+	exemptionsWithinParam   = "within"
+	defaultExemptionsWithin = 7 * 24 * time.Hour
+)
+
+var ErrExemptionsInvalidWithin = errors.New("invalid within parameter")
+
+// ExemptionReport is an exemption found on a resource in the cluster.
+type ExemptionReport struct {
+	*validate.Exemption
+
+	Kind      string                   `json:"kind"`
+	Namespace string                   `json:"namespace"`
+	Name      string                   `json:"name"`
+	Status    validate.ExemptionStatus `json:"status"`
+	Error     string                   `json:"error,omitempty"`
+}
+
+// ExemptionsResponse is the response returned from the exemptions endpoint.
+type ExemptionsResponse struct {
+	Exemptions []*ExemptionReport `json:"exemptions"`
+}
+
+// exemptions lists exemptions in the cluster which have expired or will expire within
+// the duration requested via the 'within' query parameter.  Invalid exemptions are also
+// listed so that they may be corrected.
+func (webhook *Webhook) exemptions(w http.ResponseWriter, r *http.Request) {
+	within := defaultExemptionsWithin
+
+	if param := r.URL.Query().Get(exemptionsWithinParam); param != "" {
+		duration, err := time.ParseDuration(param)
+		if err != nil {
+			webhook.writeErrorMessage(w, fmt.Errorf("%w - [%s]", ErrExemptionsInvalidWithin, param), http.StatusBadRequest)
+
+			return
+		}
+
+		within = duration
+	}
+
+	reports, err := webhook.listExemptions(time.Now(), within)
+	if err != nil {
+		webhook.writeErrorMessage(w, fmt.Errorf("%w - error listing exemptions", err), http.StatusInternalServerError)
+
+		return
+	}
+
+	response, err := json.Marshal(&ExemptionsResponse{Exemptions: reports})
+	if err != nil {
+		webhook.writeErrorMessage(w, fmt.Errorf("%w - unable to marshal the json response", err), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if _, err := w.Write(response); err != nil {
+		webhook.Log.Errorf("%s - error writing response", err)
+	}
+}
+
+// listExemptions lists all exemptions in the cluster which are invalid, have expired or
+// will expire within a given duration.
+func (webhook *Webhook) listExemptions(now time.Time, within time.Duration) ([]*ExemptionReport, error) {
+	workloads, err := webhook.listWorkloads(metav1.NamespaceAll)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := []*ExemptionReport{}
+
+	for _, workload := range workloads {
+		for key, value := range workload.GetAnnotations() {
+			name := strings.TrimPrefix(key, validate.ExemptionAnnotationPrefix+"/")
+			if name == key {
+				continue
+			}
+
+			report := &ExemptionReport{
+				Kind:      workload.GetObjectKind().GroupVersionKind().Kind,
+				Namespace: workload.GetNamespace(),
+				Name:      workload.GetName(),
+			}
+
+			exemption, err := validate.ParseExemption(name, value)
+			if err != nil {
+				report.Exemption = &validate.Exemption{Validation: name}
+				report.Error = err.Error()
+				reports = append(reports, report)
+
+				continue
+			}
+
+			report.Exemption = exemption
+			report.Status = exemption.Status(now, webhook.ExemptionGracePeriod)
+
+			// only report active exemptions if they are expiring soon
+			if report.Status == validate.ExemptionActive && exemption.Expires.After(now.Add(within)) {
+				continue
+			}
+
+			reports = append(reports, report)
+		}
+	}
+
+	return reports, nil
+}
+
+// workloadInformers returns the informer for each kind of workload validated by the webhook,
+// keyed by kind.  The informers must be registered prior to starting the informer factory.
+func workloadInformers(factory informers.SharedInformerFactory) (map[string]cache.SharedIndexInformer, error) {
+	workloads := map[string]cache.SharedIndexInformer{}
+
+	for _, kind := range resources.Kinds() {
+		informer, err := factory.ForResource(kind.GroupVersion().WithResource(kind.Resource))
+		if err != nil {
+			return nil, fmt.Errorf("%w - unable to create informer for [%s]", err, kind.Kind)
+		}
+
+		workloads[kind.Kind] = informer.Informer()
+	}
+
+	return workloads, nil
+}
+
+// listWorkloads lists the workloads in a namespace, or in all namespaces, from the informer
+// caches.  Pods which are managed by another workload are not returned.
+func (webhook *Webhook) listWorkloads(namespace string) ([]client.Object, error) {
+	workloads := []client.Object{}
+
+	for kind, informer := range webhook.Workloads {
+		var objects []interface{}
+
+		if namespace == metav1.NamespaceAll {
+			objects = informer.GetIndexer().List()
+		} else {
+			var err error
+
+			if objects, err = informer.GetIndexer().ByIndex(cache.NamespaceIndex, namespace); err != nil {
+				return nil, fmt.Errorf("%w - unable to list %s objects in namespace [%s]", err, kind, namespace)
+			}
+		}
+
+		for i := range objects {
+			cached, ok := objects[i].(client.Object)
+			if !ok {
+				continue
+			}
+
+			// copy the object as objects in the cache must not be modified
+			workload, ok := cached.DeepCopyObject().(client.Object)
+			if !ok {
+				continue
+			}
+
+			workload.GetObjectKind().SetGroupVersionKind(resources.GroupVersionKindFor(kind))
+
+			if resources.SkipViaOwnerReferences(workload) {
+				continue
+			}
+
+			workloads = append(workloads, workload)
+		}
+	}
+
+	sort.Slice(workloads, func(i, j int) bool {
+		return resources.ToString(workloads[i]) < resources.ToString(workloads[j])
+	})
+
+	return workloads, nil
+}
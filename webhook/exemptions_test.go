@@ -0,0 +1,218 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/nukleros/pod-security-webhook/validate"
+)
+
+// testWorkloads returns a webhook whose workload informers have the given objects, keyed by
+// kind, in their caches.  The informers are not started.
+func testWorkloads(t *testing.T, objects map[string][]client.Object) *Webhook {
+	t.Helper()
+
+	webhook, err := NewOfflineWebhook(nil)
+	if err != nil {
+		t.Fatalf("NewOfflineWebhook() error = %v", err)
+	}
+
+	webhook.Informers = informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+
+	if webhook.Workloads, err = workloadInformers(webhook.Informers); err != nil {
+		t.Fatalf("workloadInformers() error = %v", err)
+	}
+
+	for kind, kindObjects := range objects {
+		for _, object := range kindObjects {
+			if err := webhook.Workloads[kind].GetIndexer().Add(object); err != nil {
+				t.Fatalf("unable to add %s [%s]: %v", kind, object.GetName(), err)
+			}
+		}
+	}
+
+	return webhook
+}
+
+// exemptionAnnotation returns an exemption annotation for a validation which expires at a time.
+func exemptionAnnotation(t *testing.T, validation string, expires time.Time) map[string]string {
+	t.Helper()
+
+	value, err := json.Marshal(&validate.Exemption{
+		Justification: "testing",
+		Ticket:        "TICKET-1",
+		Approver:      "security",
+		Expires:       expires,
+	})
+	if err != nil {
+		t.Fatalf("unable to marshal exemption: %v", err)
+	}
+
+	return map[string]string{validate.ExemptionAnnotationFor(validation): string(value)}
+}
+
+func TestListExemptions(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	controller := true
+
+	tests := []struct {
+		name       string
+		objects    func(t *testing.T) map[string][]client.Object
+		wantNames  []string
+		wantStatus []validate.ExemptionStatus
+		wantError  []bool
+	}{
+		{
+			name: "ensure expired and expiring exemptions are listed from the caches",
+			objects: func(t *testing.T) map[string][]client.Object {
+				return map[string][]client.Object{
+					"Deployment": {
+						&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+							Name:        "expired",
+							Namespace:   "apps",
+							Annotations: exemptionAnnotation(t, "host-pid", now.Add(-30*24*time.Hour)),
+						}},
+						&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+							Name:        "expiring",
+							Namespace:   "apps",
+							Annotations: exemptionAnnotation(t, "host-pid", now.Add(time.Hour)),
+						}},
+						&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+							Name:        "active",
+							Namespace:   "apps",
+							Annotations: exemptionAnnotation(t, "host-pid", now.Add(30*24*time.Hour)),
+						}},
+					},
+				}
+			},
+			wantNames:  []string{"expired", "expiring"},
+			wantStatus: []validate.ExemptionStatus{validate.ExemptionExpired, validate.ExemptionActive},
+			wantError:  []bool{false, false},
+		},
+		{
+			name: "ensure invalid exemptions are listed with an error",
+			objects: func(t *testing.T) map[string][]client.Object {
+				return map[string][]client.Object{
+					"Pod": {
+						&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+							Name:        "invalid",
+							Namespace:   "apps",
+							Annotations: map[string]string{validate.ExemptionAnnotationFor("host-pid"): "{}"},
+						}},
+					},
+				}
+			},
+			wantNames:  []string{"invalid"},
+			wantStatus: []validate.ExemptionStatus{""},
+			wantError:  []bool{true},
+		},
+		{
+			name: "ensure pods managed by another workload are not listed",
+			objects: func(t *testing.T) map[string][]client.Object {
+				return map[string][]client.Object{
+					"Pod": {
+						&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+							Name:        "managed",
+							Namespace:   "apps",
+							Annotations: exemptionAnnotation(t, "host-pid", now.Add(-time.Hour)),
+							OwnerReferences: []metav1.OwnerReference{
+								{Kind: "ReplicaSet", Name: "managed", Controller: &controller},
+							},
+						}},
+					},
+				}
+			},
+			wantNames: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			webhook := testWorkloads(t, tt.objects(t))
+
+			reports, err := webhook.listExemptions(now, 24*time.Hour)
+			if err != nil {
+				t.Fatalf("listExemptions() error = %v", err)
+			}
+
+			if len(reports) != len(tt.wantNames) {
+				t.Fatalf("listExemptions() returned %d reports, want %d", len(reports), len(tt.wantNames))
+			}
+
+			for i, report := range reports {
+				if report.Name != tt.wantNames[i] {
+					t.Errorf("report [%d] name = %s, want %s", i, report.Name, tt.wantNames[i])
+				}
+
+				if report.Status != tt.wantStatus[i] {
+					t.Errorf("report [%s] status = %s, want %s", report.Name, report.Status, tt.wantStatus[i])
+				}
+
+				if (report.Error != "") != tt.wantError[i] {
+					t.Errorf("report [%s] error = %q, want error %t", report.Name, report.Error, tt.wantError[i])
+				}
+
+				if report.Kind == "" {
+					t.Errorf("report [%s] has no kind", report.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestExemptionsEndpoint(t *testing.T) {
+	t.Parallel()
+
+	webhook := testWorkloads(t, map[string][]client.Object{
+		"Pod": {
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Name:        "expired",
+				Namespace:   "apps",
+				Annotations: exemptionAnnotation(t, "host-pid", time.Now().Add(-30*24*time.Hour)),
+			}},
+		},
+	})
+
+	// the exemptions are only served on the admin router
+	recorder := httptest.NewRecorder()
+	webhook.newRouter().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/exemptions", nil))
+
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("GET /exemptions on the admission router code = %d, want %d", recorder.Code, http.StatusNotFound)
+	}
+
+	recorder = httptest.NewRecorder()
+	webhook.newAdminRouter().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/exemptions?within=1h", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("GET /exemptions code = %d, want %d", recorder.Code, http.StatusOK)
+	}
+
+	response := &ExemptionsResponse{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), response); err != nil {
+		t.Fatalf("unable to parse response: %v", err)
+	}
+
+	if len(response.Exemptions) != 1 || response.Exemptions[0].Kind != "Pod" {
+		t.Errorf("GET /exemptions = %+v, want the expired pod exemption", response.Exemptions)
+	}
+}
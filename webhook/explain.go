@@ -0,0 +1,180 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/nukleros/pod-security-webhook/resources"
+	"github.com/nukleros/pod-security-webhook/validate"
+)
+
+const admissionReviewKind = "AdmissionReview"
+
+// ExplainResponse is the response returned from the explain endpoint.
+type ExplainResponse struct {
+	Resource    string                  `json:"resource"`
+	Allowed     bool                    `json:"allowed"`
+	Validations []*validate.Explanation `json:"validations"`
+}
+
+// explain explains the outcome of each validation known to the webhook for an object.  The body
+// of the request is either the raw object, or an admission review so that the requesting user and
+// operation are taken into account.
+func (webhook *Webhook) explain(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		webhook.writeErrorMessage(w, fmt.Errorf("%w - unable to read the POST request", err), http.StatusBadRequest)
+
+		return
+	}
+
+	review, err := NewExplainReview(body)
+	if err != nil {
+		webhook.writeErrorMessage(w, err, http.StatusBadRequest)
+
+		return
+	}
+
+	explanation, err := webhook.Explain(r.Context(), review)
+	if err != nil {
+		webhook.writeErrorMessage(w, err, http.StatusUnprocessableEntity)
+
+		return
+	}
+
+	response, err := json.Marshal(explanation)
+	if err != nil {
+		webhook.writeErrorMessage(w, fmt.Errorf("%w - unable to marshal the json response", err), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if _, err := w.Write(response); err != nil {
+		webhook.Log.Errorf("%s - error writing response", err)
+	}
+}
+
+// NewExplainReview returns the admission review to explain given either an admission review or a
+// raw object.  A raw object is explained as if it were being created in its own namespace.
+func NewExplainReview(body []byte) (*admissionv1.AdmissionReview, error) {
+	object := &unstructured.Unstructured{}
+	if err := json.Unmarshal(body, &object.Object); err != nil {
+		return nil, fmt.Errorf("%w - unable to decode the POST request", err)
+	}
+
+	if object.GetKind() == admissionReviewKind {
+		review := &admissionv1.AdmissionReview{}
+		if err := json.Unmarshal(body, review); err != nil {
+			return nil, fmt.Errorf("%w - unable to decode the admission review", err)
+		}
+
+		if review.Request == nil || len(review.Request.Object.Raw) == 0 {
+			return nil, fmt.Errorf("%w - request object is nil", ErrRequestInvalid)
+		}
+
+		return review, nil
+	}
+
+	if object.GetKind() == "" {
+		return nil, fmt.Errorf("%w - object is missing a kind", ErrRequestInvalid)
+	}
+
+	gvk := object.GroupVersionKind()
+	kind := metav1.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind}
+
+	return &admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: admissionv1.SchemeGroupVersion.String(), Kind: admissionReviewKind},
+		Request: &admissionv1.AdmissionRequest{
+			Kind:        kind,
+			RequestKind: &kind,
+			Name:        object.GetName(),
+			Namespace:   object.GetNamespace(),
+			Operation:   admissionv1.Create,
+			Object:      runtime.RawExtension{Raw: body},
+		},
+	}, nil
+}
+
+// Explain runs all validations against the object of an admission review, exactly as the validate
+// endpoint would, and explains the outcome of each validation along with the settings which
+// change it.  The object is first mutated as the mutate endpoint would, as the api server calls the
+// mutating webhook prior to the validating webhook.
+func (webhook *Webhook) Explain(ctx context.Context, review *admissionv1.AdmissionReview) (*ExplainResponse, error) {
+	if review.Request == nil {
+		return nil, fmt.Errorf("%w - request object is nil", ErrRequestInvalid)
+	}
+
+	object := &unstructured.Unstructured{}
+	if err := json.Unmarshal(review.Request.Object.Raw, object); err != nil {
+		return nil, fmt.Errorf("%w - unable to unmarshal request object to unstructured object", err)
+	}
+
+	if object.GetNamespace() == "" {
+		object.SetNamespace(review.Request.Namespace)
+	}
+
+	podSpec, err := resources.GetPodSpec(object)
+	if err != nil {
+		return nil, fmt.Errorf("%w - error retrieving pod specification from object", err)
+	}
+
+	operation := &Operation{
+		Log:                  webhook.Log,
+		Policy:               webhook.Policy,
+		ExemptionGracePeriod: webhook.ExemptionGracePeriod,
+		Resource:             object,
+		PodSpec:              podSpec,
+		Namespace:            webhook.namespace(ctx, object.GetNamespace()),
+		Review:               review,
+	}
+
+	if err := operation.applyMutations(); err != nil {
+		operation.Log.Errorf("%s - explaining resource without mutations", err)
+	}
+
+	operation.registerValidations()
+
+	// failed validations are recorded in the results so the error is not needed here
+	//nolint:errcheck
+	_ = operation.execute()
+
+	results := map[string]*validate.Result{}
+	for _, result := range operation.Results {
+		results[result.Validation] = result
+	}
+
+	response := &ExplainResponse{
+		Resource:    strings.ToLower(resources.ToString(operation.Resource)),
+		Allowed:     true,
+		Validations: []*validate.Explanation{},
+	}
+
+	for _, validation := range operation.Known {
+		result, ok := results[validation.Name]
+		if !ok {
+			continue
+		}
+
+		if result.Status == validate.ResultFail {
+			response.Allowed = false
+		}
+
+		response.Validations = append(response.Validations, validation.Explain(result, operation.Resource, webhook.Policy))
+	}
+
+	return response, nil
+}
@@ -0,0 +1,463 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/nukleros/pod-security-webhook/validate"
+)
+
+// explainPod is a pod which fails the privileged validation and skips the host network validation
+// by annotation.
+func explainPod() map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]interface{}{
+			"name":      "explain",
+			"namespace": "apps",
+			"annotations": map[string]interface{}{
+				"ignore-check.kube-linter.io/host-network": "cni",
+			},
+		},
+		"spec": map[string]interface{}{
+			"hostNetwork": true,
+			"containers": []interface{}{
+				map[string]interface{}{
+					"name":            "explain",
+					"image":           "nginx",
+					"securityContext": map[string]interface{}{"privileged": true},
+				},
+			},
+		},
+	}
+}
+
+// explain sends a request body to the explain endpoint and returns the explanation of each
+// validation by name.
+func explain(t *testing.T, body interface{}) (*ExplainResponse, map[string]*validate.Explanation) {
+	t.Helper()
+
+	hook, err := NewOfflineWebhook(nil)
+	if err != nil {
+		t.Fatalf("unable to create webhook: %v", err)
+	}
+
+	raw, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("unable to marshal request: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	hook.explain(recorder, httptest.NewRequest(http.MethodPost, "/explain", bytes.NewReader(raw)))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("explain returned status [%d]: %s", recorder.Code, recorder.Body.String())
+	}
+
+	response := &ExplainResponse{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), response); err != nil {
+		t.Fatalf("unable to unmarshal response %q: %v", recorder.Body.String(), err)
+	}
+
+	explanations := map[string]*validate.Explanation{}
+	for _, explanation := range response.Validations {
+		explanations[explanation.Validation] = explanation
+	}
+
+	return response, explanations
+}
+
+// control returns the control of an explanation with the given key and value.
+func control(explanation *validate.Explanation, key, value string) *validate.Control {
+	for i := range explanation.Controls {
+		if explanation.Controls[i].Key == key && explanation.Controls[i].Value == value {
+			return &explanation.Controls[i]
+		}
+	}
+
+	return nil
+}
+
+//nolint:paralleltest
+func TestExplainObject(t *testing.T) {
+	t.Setenv("VALIDATE_HOST_PID", validate.SkipValidationEnvValue)
+	t.Setenv("VALIDATE_HOST_IPC", validate.WarnValidationEnvValue)
+
+	response, explanations := explain(t, explainPod())
+
+	if response.Allowed || response.Resource != "pod/explain in namespace apps" {
+		t.Errorf("response for [%s] allowed = %t, want pod/explain in namespace apps to be denied", response.Resource, response.Allowed)
+	}
+
+	tests := []struct {
+		name       string
+		validation string
+		enabled    bool
+		action     validate.EnforcementAction
+		status     validate.ResultStatus
+		skipReason validate.SkipReason
+		key        string
+		value      string
+		current    string
+	}{
+		{
+			name:       "ensure a failed validation is explained with the annotation to skip it",
+			validation: validate.PrivilegedValidationName,
+			enabled:    true,
+			action:     validate.EnforcementActionDeny,
+			status:     validate.ResultFail,
+			key:        "ignore-check.kube-linter.io/privileged-container",
+			value:      "<reason>",
+		},
+		{
+			name:       "ensure a validation skipped by annotation is explained with the current annotation",
+			validation: validate.HostNetworkValidationName,
+			enabled:    true,
+			action:     validate.EnforcementActionDeny,
+			status:     validate.ResultSkip,
+			skipReason: validate.SkipReasonAnnotation,
+			key:        "ignore-check.kube-linter.io/host-network",
+			value:      "<reason>",
+			current:    "cni",
+		},
+		{
+			name:       "ensure a validation disabled by environment variable is explained with the current variable",
+			validation: validate.HostPIDValidationName,
+			enabled:    false,
+			status:     validate.ResultSkip,
+			skipReason: validate.SkipReasonEnv,
+			key:        "VALIDATE_HOST_PID",
+			value:      validate.SkipValidationEnvValue,
+			current:    validate.SkipValidationEnvValue,
+		},
+		{
+			name:       "ensure a validation in warn mode is explained with its action",
+			validation: validate.HostIPCValidationName,
+			enabled:    true,
+			action:     validate.EnforcementActionWarn,
+			status:     validate.ResultPass,
+			key:        "VALIDATE_HOST_IPC_EXEMPT_USERS",
+			value:      "<user>,...",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			explanation, ok := explanations[tt.validation]
+			if !ok {
+				t.Fatalf("validation [%s] was not explained", tt.validation)
+			}
+
+			if explanation.Enabled != tt.enabled || explanation.Action != tt.action {
+				t.Errorf("enabled = %t with action [%s], want %t with action [%s]", explanation.Enabled, explanation.Action, tt.enabled, tt.action)
+			}
+
+			if explanation.Status != tt.status || explanation.SkipReason != tt.skipReason {
+				t.Errorf("status = [%s] skipped by [%s], want [%s] skipped by [%s]", explanation.Status, explanation.SkipReason, tt.status, tt.skipReason)
+			}
+
+			found := control(explanation, tt.key, tt.value)
+			if found == nil {
+				t.Fatalf("control [%s=%s] was not explained", tt.key, tt.value)
+			}
+
+			if found.Current != tt.current {
+				t.Errorf("control [%s] current = [%s], want [%s]", tt.key, found.Current, tt.current)
+			}
+		})
+	}
+}
+
+//nolint:paralleltest
+func TestExplainAdmissionReview(t *testing.T) {
+	t.Setenv("VALIDATE_PRIVILEGED_CONTAINER_EXEMPT_USERS", "admin")
+
+	raw, err := json.Marshal(explainPod())
+	if err != nil {
+		t.Fatalf("unable to marshal object: %v", err)
+	}
+
+	review, err := NewExplainReview(raw)
+	if err != nil {
+		t.Fatalf("unable to create admission review: %v", err)
+	}
+
+	review.Request.UserInfo = authenticationv1.UserInfo{Username: "admin"}
+
+	_, explanations := explain(t, review)
+
+	privileged := explanations[validate.PrivilegedValidationName]
+	if privileged == nil || privileged.SkipReason != validate.SkipReasonUser {
+		t.Fatalf("validation [%s] was not skipped for an exempt user: %+v", validate.PrivilegedValidationName, privileged)
+	}
+}
+
+// TestExplainImageMirrors ensures that images are explained as they are validated, after they have
+// been rewritten to a mirror by the mutating webhook.
+//
+//nolint:paralleltest
+func TestExplainImageMirrors(t *testing.T) {
+	t.Setenv(validate.ImageRegistryEnv, mirrorPolicy)
+
+	hook, err := NewOfflineWebhook(mirrorWebhook().Policy)
+	if err != nil {
+		t.Fatalf("unable to create webhook: %v", err)
+	}
+
+	raw, err := json.Marshal(explainPod())
+	if err != nil {
+		t.Fatalf("unable to marshal object: %v", err)
+	}
+
+	review, err := NewExplainReview(raw)
+	if err != nil {
+		t.Fatalf("unable to create admission review: %v", err)
+	}
+
+	response, err := hook.Explain(context.Background(), review)
+	if err != nil {
+		t.Fatalf("Explain() error = %v", err)
+	}
+
+	for _, explanation := range response.Validations {
+		if explanation.Validation != validate.ImageRegistryValidationName {
+			continue
+		}
+
+		if explanation.Status != validate.ResultPass {
+			t.Errorf("validation [%s] status = %s, want %s for a mirrored image: %s",
+				explanation.Validation, explanation.Status, validate.ResultPass, explanation.Message)
+		}
+
+		return
+	}
+
+	t.Errorf("validation [%s] was not explained", validate.ImageRegistryValidationName)
+}
+
+// TestExplainRemediation ensures that failed validations are explained with their remediation and
+// the settings specific to the validation, along with their current values.
+//
+//nolint:paralleltest
+func TestExplainRemediation(t *testing.T) {
+	t.Setenv(validate.ImageRegistriesEnv, "registry.internal/")
+	t.Setenv(validate.ImageRegistryEnv, "")
+	t.Setenv(validate.AllowedGIDRangesEnv, "2000-2999")
+	t.Setenv(validate.AllowedUIDRangesEnv, "")
+
+	namespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "apps",
+			Annotations: map[string]string{
+				validate.TrustedRegistriesAnnotation: "quay.io/",
+				validate.AllowedUIDRangesAnnotation:  "1000-1999",
+			},
+		},
+	}
+
+	hook, err := NewOfflineWebhook(&validate.PolicyConfig{}, namespace)
+	if err != nil {
+		t.Fatalf("unable to create webhook: %v", err)
+	}
+
+	raw, err := json.Marshal(explainPod())
+	if err != nil {
+		t.Fatalf("unable to marshal object: %v", err)
+	}
+
+	review, err := NewExplainReview(raw)
+	if err != nil {
+		t.Fatalf("unable to create admission review: %v", err)
+	}
+
+	response, err := hook.Explain(context.Background(), review)
+	if err != nil {
+		t.Fatalf("Explain() error = %v", err)
+	}
+
+	explanations := map[string]*validate.Explanation{}
+	for _, explanation := range response.Validations {
+		explanations[explanation.Validation] = explanation
+	}
+
+	tests := []struct {
+		name        string
+		validation  string
+		remediation bool
+		key         string
+		value       string
+		controlType validate.ControlType
+		current     string
+	}{
+		{
+			name:        "ensure a failed trusted registry validation is explained with the global trusted registries",
+			validation:  validate.ImageRegistryValidationName,
+			remediation: true,
+			key:         validate.ImageRegistriesEnv,
+			value:       "<registry>,...",
+			controlType: validate.ControlTypeEnv,
+			current:     "registry.internal/",
+		},
+		{
+			name:        "ensure a failed trusted registry validation is explained with the registries of the namespace",
+			validation:  validate.ImageRegistryValidationName,
+			remediation: true,
+			key:         validate.TrustedRegistriesAnnotation,
+			value:       "<registry>,...",
+			controlType: validate.ControlTypeNamespaceAnnotation,
+			current:     "quay.io/",
+		},
+		{
+			name:        "ensure a failed trusted registry validation is explained with the mode of the namespace",
+			validation:  validate.ImageRegistryValidationName,
+			remediation: true,
+			key:         validate.TrustedRegistriesModeAnnotation,
+			value:       string(validate.TrustedRegistriesModeReplace),
+			controlType: validate.ControlTypeNamespaceAnnotation,
+		},
+		{
+			name:        "ensure a failed trusted registry validation is explained with the policy configuration",
+			validation:  validate.ImageRegistryValidationName,
+			remediation: true,
+			key:         "trustedRegistries.namespaces.<namespace>",
+			value:       "[<registry>, ...]",
+			controlType: validate.ControlTypePolicy,
+		},
+		{
+			name:        "ensure a failed id ranges validation is explained with the user id ranges of the namespace",
+			validation:  validate.UserGroupRangesValidationName,
+			remediation: true,
+			key:         validate.AllowedUIDRangesAnnotation,
+			value:       "<min>-<max>,...",
+			controlType: validate.ControlTypeNamespaceAnnotation,
+			current:     "1000-1999",
+		},
+		{
+			name:        "ensure a failed id ranges validation is explained with the global group id ranges",
+			validation:  validate.UserGroupRangesValidationName,
+			remediation: true,
+			key:         validate.AllowedGIDRangesEnv,
+			value:       "<min>-<max>,...",
+			controlType: validate.ControlTypeEnv,
+			current:     "2000-2999",
+		},
+		{
+			name:        "ensure a passed volume types validation is explained with its settings without a remediation",
+			validation:  validate.AllowedVolumeTypesValidationName,
+			remediation: false,
+			key:         validate.AllowedVolumeTypesEnv,
+			value:       "<type>,...",
+			controlType: validate.ControlTypeEnv,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			explanation, ok := explanations[tt.validation]
+			if !ok {
+				t.Fatalf("validation [%s] was not explained", tt.validation)
+			}
+
+			want := ""
+			if tt.remediation {
+				want = validate.MetadataFor(tt.validation).Remediation
+			}
+
+			if explanation.Remediation != want {
+				t.Errorf("remediation = [%s], want [%s] for status [%s]", explanation.Remediation, want, explanation.Status)
+			}
+
+			found := control(explanation, tt.key, tt.value)
+			if found == nil {
+				t.Fatalf("control [%s=%s] was not explained", tt.key, tt.value)
+			}
+
+			if found.Type != tt.controlType || found.Current != tt.current {
+				t.Errorf("control [%s] = [%s] with current [%s], want [%s] with current [%s]", tt.key, found.Type, found.Current, tt.controlType, tt.current)
+			}
+		})
+	}
+}
+
+func TestNewExplainReview(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		body    interface{}
+		wantErr bool
+	}{
+		{
+			name: "ensure a raw object is explained as a create request in its namespace",
+			body: explainPod(),
+		},
+		{
+			name: "ensure an admission review is explained as is",
+			body: &admissionv1.AdmissionReview{
+				Request: &admissionv1.AdmissionRequest{
+					Namespace: "apps",
+					Operation: admissionv1.Update,
+					Object:    runtime.RawExtension{Raw: []byte(`{"kind": "Pod"}`)},
+				},
+			},
+		},
+		{
+			name:    "ensure an admission review without an object is invalid",
+			body:    &admissionv1.AdmissionReview{Request: &admissionv1.AdmissionRequest{}},
+			wantErr: true,
+		},
+		{
+			name:    "ensure an object without a kind is invalid",
+			body:    map[string]interface{}{"metadata": map[string]interface{}{"name": "explain"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			raw, err := json.Marshal(tt.body)
+			if err != nil {
+				t.Fatalf("unable to marshal request: %v", err)
+			}
+
+			// admission reviews are identified by their kind
+			if review, ok := tt.body.(*admissionv1.AdmissionReview); ok {
+				review.Kind = admissionReviewKind
+				if raw, err = json.Marshal(review); err != nil {
+					t.Fatalf("unable to marshal request: %v", err)
+				}
+			}
+
+			review, err := NewExplainReview(raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewExplainReview() error = %v, wantErr %t", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if review.Request.Namespace != "apps" {
+				t.Errorf("review namespace = [%s], want apps", review.Request.Namespace)
+			}
+		})
+	}
+}
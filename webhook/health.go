@@ -4,24 +4,185 @@
 package webhook
 
 import (
+	"crypto/x509"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync"
+	"time"
 )
 
-const statusOkMessage = `{"msg": "server is healthy"}`
+const (
+	statusOkMessage       = "server is healthy"
+	statusNotReadyMessage = "server is not ready"
 
-// healthCheck implements a simple health check that returns a 200 ok response.
-func (webhook *Webhook) healthCheck(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
+	// components which must be ready prior to serving admission requests
+	componentCertificates = "certificates"
+	componentInformers    = "informers"
+	componentShutdown     = "shutdown"
+)
+
+// HealthStatus is the response of the liveness and readiness checks.
+type HealthStatus struct {
+	Message    string             `json:"msg"`
+	Ready      bool               `json:"ready"`
+	Components []*ComponentStatus `json:"components,omitempty"`
+	Leader     *LeaderStatus      `json:"leader,omitempty"`
+}
+
+// ComponentStatus is the readiness of a single component of the webhook.
+type ComponentStatus struct {
+	Name    string `json:"name"`
+	Ready   bool   `json:"ready"`
+	Message string `json:"message,omitempty"`
+}
+
+// readiness is the state of the webhook which is not derived from its configuration, such as
+// whether the informer caches have synced.
+type readiness struct {
+	mutex    sync.RWMutex
+	synced   bool
+	draining bool
+}
+
+// Synced marks the informer caches as synced.
+func (webhook *Webhook) Synced() {
+	webhook.readiness.mutex.Lock()
+	defer webhook.readiness.mutex.Unlock()
+
+	webhook.readiness.synced = true
+}
+
+// Drain marks the webhook as draining prior to shutting down, which fails the readiness check so
+// that the webhook is removed from the endpoints of its service.
+func (webhook *Webhook) Drain() {
+	webhook.readiness.mutex.Lock()
+	defer webhook.readiness.mutex.Unlock()
+
+	webhook.readiness.draining = true
+}
+
+// Ready returns whether the webhook is ready to serve admission requests, along with the
+// readiness of each component.
+func (webhook *Webhook) Ready() (bool, []*ComponentStatus) {
+	webhook.readiness.mutex.RLock()
+	synced, draining := webhook.readiness.synced, webhook.readiness.draining
+	webhook.readiness.mutex.RUnlock()
+
+	components := []*ComponentStatus{
+		webhook.certificateStatus(time.Now()),
+		componentStatus(componentInformers, synced, "waiting for informer caches to sync"),
+		componentStatus(componentShutdown, !draining, "draining connections prior to shutting down"),
+	}
+
+	ready := true
+
+	for _, component := range components {
+		ready = ready && component.Ready
+	}
+
+	return ready, components
+}
+
+// componentStatus returns the readiness of a component, with a message if it is not ready.
+func componentStatus(name string, ready bool, message string) *ComponentStatus {
+	if ready {
+		return &ComponentStatus{Name: name, Ready: true}
+	}
+
+	return &ComponentStatus{Name: name, Message: message}
+}
+
+// certificateStatus returns the readiness of the serving certificate, which must be loaded and
+// valid at the given time.
+func (webhook *Webhook) certificateStatus(now time.Time) *ComponentStatus {
+	status := &ComponentStatus{Name: componentCertificates}
+
+	if webhook.Certificate == nil || len(webhook.Certificate.Certificate) == 0 {
+		status.Message = "serving certificate has not been loaded"
+
+		return status
+	}
 
-	_, err := fmt.Fprint(w, statusOkMessage)
+	leaf := webhook.Certificate.Leaf
+	if leaf == nil {
+		parsed, err := x509.ParseCertificate(webhook.Certificate.Certificate[0])
+		if err != nil {
+			status.Message = fmt.Sprintf("unable to parse serving certificate: %s", err)
+
+			return status
+		}
+
+		leaf = parsed
+	}
+
+	if now.After(leaf.NotAfter) {
+		status.Message = fmt.Sprintf("serving certificate expired at %s", leaf.NotAfter.Format(time.RFC3339))
+
+		return status
+	}
+
+	if now.Before(leaf.NotBefore) {
+		status.Message = fmt.Sprintf("serving certificate is not valid until %s", leaf.NotBefore.Format(time.RFC3339))
+
+		return status
+	}
+
+	status.Ready = true
+
+	return status
+}
+
+// liveness reports that the server is running, along with the readiness of each component and
+// the leader election status of this replica.  It returns a 200 response regardless of the
+// readiness of the components, so that a webhook which is waiting for its caches to sync is not
+// restarted.
+func (webhook *Webhook) liveness(w http.ResponseWriter, r *http.Request) {
+	ready, components := webhook.Ready()
+
+	webhook.writeHealth(w, &HealthStatus{Message: statusOkMessage, Ready: ready, Components: components}, http.StatusOK)
+}
+
+// readinessCheck reports whether the webhook is ready to serve admission requests, along with
+// the readiness of each component.  It returns a 503 response if any component is not ready.
+func (webhook *Webhook) readinessCheck(w http.ResponseWriter, r *http.Request) {
+	ready, components := webhook.Ready()
+
+	status := &HealthStatus{Message: statusOkMessage, Ready: ready, Components: components}
+	code := http.StatusOK
+
+	if !ready {
+		status.Message = statusNotReadyMessage
+		code = http.StatusServiceUnavailable
+	}
+
+	webhook.writeHealth(w, status, code)
+}
+
+// writeHealth writes a health status response.  The status code is written prior to the body,
+// as it cannot be changed once the body has been written.
+func (webhook *Webhook) writeHealth(w http.ResponseWriter, status *HealthStatus, code int) {
+	if webhook.Leader != nil {
+		leader := webhook.Leader.Status()
+		status.Leader = &leader
+	}
+
+	response, err := json.Marshal(status)
 	if err != nil {
-		webhook.Log.ErrorF("%s - error writing response", err)
+		webhook.Log.Errorf("%s - error marshaling health status", err)
 		w.WriteHeader(http.StatusInternalServerError)
 
 		return
 	}
 
-	webhook.Log.Debug(statusOkMessage)
-	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	if _, err := w.Write(response); err != nil {
+		webhook.Log.Errorf("%s - error writing response", err)
+
+		return
+	}
+
+	webhook.Log.Debug(status.Message)
 }
@@ -0,0 +1,159 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package webhook
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func testCertificate(t *testing.T, notBefore, notAfter time.Time) *tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "pod-security-webhook"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("unable to create certificate: %v", err)
+	}
+
+	return &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestReadiness(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		certificate func(t *testing.T) *tls.Certificate
+		synced      bool
+		draining    bool
+		wantCode    int
+		wantFailed  string
+	}{
+		{
+			name: "ensure a webhook with a valid certificate and synced caches is ready",
+			certificate: func(t *testing.T) *tls.Certificate {
+				return testCertificate(t, now.Add(-time.Hour), now.Add(time.Hour))
+			},
+			synced:   true,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:        "ensure a webhook without a certificate is not ready",
+			certificate: func(t *testing.T) *tls.Certificate { return nil },
+			synced:      true,
+			wantCode:    http.StatusServiceUnavailable,
+			wantFailed:  componentCertificates,
+		},
+		{
+			name: "ensure a webhook with an expired certificate is not ready",
+			certificate: func(t *testing.T) *tls.Certificate {
+				return testCertificate(t, now.Add(-2*time.Hour), now.Add(-time.Hour))
+			},
+			synced:     true,
+			wantCode:   http.StatusServiceUnavailable,
+			wantFailed: componentCertificates,
+		},
+		{
+			name: "ensure a webhook whose caches have not synced is not ready",
+			certificate: func(t *testing.T) *tls.Certificate {
+				return testCertificate(t, now.Add(-time.Hour), now.Add(time.Hour))
+			},
+			synced:     false,
+			wantCode:   http.StatusServiceUnavailable,
+			wantFailed: componentInformers,
+		},
+		{
+			name: "ensure a draining webhook is not ready",
+			certificate: func(t *testing.T) *tls.Certificate {
+				return testCertificate(t, now.Add(-time.Hour), now.Add(time.Hour))
+			},
+			synced:     true,
+			draining:   true,
+			wantCode:   http.StatusServiceUnavailable,
+			wantFailed: componentShutdown,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			webhook, err := NewOfflineWebhook(nil)
+			if err != nil {
+				t.Fatalf("NewOfflineWebhook() error = %v", err)
+			}
+
+			webhook.Certificate = tt.certificate(t)
+
+			if tt.synced {
+				webhook.Synced()
+			}
+
+			if tt.draining {
+				webhook.Drain()
+			}
+
+			recorder := httptest.NewRecorder()
+			webhook.readinessCheck(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			if recorder.Code != tt.wantCode {
+				t.Errorf("readinessCheck() code = %d, want %d", recorder.Code, tt.wantCode)
+			}
+
+			status := &HealthStatus{}
+			if err := json.Unmarshal(recorder.Body.Bytes(), status); err != nil {
+				t.Fatalf("unable to parse response: %v", err)
+			}
+
+			if status.Ready != (tt.wantCode == http.StatusOK) {
+				t.Errorf("readinessCheck() ready = %t, want %t", status.Ready, tt.wantCode == http.StatusOK)
+			}
+
+			for _, component := range status.Components {
+				wantReady := component.Name != tt.wantFailed
+				if component.Ready != wantReady {
+					t.Errorf("component [%s] ready = %t, want %t", component.Name, component.Ready, wantReady)
+				}
+
+				if !component.Ready && component.Message == "" {
+					t.Errorf("component [%s] is not ready without a message", component.Name)
+				}
+			}
+
+			// liveness does not depend on the readiness of the components
+			recorder = httptest.NewRecorder()
+			webhook.liveness(recorder, httptest.NewRequest(http.MethodGet, "/livez", nil))
+
+			if recorder.Code != http.StatusOK {
+				t.Errorf("liveness() code = %d, want %d", recorder.Code, http.StatusOK)
+			}
+		})
+	}
+}
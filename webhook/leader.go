@@ -0,0 +1,302 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	"github.com/nukleros/pod-security-webhook/logging"
+)
+
+const (
+	leaderElectionEnabledEnv       = "LEADER_ELECTION_ENABLED"
+	leaderElectionNamespaceEnv     = "LEADER_ELECTION_NAMESPACE"
+	leaderElectionLeaseNameEnv     = "LEADER_ELECTION_LEASE_NAME"
+	leaderElectionLeaseDurationEnv = "LEADER_ELECTION_LEASE_DURATION"
+	leaderElectionRenewDeadlineEnv = "LEADER_ELECTION_RENEW_DEADLINE"
+	leaderElectionRetryPeriodEnv   = "LEADER_ELECTION_RETRY_PERIOD"
+
+	// podNameEnv and podNamespaceEnv are set from the downward api, and are used as the identity
+	// of the replica and the namespace of the lease respectively.
+	podNameEnv      = "POD_NAME"
+	podNamespaceEnv = "POD_NAMESPACE"
+
+	serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+	defaultLeaderElectionLeaseName     = "pod-security-webhook"
+	defaultLeaderElectionLeaseDuration = 15 * time.Second
+	defaultLeaderElectionRenewDeadline = 10 * time.Second
+	defaultLeaderElectionRetryPeriod   = 2 * time.Second
+)
+
+var ErrLeaderElectionInvalidConfig = errors.New("invalid leader election configuration")
+
+// Worker is background work which must only run on a single replica of the webhook at a time,
+// such as writing policy reports.  Admission requests are served by every replica regardless.
+type Worker interface {
+	// Name returns the name of the worker, which is used in logs.
+	Name() string
+
+	// Run runs the worker until the context is cancelled, which happens when the replica is no
+	// longer the leader.  A worker may be run again if the replica is later re-elected.
+	Run(ctx context.Context)
+}
+
+// LeaderStatus is the leader election status of a replica.
+type LeaderStatus struct {
+	// Enabled is false if leader election is disabled, in which case each replica runs the
+	// workers as if it were the leader.
+	Enabled bool `json:"enabled"`
+
+	Identity string `json:"identity"`
+	Leader   string `json:"leader,omitempty"`
+	IsLeader bool   `json:"isLeader"`
+}
+
+// Leader runs the workers of the webhook on the replica which holds the leader election lease.
+type Leader struct {
+	log     *logging.Logger
+	metrics *Metrics
+	workers []Worker
+
+	// lock is nil if leader election is disabled
+	lock          resourcelock.Interface
+	leaseDuration time.Duration
+	renewDeadline time.Duration
+	retryPeriod   time.Duration
+
+	mutex  sync.RWMutex
+	status LeaderStatus
+
+	// running is held while the workers run, so that the workers of a lost lease have stopped
+	// before they are started again for a new lease
+	running sync.Mutex
+}
+
+// NewLeader returns a leader which runs the workers on the replica holding the lease.  If the lock
+// is nil, the workers run without leader election.
+func NewLeader(log *logging.Logger, metrics *Metrics, lock resourcelock.Interface, workers ...Worker) *Leader {
+	leader := &Leader{
+		log:           log,
+		metrics:       metrics,
+		workers:       workers,
+		lock:          lock,
+		leaseDuration: defaultLeaderElectionLeaseDuration,
+		renewDeadline: defaultLeaderElectionRenewDeadline,
+		retryPeriod:   defaultLeaderElectionRetryPeriod,
+	}
+
+	if lock != nil {
+		leader.status = LeaderStatus{Enabled: true, Identity: lock.Identity()}
+	} else {
+		leader.status = LeaderStatus{Identity: identity()}
+	}
+
+	return leader
+}
+
+// newLeaderFromEnv returns a leader for the workers given the leader election settings from the
+// environment.
+func newLeaderFromEnv(client kubernetes.Interface, log *logging.Logger, metrics *Metrics, workers ...Worker) (*Leader, error) {
+	if os.Getenv(leaderElectionEnabledEnv) == "false" {
+		return NewLeader(log, metrics, nil, workers...), nil
+	}
+
+	namespace, err := leaderElectionNamespace()
+	if err != nil {
+		return nil, err
+	}
+
+	name := os.Getenv(leaderElectionLeaseNameEnv)
+	if name == "" {
+		name = defaultLeaderElectionLeaseName
+	}
+
+	lock, err := resourcelock.New(
+		resourcelock.LeasesResourceLock,
+		namespace,
+		name,
+		client.CoreV1(),
+		client.CoordinationV1(),
+		resourcelock.ResourceLockConfig{Identity: identity()},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w - unable to create leader election lock [%s/%s]", err, namespace, name)
+	}
+
+	leader := NewLeader(log, metrics, lock, workers...)
+
+	if leader.leaseDuration, err = durationFromEnv(leaderElectionLeaseDurationEnv, defaultLeaderElectionLeaseDuration); err != nil {
+		return nil, err
+	}
+
+	if leader.renewDeadline, err = durationFromEnv(leaderElectionRenewDeadlineEnv, defaultLeaderElectionRenewDeadline); err != nil {
+		return nil, err
+	}
+
+	if leader.retryPeriod, err = durationFromEnv(leaderElectionRetryPeriodEnv, defaultLeaderElectionRetryPeriod); err != nil {
+		return nil, err
+	}
+
+	if leader.leaseDuration <= leader.renewDeadline || leader.renewDeadline <= leader.retryPeriod {
+		return nil, fmt.Errorf("%w - expected %s > %s > %s, found [%s > %s > %s]",
+			ErrLeaderElectionInvalidConfig,
+			leaderElectionLeaseDurationEnv, leaderElectionRenewDeadlineEnv, leaderElectionRetryPeriodEnv,
+			leader.leaseDuration, leader.renewDeadline, leader.retryPeriod,
+		)
+	}
+
+	return leader, nil
+}
+
+// Run runs the workers while this replica is the leader, until the context is cancelled.  A
+// replica which loses the lease stops its workers and rejoins the election, rather than exiting,
+// so that it continues to serve admission requests.
+func (leader *Leader) Run(ctx context.Context) {
+	if leader.lock == nil {
+		leader.lead(ctx)
+
+		return
+	}
+
+	for ctx.Err() == nil {
+		elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+			Lock:            leader.lock,
+			LeaseDuration:   leader.leaseDuration,
+			RenewDeadline:   leader.renewDeadline,
+			RetryPeriod:     leader.retryPeriod,
+			ReleaseOnCancel: true,
+			Name:            leader.lock.Describe(),
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: leader.lead,
+				OnStoppedLeading: func() { leader.log.Infof("stopped leading [%s]", leader.lock.Describe()) },
+				OnNewLeader:      leader.observe,
+			},
+		})
+		if err != nil {
+			leader.log.Errorf("%s - unable to create leader elector", err)
+
+			return
+		}
+
+		// run blocks until the lease is lost or the context is cancelled
+		elector.Run(ctx)
+
+		if ctx.Err() == nil {
+			leader.log.Infof("lost leader election lease [%s], rejoining election", leader.lock.Describe())
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(leader.retryPeriod):
+			}
+		}
+	}
+}
+
+// Status returns the leader election status of this replica.
+func (leader *Leader) Status() LeaderStatus {
+	if leader == nil {
+		return LeaderStatus{}
+	}
+
+	leader.mutex.RLock()
+	defer leader.mutex.RUnlock()
+
+	return leader.status
+}
+
+// lead runs each of the workers until the context is cancelled, and blocks until they have all
+// returned.
+func (leader *Leader) lead(ctx context.Context) {
+	leader.running.Lock()
+	defer leader.running.Unlock()
+
+	leader.setLeading(true)
+	defer leader.setLeading(false)
+
+	group := sync.WaitGroup{}
+
+	for _, worker := range leader.workers {
+		worker := worker
+
+		leader.log.Infof("starting worker [%s]", worker.Name())
+
+		group.Add(1)
+
+		go func() {
+			defer group.Done()
+
+			worker.Run(ctx)
+
+			leader.log.Infof("stopped worker [%s]", worker.Name())
+		}()
+	}
+
+	group.Wait()
+}
+
+// observe records the identity of a newly elected leader.
+func (leader *Leader) observe(identity string) {
+	leader.mutex.Lock()
+	defer leader.mutex.Unlock()
+
+	leader.status.Leader = identity
+
+	leader.log.Infof("leader election lease is held by [%s]", identity)
+}
+
+// setLeading records whether this replica is leading.
+func (leader *Leader) setLeading(leading bool) {
+	leader.mutex.Lock()
+	defer leader.mutex.Unlock()
+
+	leader.status.IsLeader = leading
+
+	if leading {
+		leader.status.Leader = leader.status.Identity
+	}
+
+	leader.metrics.leading(leading)
+}
+
+// identity returns the identity of this replica, which is the name of its pod.
+func identity() string {
+	if name := os.Getenv(podNameEnv); name != "" {
+		return name
+	}
+
+	if hostname, err := os.Hostname(); err == nil {
+		return hostname
+	}
+
+	return defaultLeaderElectionLeaseName
+}
+
+// leaderElectionNamespace returns the namespace of the leader election lease, which defaults to
+// the namespace of the webhook.
+func leaderElectionNamespace() (string, error) {
+	for _, key := range []string{leaderElectionNamespaceEnv, podNamespaceEnv} {
+		if namespace := os.Getenv(key); namespace != "" {
+			return namespace, nil
+		}
+	}
+
+	namespace, err := os.ReadFile(serviceAccountNamespaceFile)
+	if err != nil || strings.TrimSpace(string(namespace)) == "" {
+		return "", fmt.Errorf("%w - unable to determine the namespace of the lease; set [%s]",
+			ErrLeaderElectionInvalidConfig, leaderElectionNamespaceEnv)
+	}
+
+	return strings.TrimSpace(string(namespace)), nil
+}
@@ -0,0 +1,230 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package webhook
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// countingWorker is a worker which records how many replicas are running it at once.
+type countingWorker struct {
+	running *int32
+	maximum *int32
+	started chan string
+	name    string
+}
+
+func (worker *countingWorker) Name() string { return "counting" }
+
+func (worker *countingWorker) Run(ctx context.Context) {
+	running := atomic.AddInt32(worker.running, 1)
+	defer atomic.AddInt32(worker.running, -1)
+
+	for {
+		maximum := atomic.LoadInt32(worker.maximum)
+		if running <= maximum || atomic.CompareAndSwapInt32(worker.maximum, maximum, running) {
+			break
+		}
+	}
+
+	worker.started <- worker.name
+
+	<-ctx.Done()
+}
+
+func testLeader(t *testing.T, client *fake.Clientset, identity string, worker Worker) *Leader {
+	t.Helper()
+
+	lock, err := resourcelock.New(
+		resourcelock.LeasesResourceLock,
+		"webhook",
+		"pod-security-webhook",
+		client.CoreV1(),
+		client.CoordinationV1(),
+		resourcelock.ResourceLockConfig{Identity: identity},
+	)
+	if err != nil {
+		t.Fatalf("unable to create lock: %v", err)
+	}
+
+	leader := NewLeader(testDecisionLogger(), NewMetrics(), lock, worker)
+	leader.leaseDuration = time.Second
+	leader.renewDeadline = 500 * time.Millisecond
+	leader.retryPeriod = 100 * time.Millisecond
+
+	return leader
+}
+
+func TestLeaderWithoutElection(t *testing.T) {
+	t.Parallel()
+
+	running, maximum := int32(0), int32(0)
+	worker := &countingWorker{running: &running, maximum: &maximum, started: make(chan string, 1), name: "single"}
+	metrics := NewMetrics()
+	leader := NewLeader(testDecisionLogger(), metrics, nil, worker)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		leader.Run(ctx)
+		close(done)
+	}()
+
+	<-worker.started
+
+	if status := leader.Status(); status.Enabled || !status.IsLeader {
+		t.Errorf("Status() = %+v, want leader without election", status)
+	}
+
+	if got := testutil.ToFloat64(metrics.Leader); got != 1 {
+		t.Errorf("leader metric = %v, want 1", got)
+	}
+
+	cancel()
+	<-done
+
+	if status := leader.Status(); status.IsLeader {
+		t.Errorf("Status() = %+v, want not leader after stopping", status)
+	}
+
+	if got := testutil.ToFloat64(metrics.Leader); got != 0 {
+		t.Errorf("leader metric = %v, want 0", got)
+	}
+}
+
+func TestLeaderElection(t *testing.T) {
+	t.Parallel()
+
+	client := fake.NewSimpleClientset()
+	running, maximum := int32(0), int32(0)
+	started := make(chan string, 2)
+
+	leaders := map[string]*Leader{}
+	cancels := map[string]context.CancelFunc{}
+	group := sync.WaitGroup{}
+
+	for _, identity := range []string{"replica-a", "replica-b"} {
+		worker := &countingWorker{running: &running, maximum: &maximum, started: started, name: identity}
+		leaders[identity] = testLeader(t, client, identity, worker)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancels[identity] = cancel
+
+		group.Add(1)
+
+		go func(leader *Leader) {
+			defer group.Done()
+
+			leader.Run(ctx)
+		}(leaders[identity])
+	}
+
+	defer func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+
+		group.Wait()
+	}()
+
+	first := waitForLeader(t, started)
+
+	if status := leaders[first].Status(); !status.Enabled || !status.IsLeader || status.Leader != first {
+		t.Errorf("Status() of [%s] = %+v, want leader", first, status)
+	}
+
+	// stopping the leader releases the lease, so the other replica takes over
+	cancels[first]()
+
+	second := waitForLeader(t, started)
+	if second == first {
+		t.Errorf("leader after releasing the lease = %s, want the other replica", second)
+	}
+
+	if got := atomic.LoadInt32(&maximum); got != 1 {
+		t.Errorf("workers running at once = %d, want 1", got)
+	}
+}
+
+func waitForLeader(t *testing.T, started chan string) string {
+	t.Helper()
+
+	select {
+	case identity := <-started:
+		return identity
+	case <-time.After(10 * time.Second):
+		t.Fatalf("no replica was elected leader")
+	}
+
+	return ""
+}
+
+//nolint:paralleltest
+func TestNewLeaderFromEnv(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		wantEnabled bool
+		wantErr     error
+	}{
+		{
+			name:        "ensure leader election can be disabled",
+			env:         map[string]string{leaderElectionEnabledEnv: "false"},
+			wantEnabled: false,
+		},
+		{
+			name:        "ensure the namespace of the lease is the namespace of the pod",
+			env:         map[string]string{podNamespaceEnv: "webhook", podNameEnv: "webhook-0"},
+			wantEnabled: true,
+		},
+		{
+			name: "ensure a renew deadline longer than the lease duration is invalid",
+			env: map[string]string{
+				leaderElectionNamespaceEnv:     "webhook",
+				leaderElectionLeaseDurationEnv: "5s",
+				leaderElectionRenewDeadlineEnv: "10s",
+			},
+			wantErr: ErrLeaderElectionInvalidConfig,
+		},
+		{
+			name: "ensure an invalid duration is invalid",
+			env: map[string]string{
+				leaderElectionNamespaceEnv:   "webhook",
+				leaderElectionRetryPeriodEnv: "often",
+			},
+			wantErr: ErrInvalidEnv,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			leader, err := newLeaderFromEnv(fake.NewSimpleClientset(), testDecisionLogger(), NewMetrics())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("newLeaderFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if status := leader.Status(); status.Enabled != tt.wantEnabled {
+				t.Errorf("Status().Enabled = %t, want %t", status.Enabled, tt.wantEnabled)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/nukleros/pod-security-webhook/logging"
+)
+
+// LogLevelRequest is the request and response body for the log level endpoint.
+type LogLevelRequest struct {
+	Level string `json:"level"`
+}
+
+// logLevel returns the current log level of the webhook on a GET request, and sets the log
+// level of the webhook at runtime on a PUT request.
+func (webhook *Webhook) logLevel(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPut {
+		request := &LogLevelRequest{}
+		if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+			webhook.writeErrorMessage(w, fmt.Errorf("%w - unable to decode the PUT request", err), http.StatusBadRequest)
+
+			return
+		}
+
+		level, err := logging.ParseLevel(request.Level)
+		if err != nil {
+			webhook.writeErrorMessage(w, err, http.StatusBadRequest)
+
+			return
+		}
+
+		webhook.Log.Infof("setting log level from [%s] to [%s]", webhook.Log.Level(), level)
+		webhook.Log.SetLevel(level)
+	}
+
+	response, err := json.Marshal(&LogLevelRequest{Level: webhook.Log.Level().String()})
+	if err != nil {
+		webhook.writeErrorMessage(w, fmt.Errorf("%w - unable to marshal the json response", err), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if _, err := w.Write(response); err != nil {
+		webhook.Log.Errorf("%s - error writing response", err)
+	}
+}
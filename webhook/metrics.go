@@ -0,0 +1,141 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package webhook
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/nukleros/pod-security-webhook/validate"
+)
+
+const metricsNamespace = "pod_security_webhook"
+
+// Metrics are the prometheus metrics exposed by the webhook.
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	ValidationsSkipped    *prometheus.CounterVec
+	ValidationsDowngraded *prometheus.CounterVec
+
+	DecisionsDropped *prometheus.CounterVec
+	DecisionsErrored *prometheus.CounterVec
+
+	PluginErrors *prometheus.CounterVec
+
+	Leader prometheus.Gauge
+}
+
+// NewMetrics returns a new set of registered metrics for the webhook.
+func NewMetrics() *Metrics {
+	metrics := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		ValidationsSkipped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "validations_skipped_total",
+			Help:      "Number of validations skipped, partitioned by validation and reason.",
+		}, []string{"validation", "reason"}),
+		ValidationsDowngraded: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "validations_downgraded_total",
+			Help:      "Number of validations downgraded to warn mode, partitioned by validation and reason.",
+		}, []string{"validation", "reason"}),
+		DecisionsDropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "decision_log_dropped_total",
+			Help:      "Number of decision records dropped due to a full buffer, partitioned by sink.",
+		}, []string{"sink"}),
+		DecisionsErrored: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "decision_log_errors_total",
+			Help:      "Number of decision records which failed to be written, partitioned by sink.",
+		}, []string{"sink"}),
+		PluginErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "plugin_errors_total",
+			Help:      "Number of failed calls to validation plugins, partitioned by plugin and failure policy.",
+		}, []string{"plugin", "failure_policy"}),
+		Leader: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "leader",
+			Help:      "Whether this replica is the leader, which runs the background workers.",
+		}),
+	}
+
+	metrics.Registry.MustRegister(
+		metrics.ValidationsSkipped,
+		metrics.ValidationsDowngraded,
+		metrics.DecisionsDropped,
+		metrics.DecisionsErrored,
+		metrics.PluginErrors,
+		metrics.Leader,
+	)
+
+	return metrics
+}
+
+// Handler returns the http handler which serves the metrics.
+func (metrics *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{})
+}
+
+// skipped records a skipped validation.
+func (metrics *Metrics) skipped(name string, reason validate.SkipReason) {
+	if metrics == nil {
+		return
+	}
+
+	metrics.ValidationsSkipped.WithLabelValues(name, string(reason)).Inc()
+}
+
+// downgraded records a validation downgraded to warn mode.
+func (metrics *Metrics) downgraded(name string, reason validate.SkipReason) {
+	if metrics == nil {
+		return
+	}
+
+	metrics.ValidationsDowngraded.WithLabelValues(name, string(reason)).Inc()
+}
+
+// decisionDropped records a decision record dropped by a sink.
+func (metrics *Metrics) decisionDropped(sink string) {
+	if metrics == nil {
+		return
+	}
+
+	metrics.DecisionsDropped.WithLabelValues(sink).Inc()
+}
+
+// decisionErrored records decision records which failed to be written by a sink.
+func (metrics *Metrics) decisionErrored(sink string, count int) {
+	if metrics == nil {
+		return
+	}
+
+	metrics.DecisionsErrored.WithLabelValues(sink).Add(float64(count))
+}
+
+// pluginErrored records a failed call to a validation plugin.
+func (metrics *Metrics) pluginErrored(plugin *validate.Plugin) {
+	if metrics == nil {
+		return
+	}
+
+	metrics.PluginErrors.WithLabelValues(plugin.Name, string(plugin.FailurePolicy)).Inc()
+}
+
+// leading records whether this replica is the leader.
+func (metrics *Metrics) leading(leading bool) {
+	if metrics == nil {
+		return
+	}
+
+	if leading {
+		metrics.Leader.Set(1)
+	} else {
+		metrics.Leader.Set(0)
+	}
+}
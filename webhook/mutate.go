@@ -0,0 +1,239 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/nukleros/pod-security-webhook/resources"
+)
+
+// OriginalImagesAnnotation is the annotation on the pod, or pod template of a workload, which
+// records the original image of each container which was rewritten to a mirror.
+const OriginalImagesAnnotation = "pod-security-webhook.nukleros.io/original-images"
+
+// mutate runs through each step of the mutation process.  Mutations never deny a request, and as
+// mutating webhooks are called prior to validating webhooks, the validations run against the
+// mutated resource.
+func (webhook *Webhook) mutate(w http.ResponseWriter, r *http.Request) {
+	// create a new operation object for each instance of mutate
+	operation := &Operation{
+		Log:      webhook.Log,
+		Metrics:  webhook.Metrics,
+		Policy:   webhook.Policy,
+		Started:  time.Now(),
+		Mutating: true,
+		OperationStep: []OperationStep{
+			webhook.performSetup,
+			webhook.performMutate,
+		},
+	}
+
+	// mutations are not registered as they are not individually skipped or reported
+	operation.RegisterFunc = func() {}
+
+	// run the operation
+	operation.run(webhook, w, r)
+}
+
+// performMutate builds the patches which mutate the resource.  As mutations never deny a request,
+// the resource is permitted without any patches if they can not be built, and is then validated
+// unchanged.
+func (webhook *Webhook) performMutate(w http.ResponseWriter, r *http.Request, operation *Operation) (int, error) {
+	if err := operation.mirrorImages(); err != nil {
+		operation.Log.Errorf("%s - permitting resource without mutations", err)
+		operation.Patches = nil
+	}
+
+	return http.StatusAccepted, nil
+}
+
+// applyMutations applies the patches which mutate the resource of an operation to the resource,
+// so that it is validated as the api server would validate it after calling the mutating webhook.
+func (operation *Operation) applyMutations() error {
+	if err := operation.mirrorImages(); err != nil {
+		return err
+	}
+
+	if len(operation.Patches) == 0 {
+		return nil
+	}
+
+	patchData, err := json.Marshal(operation.Patches)
+	if err != nil {
+		return fmt.Errorf("%w - unable to marshal patches", err)
+	}
+
+	patch, err := jsonpatch.DecodePatch(patchData)
+	if err != nil {
+		return fmt.Errorf("%w - unable to decode patches", err)
+	}
+
+	original, err := json.Marshal(operation.Resource)
+	if err != nil {
+		return fmt.Errorf("%w - unable to marshal resource", err)
+	}
+
+	mutated, err := patch.Apply(original)
+	if err != nil {
+		return fmt.Errorf("%w - unable to apply patches", err)
+	}
+
+	object := &unstructured.Unstructured{}
+	if err := json.Unmarshal(mutated, object); err != nil {
+		return fmt.Errorf("%w - unable to unmarshal mutated resource to unstructured object", err)
+	}
+
+	podSpec, err := resources.GetPodSpec(object)
+	if err != nil {
+		return fmt.Errorf("%w - error retrieving pod specification from mutated resource", err)
+	}
+
+	operation.Resource, operation.PodSpec, operation.Patches = object, podSpec, nil
+
+	return nil
+}
+
+// mirrorImages adds patches which rewrite the image of each container from a source registry to
+// its mirror, recording the original images in an annotation.
+func (operation *Operation) mirrorImages() error {
+	if operation.Policy == nil || len(operation.Policy.ImageMirrors) == 0 {
+		return nil
+	}
+
+	templatePath := resources.GetPodTemplatePath(operation.Resource)
+	originals := map[string]string{}
+
+	for _, field := range []struct {
+		name       string
+		containers []corev1.Container
+	}{
+		{name: "initContainers", containers: operation.PodSpec.InitContainers},
+		{name: "containers", containers: operation.PodSpec.Containers},
+	} {
+		for i, container := range field.containers {
+			mirrored, ok := operation.Policy.MirrorImage(container.Image)
+			if !ok {
+				continue
+			}
+
+			operation.Log.Infof("rewriting image [%s] for container [%s] to mirror [%s]", container.Image, container.Name, mirrored)
+
+			operation.Patches = append(operation.Patches, map[string]interface{}{
+				"op":    "replace",
+				"path":  jsonPointer(templatePath, "spec", field.name, strconv.Itoa(i), "image"),
+				"value": mirrored,
+			})
+
+			originals[container.Name] = container.Image
+		}
+	}
+
+	if len(originals) == 0 {
+		return nil
+	}
+
+	return operation.annotateOriginalImages(templatePath, originals)
+}
+
+// annotateOriginalImages adds a patch which records the original images of the containers in an
+// annotation on the pod template, merged with any original images recorded previously.
+func (operation *Operation) annotateOriginalImages(templatePath []string, originals map[string]string) error {
+	annotations := resources.GetPodAnnotations(operation.Resource)
+
+	if existing, ok := annotations[OriginalImagesAnnotation]; ok {
+		previous := map[string]string{}
+		if err := json.Unmarshal([]byte(existing), &previous); err != nil {
+			operation.Log.Warningf("%s - replacing invalid annotation [%s]", err, OriginalImagesAnnotation)
+		}
+
+		for name, image := range previous {
+			if _, ok := originals[name]; !ok {
+				originals[name] = image
+			}
+		}
+	}
+
+	value, err := json.Marshal(originals)
+	if err != nil {
+		return fmt.Errorf("%w - unable to marshal original images", err)
+	}
+
+	// a json patch can not add a key to a map which does not exist, so we must add the annotations,
+	// or the metadata, if they are not already set
+	if annotations != nil {
+		operation.Patches = append(operation.Patches, map[string]interface{}{
+			"op":    "add",
+			"path":  jsonPointer(templatePath, "metadata", "annotations", OriginalImagesAnnotation),
+			"value": string(value),
+		})
+
+		return nil
+	}
+
+	annotationsValue := map[string]string{OriginalImagesAnnotation: string(value)}
+
+	hasMetadata, err := hasField(operation.Resource, templatePath, "metadata")
+	if err != nil {
+		return err
+	}
+
+	if hasMetadata {
+		operation.Patches = append(operation.Patches, map[string]interface{}{
+			"op":    "add",
+			"path":  jsonPointer(templatePath, "metadata", "annotations"),
+			"value": annotationsValue,
+		})
+
+		return nil
+	}
+
+	operation.Patches = append(operation.Patches, map[string]interface{}{
+		"op":    "add",
+		"path":  jsonPointer(templatePath, "metadata"),
+		"value": map[string]interface{}{"annotations": annotationsValue},
+	})
+
+	return nil
+}
+
+// hasField returns whether a field, at a path relative to a prefix, is set on a resource.
+func hasField(resource runtime.Object, prefix []string, path ...string) (bool, error) {
+	path = append(append([]string{}, prefix...), path...)
+
+	object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(resource)
+	if err != nil {
+		return false, fmt.Errorf("%w - unable to convert resource to unstructured object", err)
+	}
+
+	_, found, err := unstructured.NestedFieldNoCopy(object, path...)
+	if err != nil {
+		return false, fmt.Errorf("%w - unable to retrieve field [%s]", err, strings.Join(path, "."))
+	}
+
+	return found, nil
+}
+
+// jsonPointer returns the json pointer for a path relative to a prefix, escaping each of its
+// segments.
+func jsonPointer(prefix []string, path ...string) string {
+	escaper := strings.NewReplacer("~", "~0", "/", "~1")
+
+	pointer := ""
+	for _, segment := range append(append([]string{}, prefix...), path...) {
+		pointer += "/" + escaper.Replace(segment)
+	}
+
+	return pointer
+}
@@ -0,0 +1,326 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/nukleros/pod-security-webhook/validate"
+)
+
+const mirrorPolicy = "registry.internal/dockerhub"
+
+var errUnconvertibleTest = errors.New("unable to marshal")
+
+func mirrorWebhook() *Webhook {
+	return &Webhook{
+		Log:     testDecisionLogger(),
+		Metrics: NewMetrics(),
+		Policy: &validate.PolicyConfig{
+			ImageMirrors: []*validate.ImageMirror{
+				{Sources: []string{"docker.io"}, Mirror: mirrorPolicy},
+			},
+		},
+	}
+}
+
+// review sends an admission review for an object to a handler and returns the response.
+func review(t *testing.T, handler http.HandlerFunc, object map[string]interface{}) *admissionv1.AdmissionResponse {
+	t.Helper()
+
+	raw, err := json.Marshal(object)
+	if err != nil {
+		t.Fatalf("unable to marshal object: %v", err)
+	}
+
+	resource := &unstructured.Unstructured{Object: object}
+	gvk := resource.GroupVersionKind()
+	kind := metav1.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind}
+
+	body, err := json.Marshal(&admissionv1.AdmissionReview{
+		Request: &admissionv1.AdmissionRequest{
+			UID:         "uid",
+			Kind:        kind,
+			RequestKind: &kind,
+			Operation:   admissionv1.Create,
+			Namespace:   resource.GetNamespace(),
+			Object:      runtime.RawExtension{Raw: raw},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unable to marshal admission review: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body)))
+
+	response := &admissionv1.AdmissionReview{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), response); err != nil {
+		t.Fatalf("unable to unmarshal admission review response %q: %v", recorder.Body.String(), err)
+	}
+
+	return response.Response
+}
+
+// patched returns an object with the patch from an admission response applied.
+func patched(t *testing.T, object map[string]interface{}, response *admissionv1.AdmissionResponse) map[string]interface{} {
+	t.Helper()
+
+	raw, err := json.Marshal(object)
+	if err != nil {
+		t.Fatalf("unable to marshal object: %v", err)
+	}
+
+	patch, err := jsonpatch.DecodePatch(response.Patch)
+	if err != nil {
+		t.Fatalf("unable to decode patch %s: %v", response.Patch, err)
+	}
+
+	raw, err = patch.Apply(raw)
+	if err != nil {
+		t.Fatalf("unable to apply patch %s: %v", response.Patch, err)
+	}
+
+	result := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		t.Fatalf("unable to unmarshal patched object: %v", err)
+	}
+
+	return result
+}
+
+func TestMutateImageMirror(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		object        map[string]interface{}
+		templatePath  []string
+		wantImages    []string
+		wantOriginals string
+	}{
+		{
+			name: "ensure deployment images from a source registry are rewritten and recorded",
+			object: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata":   map[string]interface{}{"name": "app", "namespace": "default"},
+				"spec": map[string]interface{}{
+					"template": map[string]interface{}{
+						"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "app"}},
+						"spec": map[string]interface{}{
+							"initContainers": []interface{}{
+								map[string]interface{}{"name": "init", "image": "busybox@sha256:abc"},
+							},
+							"containers": []interface{}{
+								map[string]interface{}{"name": "app", "image": "ghcr.io/nukleros/app:v1"},
+								map[string]interface{}{"name": "proxy", "image": "docker.io/envoyproxy/envoy:v1.23"},
+							},
+						},
+					},
+				},
+			},
+			templatePath: []string{"spec", "template"},
+			wantImages: []string{
+				mirrorPolicy + "/library/busybox@sha256:abc",
+				"ghcr.io/nukleros/app:v1",
+				mirrorPolicy + "/envoyproxy/envoy:v1.23",
+			},
+			wantOriginals: `{"init":"busybox@sha256:abc","proxy":"docker.io/envoyproxy/envoy:v1.23"}`,
+		},
+		{
+			name: "ensure pod images are rewritten and merged with previously recorded images",
+			object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Pod",
+				"metadata": map[string]interface{}{
+					"name":      "app",
+					"namespace": "default",
+					"annotations": map[string]interface{}{
+						OriginalImagesAnnotation: `{"sidecar":"nginx:1.23"}`,
+					},
+				},
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "app", "image": "nginx:1.25"},
+						map[string]interface{}{"name": "sidecar", "image": mirrorPolicy + "/library/nginx:1.23"},
+					},
+				},
+			},
+			templatePath: []string{},
+			wantImages: []string{
+				mirrorPolicy + "/library/nginx:1.25",
+				mirrorPolicy + "/library/nginx:1.23",
+			},
+			wantOriginals: `{"app":"nginx:1.25","sidecar":"nginx:1.23"}`,
+		},
+		{
+			name: "ensure cronjob images are rewritten when the pod template has no metadata",
+			object: map[string]interface{}{
+				"apiVersion": "batch/v1",
+				"kind":       "CronJob",
+				"metadata":   map[string]interface{}{"name": "app", "namespace": "default"},
+				"spec": map[string]interface{}{
+					"schedule": "* * * * *",
+					"jobTemplate": map[string]interface{}{
+						"spec": map[string]interface{}{
+							"template": map[string]interface{}{
+								"spec": map[string]interface{}{
+									"containers": []interface{}{
+										map[string]interface{}{"name": "job", "image": "alpine:3"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			templatePath:  []string{"spec", "jobTemplate", "spec", "template"},
+			wantImages:    []string{mirrorPolicy + "/library/alpine:3"},
+			wantOriginals: `{"job":"alpine:3"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			webhook := mirrorWebhook()
+			response := review(t, webhook.mutate, tt.object)
+			if !response.Allowed {
+				t.Fatalf("mutate() allowed = false, message = %s", response.Result.Message)
+			}
+			result := &unstructured.Unstructured{Object: patched(t, tt.object, response)}
+
+			images := []string{}
+			for _, field := range []string{"initContainers", "containers"} {
+				containers, _, _ := unstructured.NestedSlice(result.Object, append(tt.templatePath, "spec", field)...)
+				for _, container := range containers {
+					images = append(images, container.(map[string]interface{})["image"].(string))
+				}
+			}
+			if len(images) != len(tt.wantImages) {
+				t.Fatalf("mutate() images = %v, want %v", images, tt.wantImages)
+			}
+			for i := range images {
+				if images[i] != tt.wantImages[i] {
+					t.Errorf("mutate() images = %v, want %v", images, tt.wantImages)
+				}
+			}
+
+			originals, _, _ := unstructured.NestedString(result.Object, append(tt.templatePath, "metadata", "annotations", OriginalImagesAnnotation)...)
+			if originals != tt.wantOriginals {
+				t.Errorf("mutate() original images = %s, want %s", originals, tt.wantOriginals)
+			}
+		})
+	}
+}
+
+func TestMutateWithoutMirrors(t *testing.T) {
+	t.Parallel()
+
+	webhook := &Webhook{Log: testDecisionLogger(), Metrics: NewMetrics(), Policy: &validate.PolicyConfig{}}
+	response := review(t, webhook.mutate, map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]interface{}{"name": "app", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"containers": []interface{}{map[string]interface{}{"name": "app", "image": "nginx"}},
+		},
+	})
+
+	if !response.Allowed || response.Patch != nil {
+		t.Errorf("mutate() allowed = %v, patch = %s, want allowed without a patch", response.Allowed, response.Patch)
+	}
+}
+
+// unconvertiblePod is a pod which is unable to be converted to an unstructured object.
+type unconvertiblePod struct {
+	corev1.Pod
+}
+
+func (pod *unconvertiblePod) MarshalJSON() ([]byte, error) {
+	return nil, errUnconvertibleTest
+}
+
+func TestMutateFailure(t *testing.T) {
+	t.Parallel()
+
+	webhook := mirrorWebhook()
+
+	// the pod has no metadata, so the annotation recording the original images requires converting it
+	pod := &unconvertiblePod{}
+	pod.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Pod"))
+	pod.Spec.Containers = []corev1.Container{{Name: "app", Image: "nginx"}}
+
+	operation := &Operation{
+		Log:      webhook.Log,
+		Policy:   webhook.Policy,
+		Mutating: true,
+		Resource: pod,
+		PodSpec:  &pod.Spec,
+	}
+
+	if _, err := webhook.performMutate(nil, nil, operation); err != nil {
+		t.Fatalf("performMutate() error = %v, want the resource to be permitted", err)
+	}
+
+	if len(operation.Patches) != 0 {
+		t.Errorf("performMutate() patches = %v, want none when the mutation fails", operation.Patches)
+	}
+}
+
+// TestMutateThenValidate ensures that the validations, which are called by the api server after the
+// mutating webhooks, run against the rewritten images.
+//
+//nolint:paralleltest
+func TestMutateThenValidate(t *testing.T) {
+	t.Setenv(validate.ImageRegistryEnv, mirrorPolicy)
+
+	nonRoot := true
+	pod := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]interface{}{"name": "app", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"automountServiceAccountToken": false,
+			"serviceAccountName":           "app",
+			"securityContext":              map[string]interface{}{"runAsNonRoot": nonRoot},
+			"containers": []interface{}{
+				map[string]interface{}{
+					"name":  "app",
+					"image": "nginx:1.25",
+					"securityContext": map[string]interface{}{
+						"allowPrivilegeEscalation": false,
+						"capabilities":             map[string]interface{}{"drop": []interface{}{"ALL"}},
+					},
+				},
+			},
+		},
+	}
+
+	webhook := mirrorWebhook()
+
+	if response := review(t, webhook.validate, pod); response.Allowed {
+		t.Fatalf("validate() allowed = true for an upstream image, want false")
+	}
+
+	mutated := patched(t, pod, review(t, webhook.mutate, pod))
+
+	if response := review(t, webhook.validate, mutated); !response.Allowed {
+		t.Errorf("validate() allowed = false for a mirrored image, message = %s", response.Result.Message)
+	}
+}
@@ -0,0 +1,258 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package webhook
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	serverReadTimeoutEnv       = "SERVER_READ_TIMEOUT"
+	serverReadHeaderTimeoutEnv = "SERVER_READ_HEADER_TIMEOUT"
+	serverWriteTimeoutEnv      = "SERVER_WRITE_TIMEOUT"
+	serverIdleTimeoutEnv       = "SERVER_IDLE_TIMEOUT"
+	shutdownDrainDelayEnv      = "SHUTDOWN_DRAIN_DELAY"
+	shutdownGracePeriodEnv     = "SHUTDOWN_GRACE_PERIOD"
+
+	// the api server times out admission requests after at most 30 seconds, so requests which take
+	// longer than this are abandoned by the api server regardless
+	defaultServerReadTimeout       = 10 * time.Second
+	defaultServerReadHeaderTimeout = 5 * time.Second
+	defaultServerWriteTimeout      = 30 * time.Second
+	defaultServerIdleTimeout       = 120 * time.Second
+
+	// the drain delay allows the readiness probe to fail, and the replica to be removed from the
+	// endpoints of the service, prior to closing the listener.  the sum of the drain delay and the
+	// grace period must be less than the termination grace period of the pod.
+	defaultShutdownDrainDelay  = 5 * time.Second
+	defaultShutdownGracePeriod = 20 * time.Second
+)
+
+var ErrServerFailed = errors.New("webhook server failed")
+
+// ServerOptions are the timeouts of the webhook server and its shutdown.
+type ServerOptions struct {
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+
+	// DrainDelay is the period for which the readiness check fails prior to the listener being
+	// closed, and GracePeriod is the period in which in-flight requests are allowed to complete
+	// once the listener has been closed.
+	DrainDelay  time.Duration
+	GracePeriod time.Duration
+}
+
+// ServerOptionsFromEnv returns the server options from the environment, using defaults suitable
+// for admission requests for any which are unset.
+func ServerOptionsFromEnv() (*ServerOptions, error) {
+	options := &ServerOptions{}
+
+	for _, option := range []struct {
+		value        *time.Duration
+		key          string
+		defaultValue time.Duration
+	}{
+		{value: &options.ReadTimeout, key: serverReadTimeoutEnv, defaultValue: defaultServerReadTimeout},
+		{value: &options.ReadHeaderTimeout, key: serverReadHeaderTimeoutEnv, defaultValue: defaultServerReadHeaderTimeout},
+		{value: &options.WriteTimeout, key: serverWriteTimeoutEnv, defaultValue: defaultServerWriteTimeout},
+		{value: &options.IdleTimeout, key: serverIdleTimeoutEnv, defaultValue: defaultServerIdleTimeout},
+		{value: &options.DrainDelay, key: shutdownDrainDelayEnv, defaultValue: defaultShutdownDrainDelay},
+		{value: &options.GracePeriod, key: shutdownGracePeriodEnv, defaultValue: defaultShutdownGracePeriod},
+	} {
+		value, err := durationFromEnv(option.key, option.defaultValue)
+		if err != nil {
+			return nil, err
+		}
+
+		*option.value = value
+	}
+
+	return options, nil
+}
+
+// Runner runs the server and the background processes of the webhook, and shuts them down
+// gracefully.
+type Runner struct {
+	webhook *Webhook
+	options *ServerOptions
+	server  *http.Server
+
+	// admin serves the administrative endpoints without tls on the admin address of the webhook.
+	// It is nil if the webhook has no admin address.
+	admin *http.Server
+}
+
+// NewRunner returns a runner which serves the webhook on its port, and the administrative
+// endpoints of the webhook on its admin address.
+func NewRunner(webhook *Webhook, options *ServerOptions) *Runner {
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%v", webhook.Port), // Listen on all the interfaces
+		Handler: webhook.Router,
+		TLSConfig: &tls.Config{
+			Certificates: []tls.Certificate{*webhook.Certificate},
+			MinVersion:   tls.VersionTLS12,
+		},
+
+		// set timeouts to prevent ddos attacks
+		ReadTimeout:       options.ReadTimeout,
+		ReadHeaderTimeout: options.ReadHeaderTimeout,
+		WriteTimeout:      options.WriteTimeout,
+		IdleTimeout:       options.IdleTimeout,
+	}
+
+	runner := &Runner{webhook: webhook, options: options, server: server}
+
+	if webhook.AdminAddress != "" && webhook.AdminRouter != nil {
+		runner.admin = &http.Server{
+			Addr:              webhook.AdminAddress,
+			Handler:           webhook.AdminRouter,
+			ReadTimeout:       options.ReadTimeout,
+			ReadHeaderTimeout: options.ReadHeaderTimeout,
+			WriteTimeout:      options.WriteTimeout,
+			IdleTimeout:       options.IdleTimeout,
+		}
+	}
+
+	return runner
+}
+
+// Run listens on the port and the admin address of the webhook and serves them until the context
+// is cancelled, and then shuts down gracefully.  An error is returned if either listener fails,
+// so that the process exits rather than running without serving.
+func (runner *Runner) Run(ctx context.Context) error {
+	listener, err := net.Listen("tcp", runner.server.Addr)
+	if err != nil {
+		return fmt.Errorf("%w - unable to listen on [%s]; %s", ErrServerFailed, runner.server.Addr, err)
+	}
+
+	var adminListener net.Listener
+
+	if runner.admin != nil {
+		if adminListener, err = net.Listen("tcp", runner.admin.Addr); err != nil {
+			listener.Close()
+
+			return fmt.Errorf("%w - unable to listen on admin address [%s]; %s", ErrServerFailed, runner.admin.Addr, err)
+		}
+	}
+
+	return runner.Serve(ctx, listener, adminListener)
+}
+
+// Serve serves the webhook on a listener, and the administrative endpoints on the admin listener
+// if it is not nil, until the context is cancelled or either listener fails.  Once the context is
+// cancelled, the readiness check fails for the drain delay, after which the listeners are closed
+// and in-flight requests are allowed to complete within the grace period.
+func (runner *Runner) Serve(ctx context.Context, listener, adminListener net.Listener) error {
+	log := runner.webhook.Log
+
+	// the background processes are stopped after the server, as requests may depend on them
+	background, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	backgroundDone := make(chan struct{})
+
+	go func() {
+		defer close(backgroundDone)
+
+		if err := runner.webhook.Start(background); err != nil {
+			log.Error(err.Error())
+		}
+	}()
+
+	serveErr := make(chan error, 2)
+
+	go func() {
+		log.Infof("starting web server on [%s]", listener.Addr())
+
+		serveErr <- runner.server.ServeTLS(listener, "", "")
+	}()
+
+	admin := runner.admin
+	if adminListener == nil {
+		admin = nil
+	}
+
+	if admin != nil {
+		go func() {
+			log.Infof("starting admin server on [%s]", adminListener.Addr())
+
+			serveErr <- admin.Serve(adminListener)
+		}()
+	}
+
+	select {
+	case err := <-serveErr:
+		runner.close(admin)
+		stopBackground()
+		<-backgroundDone
+		runner.webhook.Shutdown()
+
+		return fmt.Errorf("%w - %s", ErrServerFailed, err)
+	case <-ctx.Done():
+	}
+
+	log.Infof("received shutdown signal, draining connections for %s", runner.options.DrainDelay)
+
+	runner.webhook.Drain()
+
+	select {
+	case err := <-serveErr:
+		log.Errorf("%s - web server failed while draining", err)
+	case <-time.After(runner.options.DrainDelay):
+	}
+
+	log.Infof("shutting down web server with a grace period of %s", runner.options.GracePeriod)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), runner.options.GracePeriod)
+	defer cancel()
+
+	shutdownErr := runner.server.Shutdown(shutdownCtx)
+	if shutdownErr != nil {
+		log.Errorf("%s - failed to shutdown web server gracefully, closing remaining connections", shutdownErr)
+	}
+
+	// the admin server serves no admission requests, so it is not drained
+	if admin != nil {
+		if err := admin.Shutdown(shutdownCtx); err != nil {
+			log.Errorf("%s - failed to shutdown admin server gracefully, closing remaining connections", err)
+		}
+	}
+
+	runner.close(admin)
+
+	stopBackground()
+
+	select {
+	case <-backgroundDone:
+	case <-shutdownCtx.Done():
+		log.Error("background processes did not stop within the grace period")
+	}
+
+	runner.webhook.Shutdown()
+
+	if shutdownErr != nil {
+		return fmt.Errorf("%w - %s", ErrServerFailed, shutdownErr)
+	}
+
+	return nil
+}
+
+// close closes the web server and the admin server, if any, along with their connections.
+func (runner *Runner) close(admin *http.Server) {
+	//nolint:errcheck
+	runner.server.Close()
+
+	if admin != nil {
+		//nolint:errcheck
+		admin.Close()
+	}
+}
@@ -0,0 +1,258 @@
+// Copyright 2022 Nukleros
+// SPDX-License-Identifier: MIT
+
+package webhook
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func testRunner(t *testing.T, port int) *Runner {
+	t.Helper()
+
+	webhook, err := NewOfflineWebhook(nil)
+	if err != nil {
+		t.Fatalf("NewOfflineWebhook() error = %v", err)
+	}
+
+	webhook.Certificate = testCertificate(t, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	webhook.Router = webhook.newRouter()
+	webhook.AdminRouter = webhook.newAdminRouter()
+	webhook.AdminAddress = "127.0.0.1:0"
+	webhook.Port = port
+
+	return NewRunner(webhook, &ServerOptions{
+		ReadTimeout:       time.Second,
+		ReadHeaderTimeout: time.Second,
+		WriteTimeout:      time.Second,
+		IdleTimeout:       time.Second,
+		DrainDelay:        500 * time.Millisecond,
+		GracePeriod:       time.Second,
+	})
+}
+
+// readyz returns the status code of the readiness check, or zero if the request failed.
+func readyz(client *http.Client, addr string) int {
+	response, err := client.Get("https://" + addr + "/readyz")
+	if err != nil {
+		return 0
+	}
+	defer response.Body.Close()
+
+	return response.StatusCode
+}
+
+// waitForStatus waits for the readiness check to return a status code.
+func waitForStatus(t *testing.T, client *http.Client, addr string, code int) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for readyz(client, addr) != code {
+		if time.Now().After(deadline) {
+			t.Fatalf("readiness check did not return [%d]", code)
+		}
+
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestRunnerGracefulShutdown(t *testing.T) {
+	t.Parallel()
+
+	runner := testRunner(t, 0)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen: %v", err)
+	}
+
+	adminListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	served := make(chan error, 1)
+
+	go func() {
+		served <- runner.Serve(ctx, listener, adminListener)
+	}()
+
+	client := &http.Client{
+		Timeout: time.Second,
+		//nolint:gosec
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+	}
+
+	waitForStatus(t, client, listener.Addr().String(), http.StatusOK)
+
+	// the administrative endpoints are only served on the admin listener
+	for _, endpoint := range []struct {
+		url      string
+		wantCode int
+	}{
+		{url: "http://" + adminListener.Addr().String() + "/loglevel", wantCode: http.StatusOK},
+		{url: "https://" + listener.Addr().String() + "/loglevel", wantCode: http.StatusNotFound},
+	} {
+		response, err := client.Get(endpoint.url)
+		if err != nil {
+			t.Fatalf("unable to get [%s]: %v", endpoint.url, err)
+		}
+
+		response.Body.Close()
+
+		if response.StatusCode != endpoint.wantCode {
+			t.Errorf("GET [%s] code = %d, want %d", endpoint.url, response.StatusCode, endpoint.wantCode)
+		}
+	}
+
+	// readiness fails while draining, prior to the listener being closed
+	started := time.Now()
+
+	cancel()
+
+	waitForStatus(t, client, listener.Addr().String(), http.StatusServiceUnavailable)
+
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Errorf("Serve() error = %v", err)
+		}
+
+		if elapsed := time.Since(started); elapsed < runner.options.DrainDelay {
+			t.Errorf("Serve() returned after %s, want at least the drain delay of %s", elapsed, runner.options.DrainDelay)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Serve() did not return after shutting down")
+	}
+}
+
+func TestRunnerListenerFailure(t *testing.T) {
+	t.Parallel()
+
+	// occupy a port so that the runner is unable to listen on it
+	occupied, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("unable to listen: %v", err)
+	}
+	defer occupied.Close()
+
+	runner := testRunner(t, occupied.Addr().(*net.TCPAddr).Port)
+
+	if err := runner.Run(context.Background()); !errors.Is(err, ErrServerFailed) {
+		t.Errorf("Run() error = %v, want %v", err, ErrServerFailed)
+	}
+}
+
+func TestRunnerAdminListenerFailure(t *testing.T) {
+	t.Parallel()
+
+	// occupy an address so that the runner is unable to listen on it for the admin server
+	occupied, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen: %v", err)
+	}
+	defer occupied.Close()
+
+	runner := testRunner(t, 0)
+	runner.admin.Addr = occupied.Addr().String()
+
+	if err := runner.Run(context.Background()); !errors.Is(err, ErrServerFailed) {
+		t.Errorf("Run() error = %v, want %v", err, ErrServerFailed)
+	}
+}
+
+func TestRunnerServeFailure(t *testing.T) {
+	t.Parallel()
+
+	runner := testRunner(t, 0)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen: %v", err)
+	}
+
+	served := make(chan error, 1)
+
+	go func() {
+		served <- runner.Serve(context.Background(), listener, nil)
+	}()
+
+	// closing the listener fails the server, which must return rather than run without serving
+	listener.Close()
+
+	select {
+	case err := <-served:
+		if !errors.Is(err, ErrServerFailed) {
+			t.Errorf("Serve() error = %v, want %v", err, ErrServerFailed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Serve() did not return after the listener failed")
+	}
+}
+
+//nolint:paralleltest
+func TestServerOptionsFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    *ServerOptions
+		wantErr bool
+	}{
+		{
+			name: "ensure defaults are used for unset options",
+			env:  map[string]string{},
+			want: &ServerOptions{
+				ReadTimeout:       defaultServerReadTimeout,
+				ReadHeaderTimeout: defaultServerReadHeaderTimeout,
+				WriteTimeout:      defaultServerWriteTimeout,
+				IdleTimeout:       defaultServerIdleTimeout,
+				DrainDelay:        defaultShutdownDrainDelay,
+				GracePeriod:       defaultShutdownGracePeriod,
+			},
+		},
+		{
+			name: "ensure options are read from the environment",
+			env:  map[string]string{serverWriteTimeoutEnv: "15s", shutdownGracePeriodEnv: "45s"},
+			want: &ServerOptions{
+				ReadTimeout:       defaultServerReadTimeout,
+				ReadHeaderTimeout: defaultServerReadHeaderTimeout,
+				WriteTimeout:      15 * time.Second,
+				IdleTimeout:       defaultServerIdleTimeout,
+				DrainDelay:        defaultShutdownDrainDelay,
+				GracePeriod:       45 * time.Second,
+			},
+		},
+		{
+			name:    "ensure an invalid duration is invalid",
+			env:     map[string]string{shutdownDrainDelayEnv: "-5s"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			got, err := ServerOptionsFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ServerOptionsFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err == nil && *got != *tt.want {
+				t.Errorf("ServerOptionsFromEnv() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
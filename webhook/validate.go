@@ -4,9 +4,18 @@
 package webhook
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/nukleros/pod-security-webhook/resources"
 	"github.com/nukleros/pod-security-webhook/validate"
@@ -18,9 +27,52 @@ var (
 
 // validate runs through each step of the validation process.
 func (webhook *Webhook) validate(w http.ResponseWriter, r *http.Request) {
-	// create a new operation object for each instance of mutate
+	webhook.validateOperation().run(webhook, w, r)
+}
+
+// Admit runs an admission review through each step of the validation process, exactly as the
+// validate endpoint would, and returns the response along with the result of each validation.
+// This is used to test policies against fixtures outside of a cluster, so the image mirrors of the
+// policy configuration are applied first, as the api server would call the mutate endpoint before
+// the validate endpoint.
+func (webhook *Webhook) Admit(review *admissionv1.AdmissionReview) (*admissionv1.AdmissionResponse, []*validate.Result, error) {
+	body, err := json.Marshal(review)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w - unable to marshal admission review", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	operation := webhook.validateOperation()
+
+	// the validations are registered with the resource from the admission review, so the mutations
+	// must be applied before they are registered
+	operation.RegisterFunc = func() {
+		if err := operation.applyMutations(); err != nil {
+			operation.Log.Errorf("%s - admitting resource without mutations", err)
+		}
+
+		operation.registerValidations()
+	}
+
+	operation.run(webhook, recorder, httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(body)))
+
+	response := &admissionv1.AdmissionReview{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), response); err != nil {
+		return nil, nil, fmt.Errorf("%w - unable to unmarshal admission response [%s]", err, strings.TrimSpace(recorder.Body.String()))
+	}
+
+	return response.Response, operation.Results, nil
+}
+
+// validateOperation returns a new operation which runs through each step of the validation process.
+func (webhook *Webhook) validateOperation() *Operation {
+	// create a new operation object for each instance of validate
 	operation := &Operation{
-		Log: webhook.Log,
+		Log:                  webhook.Log,
+		Metrics:              webhook.Metrics,
+		Policy:               webhook.Policy,
+		ExemptionGracePeriod: webhook.ExemptionGracePeriod,
+		Started:              time.Now(),
 		OperationStep: []OperationStep{
 			webhook.performSetup,
 			webhook.performValidate,
@@ -30,33 +82,109 @@ func (webhook *Webhook) validate(w http.ResponseWriter, r *http.Request) {
 	// set the register function to register the operations
 	operation.RegisterFunc = operation.registerValidations
 
-	// run the operation
-	operation.run(webhook, w, r)
+	return operation
+}
+
+// Evaluate runs all validations against a resource outside of an admission request and returns
+// the results.  This is used to audit existing resources in the cluster and to scan manifests, so
+// the image mirrors of the policy configuration are applied first, as they would be on admission.
+func (webhook *Webhook) Evaluate(resource client.Object) ([]*validate.Result, error) {
+	podSpec, err := resources.GetPodSpec(resource)
+	if err != nil {
+		return nil, fmt.Errorf("%w - error retrieving pod specification from object", err)
+	}
+
+	operation := &Operation{
+		Log:                  webhook.Log,
+		Policy:               webhook.Policy,
+		ExemptionGracePeriod: webhook.ExemptionGracePeriod,
+		Resource:             resource,
+		PodSpec:              podSpec,
+		Namespace:            webhook.namespace(context.Background(), resource.GetNamespace()),
+	}
+
+	if err := operation.applyMutations(); err != nil {
+		operation.Log.Errorf("%s - evaluating resource without mutations", err)
+	}
+
+	operation.registerValidations()
+
+	// failed validations are recorded in the results so the error is not needed here
+	//nolint:errcheck
+	_ = operation.execute()
+
+	return operation.Results, nil
 }
 
 // registerValidations registers all validations that are know to this webhook.
 func (operation *Operation) registerValidations() {
-	// validate no privilege escalation requests and no root containers unless overridden by an annotation or environment
-	// variable
-	operation.registerValidation(validate.NewValidation(validate.RunAsNonRootValidationName, validate.RunAsNonRoot))
-	operation.registerValidation(validate.NewValidation(validate.PrivilegedValidationName, validate.Privileged))
-	operation.registerValidation(validate.NewValidation(validate.AllowPrivilegeEscalationValidationName, validate.AllowPrivilegeEscalation))
-
-	// validate items pertaining access to host resources
-	operation.registerValidation(validate.NewValidation(validate.HostPIDValidationName, validate.HostPID))
-	operation.registerValidation(validate.NewValidation(validate.HostIPCValidationName, validate.HostIPC))
-	operation.registerValidation(validate.NewValidation(validate.HostNetworkValidationName, validate.HostNetwork))
-
-	// validate items pertaining to expanded container capabilities
-	operation.registerValidation(validate.NewValidation(validate.AddCapabilitiesValidationName, validate.AddCapabilities))
-	operation.registerValidation(validate.NewValidation(validate.DropCapabilitiesValidationName, validate.DropCapabilities))
-
-	// validate items pertaining to images
-	operation.registerValidation(validate.NewValidation(validate.ImageRegistryValidationName, validate.ImageRegistry))
+	for _, validation := range Validations(operation.Policy) {
+		operation.registerValidation(validation)
+	}
+}
+
+// Validations returns all validations that are known to this webhook, including the custom
+// validations and plugins of a policy configuration, in the order in which they are run.
+func Validations(policy *validate.PolicyConfig) []*validate.Validation {
+	validations := []*validate.Validation{
+		// validate no privilege escalation requests and no root containers unless overridden by an annotation or environment
+		// variable
+		validate.NewValidation(validate.RunAsNonRootValidationName, validate.RunAsNonRoot),
+		validate.NewValidation(validate.PrivilegedValidationName, validate.Privileged),
+		validate.NewValidation(validate.AllowPrivilegeEscalationValidationName, validate.AllowPrivilegeEscalation),
+
+		// validate items pertaining access to host resources
+		validate.NewValidation(validate.HostPIDValidationName, validate.HostPID),
+		validate.NewValidation(validate.HostIPCValidationName, validate.HostIPC),
+		validate.NewValidation(validate.HostNetworkValidationName, validate.HostNetwork),
+		validate.NewValidation(validate.WindowsHostProcessValidationName, validate.WindowsHostProcess),
+
+		// validate items pertaining to kernel settings and linux security modules
+		validate.NewValidation(validate.UnsafeSysctlsValidationName, validate.UnsafeSysctls),
+		validate.NewValidation(validate.UnsafeProcMountValidationName, validate.UnsafeProcMount),
+		validate.NewValidation(validate.AppArmorProfileValidationName, validate.AppArmorProfile),
+		validate.NewValidation(validate.SELinuxOptionsValidationName, validate.SELinuxOptions),
+
+		// validate items pertaining to scheduling
+		validate.NewValidation(validate.SchedulingValidationName, validate.Scheduling),
+
+		// validate items pertaining to user and group ids
+		validate.NewValidation(validate.UserGroupRangesValidationName, validate.UserGroupRanges),
+
+		// validate items pertaining to volumes
+		validate.NewValidation(validate.AllowedVolumeTypesValidationName, validate.AllowedVolumeTypes),
+
+		// validate items pertaining to expanded container capabilities
+		validate.NewValidation(validate.AddCapabilitiesValidationName, validate.AddCapabilities),
+		validate.NewValidation(validate.DropCapabilitiesValidationName, validate.DropCapabilities),
+
+		// validate items pertaining to images
+		validate.NewValidation(validate.ImageRegistryValidationName, validate.ImageRegistry),
+		validate.NewValidation(validate.ImageSignatureValidationName, validate.ImageSignature),
+
+		// validate items pertaining to resource requirements
+		validate.NewValidation(validate.CPURequirementsValidationName, validate.CPURequirements),
+		validate.NewValidation(validate.MemoryRequirementsValidationName, validate.MemoryRequirements),
+	}
+
+	// validate items pertaining to custom validations from the policy configuration
+	if policy != nil {
+		for _, custom := range policy.CustomValidations {
+			validations = append(validations, custom.Validation())
+		}
+
+		for _, plugin := range policy.Plugins {
+			validations = append(validations, plugin.Validation())
+		}
+	}
+
+	return validations
 }
 
 // registerValidation registers an individual valiation for the webhook.
 func (operation *Operation) registerValidation(validation *validate.Validation) {
+	operation.Known = append(operation.Known, validation)
+
 	// do not register a validation if we have an environment variable override set explicitly to 'false'
 	if os.Getenv(validation.EnvironmetVariableOverride()) == validate.SkipValidationEnvValue {
 		operation.Log.Infof(
@@ -66,24 +194,60 @@ func (operation *Operation) registerValidation(validation *validate.Validation)
 			validate.SkipValidationEnvValue,
 		)
 
+		operation.skip(validation, validate.SkipReasonEnv, fmt.Sprintf(
+			"%s=%s",
+			validation.EnvironmetVariableOverride(),
+			validate.SkipValidationEnvValue,
+		))
+
+		return
+	}
+
+	// downgrade the validation to warn mode if we have an environment variable override set
+	// explicitly to 'warn'
+	if os.Getenv(validation.EnvironmetVariableOverride()) == validate.WarnValidationEnvValue {
+		validation.Action = validate.EnforcementActionWarn
+	}
+
+	// if the requester is exempt from this validation, skip the validation or downgrade the
+	// validation to warn mode
+	if skip := operation.applyUserExemption(validation); skip {
 		return
 	}
 
-	// add the pod spec and resource to the mutation from the webhook operation
+	// add the pod spec, resource, namespace and policy configuration to the mutation from the webhook operation
 	validation.PodSpec = operation.PodSpec
 	validation.Resource = operation.Resource
+	validation.Policy = operation.Policy
+	validation.Namespace = operation.Namespace
+
+	if operation.Review != nil && operation.Review.Request != nil {
+		validation.Operation = operation.Review.Request.Operation
+	}
 
 	// if we have owner references, we have another controller that is managing our thing
 	// so we should not mutate it
 	if resources.SkipViaOwnerReferences(validation.Resource) {
 		// debug here otherwise each pod created by a deployment/etc will cause a log
 		// message which is super chatty
-		operation.Log.DebugF(
+		operation.Log.Debugf(
 			"skipping validation [%s] due to owner references [%+v]",
 			validation.Name,
 			validation.Resource.GetOwnerReferences(),
 		)
 
+		operation.skip(validation, validate.SkipReasonOwner, fmt.Sprintf(
+			"owned by %s/%s",
+			validation.Resource.GetOwnerReferences()[0].Kind,
+			validation.Resource.GetOwnerReferences()[0].Name,
+		))
+
+		return
+	}
+
+	// if we have an exemption for this resource, skip the validation while the exemption is active
+	// and downgrade the validation to warn mode while the exemption is within its grace period
+	if skip := operation.applyExemption(validation); skip {
 		return
 	}
 
@@ -97,26 +261,167 @@ func (operation *Operation) registerValidation(validation *validate.Validation)
 			resources.GetAnnotation(validation.Resource, validation.AnnotationOverride()),
 		)
 
+		operation.skip(validation, validate.SkipReasonAnnotation, validation.AnnotationOverride())
+
 		return
 	}
 
-	operation.Log.DebugF("registering validation: %s", validation.Name)
+	operation.Log.Debugf("registering validation: %s", validation.Name)
 	operation.Validations = append(operation.Validations, validation)
 }
 
+// applyUserExemption applies an exemption for the requesting user, group or service account
+// to a validation.  It returns true if the validation should be skipped.
+func (operation *Operation) applyUserExemption(validation *validate.Validation) bool {
+	if operation.Review == nil || operation.Review.Request == nil {
+		return false
+	}
+
+	exemption, err := validation.UserExemption()
+	if err != nil {
+		// an invalid user exemption does not exempt the requester from the validation
+		operation.Log.Errorf("%s - ignoring user exemptions for validation [%s]", err, validation.Name)
+
+		return false
+	}
+
+	match := exemption.Match(&operation.Review.Request.UserInfo)
+	if match == "" {
+		return false
+	}
+
+	if exemption.Action == validate.WarnValidationEnvValue {
+		operation.Log.Infof(
+			"downgrading validation [%s] to warn mode for requester [%s] due to exempt [%s]",
+			validation.Name,
+			operation.Review.Request.UserInfo.Username,
+			match,
+		)
+
+		operation.Metrics.downgraded(validation.Name, validate.SkipReasonUser)
+
+		validation.Action = validate.EnforcementActionWarn
+		validation.ExemptedBy = fmt.Sprintf("%s %s", validate.SkipReasonUser, match)
+
+		return false
+	}
+
+	operation.Log.Infof(
+		"skipping validation [%s] for requester [%s] due to exempt [%s]",
+		validation.Name,
+		operation.Review.Request.UserInfo.Username,
+		match,
+	)
+
+	operation.skip(validation, validate.SkipReasonUser, match)
+
+	return true
+}
+
+// applyExemption applies a time-bound exemption to a validation.  It returns true if the
+// validation should be skipped.
+func (operation *Operation) applyExemption(validation *validate.Validation) bool {
+	exemption, err := validate.GetExemption(validation.Resource, validation.Name)
+	if err != nil {
+		// an invalid exemption does not exempt the resource from the validation
+		operation.Log.Errorf(
+			"%s - ignoring exemption for %s",
+			err,
+			strings.ToLower(resources.ToString(validation.Resource)),
+		)
+
+		return false
+	}
+
+	if exemption == nil {
+		return false
+	}
+
+	switch exemption.Status(time.Now(), operation.ExemptionGracePeriod) {
+	case validate.ExemptionActive:
+		operation.Log.Infof(
+			"skipping validation [%s] due to exemption [%s]",
+			validation.Name,
+			exemption,
+		)
+
+		operation.skip(validation, validate.SkipReasonExemption, exemption.String())
+
+		return true
+	case validate.ExemptionGrace:
+		operation.Log.Infof(
+			"downgrading validation [%s] to warn mode due to expired exemption within grace period [%s]",
+			validation.Name,
+			exemption,
+		)
+
+		operation.Metrics.downgraded(validation.Name, validate.SkipReasonExemption)
+
+		validation.Action = validate.EnforcementActionWarn
+		validation.ExemptedBy = fmt.Sprintf("%s %s (expired)", validate.SkipReasonExemption, exemption)
+	case validate.ExemptionExpired:
+		operation.Log.Debugf(
+			"enforcing validation [%s] due to expired exemption [%s]",
+			validation.Name,
+			exemption,
+		)
+	}
+
+	return false
+}
+
+// skip records a validation which was skipped along with the reason it was skipped.
+func (operation *Operation) skip(validation *validate.Validation, reason validate.SkipReason, message string) {
+	result := validate.NewSkipResult(validation.Name, reason, message)
+
+	// skips due to an exemption for the resource or requester are recorded as exempted
+	if reason == validate.SkipReasonExemption || reason == validate.SkipReasonUser {
+		result.ExemptedBy = fmt.Sprintf("%s %s", reason, message)
+	}
+
+	operation.Metrics.skipped(validation.Name, reason)
+	operation.Results = append(operation.Results, result)
+}
+
 // performValidate performs prevalidation prior to actually running the tests to ensure that we
 // have a clean input.
 func (webhook *Webhook) performValidate(w http.ResponseWriter, r *http.Request, operation *Operation) (int, error) {
+	if err := operation.execute(); err != nil {
+		return http.StatusForbidden, err
+	}
+
+	return http.StatusAccepted, nil
+}
+
+// execute executes each of the registered validations and records the results on the operation.  An
+// error is returned if any validations in deny mode have failed.  Failed validations in warn mode do
+// not return an error, but are recorded as warnings.
+func (operation *Operation) execute() error {
+	failures := []string{}
+
 	for _, validation := range operation.Validations {
-		operation.Log.DebugF("performing validation: %s", validation.Name)
+		operation.Log.Debugf("performing validation: %s", validation.Name)
 
-		isValid, err := validation.Execute()
-		if !isValid || err != nil {
-			return http.StatusForbidden, fmt.Errorf("%s - %w", ErrValidationFailed, err)
+		result := validation.Result()
+		operation.Results = append(operation.Results, result)
+
+		switch result.Status {
+		case validate.ResultPass:
+			operation.Log.Debugf("successfully completed validation: %s", validation.Name)
+		case validate.ResultWarn:
+			// validations in warn mode do not deny the request but return a warning to the requester
+			operation.Log.Warningf("%s - permitting due to warn mode", result.Message)
+			operation.Warnings = append(operation.Warnings, result.Message)
+		case validate.ResultFail:
+			failures = append(failures, result.Message)
+		case validate.ResultSkip:
+			// registered validations are never skipped
 		}
+	}
 
-		operation.Log.DebugF("successfully completed validation: %s", validation.Name)
+	if len(failures) > 0 {
+		return fmt.Errorf("%s - %s", ErrValidationFailed, strings.Join(failures, "; "))
 	}
 
-	return http.StatusAccepted, nil
+	return nil
 }
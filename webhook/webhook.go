@@ -4,27 +4,35 @@
 package webhook
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
+	"time"
 
-	"github.com/apsdehal/go-logger"
 	"github.com/gorilla/mux"
 	admissionv1 "k8s.io/api/admission/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/homedir"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/nukleros/pod-security-webhook/logging"
 	"github.com/nukleros/pod-security-webhook/resources"
 	"github.com/nukleros/pod-security-webhook/validate"
 )
@@ -35,59 +43,134 @@ const (
 	portEnv    = "WEBHOOK_PORT"
 	debugEnv   = "DEBUG"
 
+	// adminAddressEnv is the address of the administrative endpoints, which are served without
+	// authentication and so are only served on the loopback interface by default.
+	adminAddressEnv = "ADMIN_ADDRESS"
+
+	logLevelEnv  = "LOG_LEVEL"
+	logFormatEnv = "LOG_FORMAT"
+
 	defaultTLSCertEnv = "/ssl_certs/tls.crt"
 	defaultTLSKeyEnv  = "/ssl_certs/tls.key"
 	defaultPort       = 8443
+
+	defaultAdminAddress = "127.0.0.1:8081"
+
+	informerResyncPeriod = 1 * time.Hour
 )
 
 var (
 	ErrRequestInvalid = errors.New("invalid request")
+	ErrInformerSync   = errors.New("unable to sync informer cache")
+	ErrInvalidEnv     = errors.New("invalid environment variable")
 )
 
 type Webhook struct {
-	Certificate *tls.Certificate
-	Client      kubernetes.Interface
-	Log         *logger.Logger
-	Router      *mux.Router
-	Port        int
+	Certificate   *tls.Certificate
+	Client        kubernetes.Interface
+	DynamicClient dynamic.Interface
+	Informers     informers.SharedInformerFactory
+	Namespaces    corev1listers.NamespaceLister
+	Workloads     map[string]cache.SharedIndexInformer
+	Log           *logging.Logger
+	Metrics       *Metrics
+	Router        *mux.Router
+	Port          int
+
+	// AdminRouter serves the administrative endpoints, such as changing the log level, on the
+	// AdminAddress.  These are kept off of the admission port as they are unauthenticated.
+	AdminRouter  *mux.Router
+	AdminAddress string
+
+	// Events is the broadcaster and Recorder is the recorder used to record events for rejected
+	// resources and resources permitted with warnings.  Both are nil if events are disabled.
+	Events   record.EventBroadcaster
+	Recorder record.EventRecorder
+
+	// DecisionLog records each admission decision to a set of sinks.  It is nil if no sinks
+	// are configured.
+	DecisionLog *DecisionLog
+
+	// Auditor audits existing workloads and writes the results as policy reports.  It is nil
+	// if audit mode is disabled.
+	Auditor *Auditor
+
+	// Leader runs the background workers, such as the auditor, on the replica which holds the
+	// leader election lease.  It is nil for webhooks which do not run in a cluster.
+	Leader *Leader
+
+	// Policy is the policy configuration, which defines custom validations.
+	Policy *validate.PolicyConfig
+
+	// ExemptionGracePeriod is the period after an exemption expires in which validations
+	// run in warn mode prior to being enforced.
+	ExemptionGracePeriod time.Duration
+
+	// readiness tracks whether the informer caches have synced and whether the webhook is
+	// draining prior to shutting down.
+	readiness readiness
 }
 
 type OperationStep func(http.ResponseWriter, *http.Request, *Operation) (int, error)
 
 type Operation struct {
-	Log         *logger.Logger
+	Log         *logging.Logger
+	Metrics     *Metrics
 	Resource    client.Object
 	PodSpec     *corev1.PodSpec
+	Namespace   *corev1.Namespace
 	Validations []*validate.Validation
+	Results     []*validate.Result
 	Review      *admissionv1.AdmissionReview
 
+	// Known are all validations known to the webhook for this operation, including those which
+	// were skipped, in the order in which they were registered.
+	Known []*validate.Validation
+
+	// configuration for this operation
+	Policy               *validate.PolicyConfig
+	ExemptionGracePeriod time.Duration
+
+	// Started is the time at which the operation started
+	Started time.Time
+
+	// Mutating is true for operations which mutate rather than validate the resource
+	Mutating bool
+
 	// functions
 	OperationStep []OperationStep
 	RegisterFunc  func()
 
 	// admission for this operation
-	Patches        []map[string]string
+	Patches        []map[string]interface{}
 	Permitted      bool
+	Warnings       []string
 	ResponseError  error
 	ResponseReason metav1.StatusReason
 	StatusCode     int
 }
 
 func NewWebhook() (*Webhook, error) {
-	// get the kubernetes client
-	kubernetesClient, err := getClient()
+	// get the kubernetes clients
+	config, err := getConfig()
+	if err != nil {
+		return nil, fmt.Errorf("%w - error creating client config for webhook", err)
+	}
+
+	kubernetesClient, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		return nil, fmt.Errorf("%w - error creating client object for webhook", err)
 	}
 
-	// get the logger
-	log, err := logger.New("webhook", 0, os.Stdout)
+	dynamicClient, err := dynamic.NewForConfig(config)
 	if err != nil {
-		return nil, fmt.Errorf("%w - error creating logger object for webhook", err)
+		return nil, fmt.Errorf("%w - error creating dynamic client object for webhook", err)
 	}
 
-	if os.Getenv(debugEnv) == "true" {
-		log.SetLogLevel(logger.DebugLevel)
+	// get the logger
+	log, err := newLogger()
+	if err != nil {
+		return nil, fmt.Errorf("%w - error creating logger object for webhook", err)
 	}
 
 	// get the certificates
@@ -105,11 +188,48 @@ func NewWebhook() (*Webhook, error) {
 		return nil, fmt.Errorf("%w - error loading x509 key pair from cert: [%s] and key: [%s]", err, cert, key)
 	}
 
+	// get the exemption grace period
+	gracePeriod, err := validate.ExemptionGracePeriod()
+	if err != nil {
+		return nil, fmt.Errorf("%w - error retrieving exemption grace period", err)
+	}
+
+	// get the policy configuration
+	policy, err := validate.PolicyConfigFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("%w - error loading policy configuration", err)
+	}
+
+	// get the event broadcaster
+	events, err := newEventBroadcaster(kubernetesClient)
+	if err != nil {
+		return nil, fmt.Errorf("%w - error creating event broadcaster for webhook", err)
+	}
+
 	// create the webhook
 	webhook := &Webhook{
-		Certificate: &tlsPair,
-		Client:      kubernetesClient,
-		Log:         log,
+		Events:               events,
+		Recorder:             newEventRecorder(events),
+		Certificate:          &tlsPair,
+		Client:               kubernetesClient,
+		DynamicClient:        dynamicClient,
+		Informers:            informers.NewSharedInformerFactory(kubernetesClient, informerResyncPeriod),
+		Log:                  log,
+		Metrics:              NewMetrics(),
+		Policy:               policy,
+		ExemptionGracePeriod: gracePeriod,
+	}
+
+	// register the namespace lister and the workload informers prior to starting the informers
+	webhook.Namespaces = webhook.Informers.Core().V1().Namespaces().Lister()
+
+	if webhook.Workloads, err = workloadInformers(webhook.Informers); err != nil {
+		return nil, fmt.Errorf("%w - error creating workload informers for webhook", err)
+	}
+
+	// report failed calls to validation plugins, which are otherwise hidden when a plugin fails open
+	for _, plugin := range policy.Plugins {
+		plugin.OnError = webhook.pluginError
 	}
 
 	// get the port
@@ -125,19 +245,216 @@ func NewWebhook() (*Webhook, error) {
 		webhook.Port = portInt
 	}
 
-	// set the handler functions and return
+	// get the admin address
+	webhook.AdminAddress = os.Getenv(adminAddressEnv)
+	if webhook.AdminAddress == "" {
+		webhook.AdminAddress = defaultAdminAddress
+	}
+
+	// set the handler functions
+	webhook.Router = webhook.newRouter()
+	webhook.AdminRouter = webhook.newAdminRouter()
+
+	// create the decision log if any sinks are configured
+	if webhook.DecisionLog, err = newDecisionLogFromEnv(log, webhook.Metrics); err != nil {
+		return nil, fmt.Errorf("%w - error creating decision log for webhook", err)
+	}
+
+	// create the auditor if audit mode is enabled
+	if webhook.Auditor, err = NewAuditor(webhook); err != nil {
+		return nil, fmt.Errorf("%w - error creating auditor for webhook", err)
+	}
+
+	// create the leader which runs the background workers on a single replica, if there are any, so
+	// that replicas without background work do not contend for the lease
+	workers := []Worker{}
+	if webhook.Auditor != nil {
+		workers = append(workers, webhook.Auditor)
+	}
+
+	if len(workers) > 0 {
+		if webhook.Leader, err = newLeaderFromEnv(kubernetesClient, log, webhook.Metrics, workers...); err != nil {
+			return nil, fmt.Errorf("%w - error creating leader election for webhook", err)
+		}
+	}
+
+	return webhook, nil
+}
+
+// NewOfflineWebhook returns a webhook which validates resources without a cluster, such as when
+// testing policies or scanning manifests.  Namespaces are served from the given namespaces only.
+func NewOfflineWebhook(policy *validate.PolicyConfig, namespaces ...*corev1.Namespace) (*Webhook, error) {
+	gracePeriod, err := validate.ExemptionGracePeriod()
+	if err != nil {
+		return nil, fmt.Errorf("%w - error retrieving exemption grace period", err)
+	}
+
+	if policy == nil {
+		policy = &validate.PolicyConfig{}
+	}
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+
+	for _, namespace := range namespaces {
+		if err := indexer.Add(namespace); err != nil {
+			return nil, fmt.Errorf("%w - unable to add namespace [%s]", err, namespace.Name)
+		}
+	}
+
+	return &Webhook{
+		Log:                  logging.New("webhook", logging.FormatLogfmt, logging.ErrorLevel, io.Discard),
+		Metrics:              NewMetrics(),
+		Namespaces:           corev1listers.NewNamespaceLister(indexer),
+		Policy:               policy,
+		ExemptionGracePeriod: gracePeriod,
+	}, nil
+}
+
+// newRouter returns the router which serves each of the endpoints of the webhook.
+func (webhook *Webhook) newRouter() *mux.Router {
 	router := mux.NewRouter()
 	router.HandleFunc("/validate", webhook.validate)
-	router.HandleFunc("/healthz", webhook.healthCheck)
+	router.HandleFunc("/mutate", webhook.mutate)
+	router.HandleFunc("/livez", webhook.liveness)
+	router.HandleFunc("/readyz", webhook.readinessCheck)
+	router.HandleFunc("/healthz", webhook.liveness)
+	router.HandleFunc("/explain", webhook.explain).Methods(http.MethodPost)
+	router.Handle("/metrics", webhook.Metrics.Handler())
+
+	return router
+}
 
-	webhook.Router = router
+// newAdminRouter returns the router which serves the administrative endpoints of the webhook.
+func (webhook *Webhook) newAdminRouter() *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc("/loglevel", webhook.logLevel).Methods(http.MethodGet, http.MethodPut)
+	router.HandleFunc("/exemptions", webhook.exemptions).Methods(http.MethodGet)
 
-	return webhook, nil
+	return router
 }
 
-// getClient returns a valid kubernetes client used for interacting with the cluster.
+// newLogger returns the logger for the webhook given the format and level from the environment.
+func newLogger() (*logging.Logger, error) {
+	format := logging.FormatJSON
+
+	if formatEnv := os.Getenv(logFormatEnv); formatEnv != "" {
+		parsed, err := logging.ParseFormat(formatEnv)
+		if err != nil {
+			return nil, fmt.Errorf("%w - error parsing log format from environment variable %s", err, logFormatEnv)
+		}
+
+		format = parsed
+	}
+
+	level := logging.InfoLevel
+
+	if levelEnv := os.Getenv(logLevelEnv); levelEnv != "" {
+		parsed, err := logging.ParseLevel(levelEnv)
+		if err != nil {
+			return nil, fmt.Errorf("%w - error parsing log level from environment variable %s", err, logLevelEnv)
+		}
+
+		level = parsed
+	}
+
+	// the debug environment variable is kept for backwards compatibility
+	if os.Getenv(debugEnv) == "true" {
+		level = logging.DebugLevel
+	}
+
+	return logging.New("webhook", format, level, os.Stdout), nil
+}
+
+// Start starts the background processes for the webhook and blocks until the context is
+// cancelled.
+func (webhook *Webhook) Start(ctx context.Context) error {
+	if webhook.DecisionLog != nil {
+		webhook.DecisionLog.Start()
+	}
+
+	// webhooks which do not run in a cluster have no informers
+	if webhook.Informers != nil {
+		webhook.Informers.Start(ctx.Done())
+
+		for informer, synced := range webhook.Informers.WaitForCacheSync(ctx.Done()) {
+			if !synced {
+				return fmt.Errorf("%w - [%v]", ErrInformerSync, informer)
+			}
+		}
+	}
+
+	webhook.Synced()
+
+	// the workers must only start once the caches have synced, as they read from the caches
+	if webhook.Leader != nil {
+		webhook.Leader.Run(ctx)
+	}
+
+	<-ctx.Done()
+
+	return nil
+}
+
+// Shutdown releases any resources held by the webhook.
+func (webhook *Webhook) Shutdown() {
+	if webhook.DecisionLog != nil {
+		webhook.DecisionLog.Close()
+	}
+
+	if webhook.Policy != nil {
+		if err := webhook.Policy.Close(); err != nil {
+			webhook.Log.Errorf("%s - error closing policy configuration", err)
+		}
+	}
+
+	if webhook.Events != nil {
+		webhook.Events.Shutdown()
+	}
+}
+
+// namespace returns the namespace with the given name from the informer cache, falling back to
+// the api server if it is not found in the cache, for example while the cache is syncing.  A nil
+// namespace is returned if the namespace could not be retrieved.  Webhooks which do not run in a
+// cluster treat resources without a namespace as being in the default namespace, and namespaces
+// which they were not given as namespaces without annotations.
+func (webhook *Webhook) namespace(ctx context.Context, name string) *corev1.Namespace {
+	if name == "" {
+		if webhook.Client != nil {
+			return nil
+		}
+
+		name = metav1.NamespaceDefault
+	}
+
+	if webhook.Namespaces != nil {
+		if namespace, err := webhook.Namespaces.Get(name); err == nil {
+			return namespace
+		}
+	}
+
+	if webhook.Client == nil {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	}
+
+	namespace, err := webhook.Client.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		webhook.Log.Errorf("%s - unable to retrieve namespace [%s]", err, name)
+
+		return nil
+	}
+
+	return namespace
+}
+
+// pluginError logs and records a failed call to a validation plugin.
+func (webhook *Webhook) pluginError(plugin *validate.Plugin, err error) {
+	webhook.Log.Errorf("%s - applying failure policy [%s]", err, plugin.FailurePolicy)
+	webhook.Metrics.pluginErrored(plugin)
+}
+
+// getConfig returns a valid kubernetes client configuration used for interacting with the cluster.
 // TODO: improve logic for retrieving kubernetes client.
-func getClient() (kubernetes.Interface, error) {
+func getConfig() (*rest.Config, error) {
 	var config *rest.Config
 
 	var err error
@@ -149,20 +466,18 @@ func getClient() (kubernetes.Interface, error) {
 			return nil, fmt.Errorf("%w - error loading kubeconfig from environment variable KUBECONFIG: [%s]", err, kubeConfig)
 		}
 
-		//nolint:wrapcheck
-		return kubernetes.NewForConfig(config)
+		return config, nil
 	}
 
 	// read kubeconfig from home directory
 	if home := homedir.HomeDir(); home != "" {
 		kubeConfig = filepath.Join(home, ".kube", "config")
 		if _, err = os.Stat(kubeConfig); err == nil {
-			if config, err = clientcmd.BuildConfigFromFlags("", kubeConfig); err == nil {
+			if config, err = clientcmd.BuildConfigFromFlags("", kubeConfig); err != nil {
 				return nil, fmt.Errorf("%w - error loading kubeconfig from home path: [%s]", err, kubeConfig)
 			}
 
-			//nolint:wrapcheck
-			return kubernetes.NewForConfig(config)
+			return config, nil
 		}
 	}
 
@@ -171,8 +486,56 @@ func getClient() (kubernetes.Interface, error) {
 		return nil, fmt.Errorf("%w - error loading in-cluster kubernetes client config", err)
 	}
 
-	//nolint:wrapcheck
-	return kubernetes.NewForConfig(config)
+	return config, nil
+}
+
+// intFromEnv returns a positive integer from an environment variable, or a default value if
+// the environment variable is unset.
+func intFromEnv(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed <= 0 {
+		return 0, fmt.Errorf("%w - expected positive integer [%s=%s]", ErrInvalidEnv, key, value)
+	}
+
+	return parsed, nil
+}
+
+// nonNegativeIntFromEnv returns a non-negative integer from an environment variable, or a default
+// value if the environment variable is unset.  It is used for settings where zero disables
+// something, such as the number of retries.
+func nonNegativeIntFromEnv(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 0 {
+		return 0, fmt.Errorf("%w - expected non-negative integer [%s=%s]", ErrInvalidEnv, key, value)
+	}
+
+	return parsed, nil
+}
+
+// durationFromEnv returns a positive duration from an environment variable, or a default value if
+// the environment variable is unset.
+func durationFromEnv(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed <= 0 {
+		return 0, fmt.Errorf("%w - expected positive duration [%s=%s]", ErrInvalidEnv, key, value)
+	}
+
+	return parsed, nil
 }
 
 // writeErrorMessage writes error message to stderr and the http stream.
@@ -197,6 +560,9 @@ func (webhook *Webhook) performSetup(w http.ResponseWriter, r *http.Request, ope
 		return http.StatusBadRequest, fmt.Errorf("%w - request object is nil", ErrRequestInvalid)
 	}
 
+	// set the review so that a response may be sent if any of the following steps fail
+	operation.Review = &input
+
 	// ensure the object in the request is not empty
 	if len(input.Request.Object.Raw) < 1 {
 		return http.StatusBadRequest, fmt.Errorf("%w - empty object in request", ErrRequestInvalid)
@@ -216,9 +582,24 @@ func (webhook *Webhook) performSetup(w http.ResponseWriter, r *http.Request, ope
 		return http.StatusInternalServerError, fmt.Errorf("%w - error retrieving pod specification from object", err)
 	}
 
-	operation.Review = &input
 	operation.PodSpec = podSpec
 	operation.Resource = &object
+	operation.Namespace = webhook.namespace(r.Context(), input.Request.Namespace)
+
+	// correlate all log messages for this operation with the request
+	name := object.GetName()
+	if name == "" {
+		name = object.GetGenerateName()
+	}
+
+	operation.Log = operation.Log.With(
+		"uid", input.Request.UID,
+		"kind", input.Request.Kind.Kind,
+		"namespace", input.Request.Namespace,
+		"name", name,
+		"user", input.Request.UserInfo.Username,
+		"operation", input.Request.Operation,
+	)
 
 	// run the function to register the operation
 	operation.RegisterFunc()
@@ -237,7 +618,7 @@ func (operation *Operation) run(webhook *Webhook, w http.ResponseWriter, r *http
 				operation.StatusCode = http.StatusForbidden
 			}
 
-			webhook.Log.Error(operation.ResponseError.Error())
+			operation.Log.Error(operation.ResponseError.Error())
 
 			// respond with an internal error message and register a response error
 			// if that fails
@@ -251,6 +632,8 @@ func (operation *Operation) run(webhook *Webhook, w http.ResponseWriter, r *http
 				)
 			}
 
+			webhook.finish(operation)
+
 			return
 		}
 	}
@@ -262,6 +645,51 @@ func (operation *Operation) run(webhook *Webhook, w http.ResponseWriter, r *http
 	if err := webhook.respond(w, operation); err != nil {
 		webhook.writeErrorMessage(w, fmt.Errorf("%w - error sending response", err), http.StatusInternalServerError)
 	}
+
+	webhook.finish(operation)
+}
+
+// finish records the outcome of an operation once the response has been sent.  Mutations are
+// only logged, as the admission decision is made and recorded by the validations.
+func (webhook *Webhook) finish(operation *Operation) {
+	if operation.Mutating {
+		operation.Log.With("patches", len(operation.Patches)).Debug("mutation complete")
+
+		return
+	}
+
+	webhook.recordEvents(operation)
+	operation.logDecision()
+
+	if webhook.DecisionLog != nil {
+		webhook.DecisionLog.Record(operation.decisionRecord())
+	}
+}
+
+// logDecision logs the admission decision for an operation along with the violated validations.
+func (operation *Operation) logDecision() {
+	decision := "denied"
+	if operation.Permitted {
+		decision = "allowed"
+	}
+
+	operation.Log.With(
+		"decision", decision,
+		"violations", operation.violations(),
+	).Info("admission decision")
+}
+
+// violations returns the names of the validations which failed or produced a warning.
+func (operation *Operation) violations() []string {
+	violations := []string{}
+
+	for _, result := range operation.Results {
+		if result.Status == validate.ResultFail || result.Status == validate.ResultWarn {
+			violations = append(violations, result.Validation)
+		}
+	}
+
+	return violations
 }
 
 // respond send the response back to the main processing loop.
@@ -273,6 +701,14 @@ func (webhook *Webhook) respond(w http.ResponseWriter, operation *Operation) err
 		Result:  &metav1.Status{Code: int32(operation.StatusCode)},
 	}
 
+	// set the audit annotations recorded by the api server
+	operation.Review.Response.AuditAnnotations = operation.auditAnnotations()
+
+	// set the response warnings
+	if len(operation.Warnings) > 0 {
+		operation.Review.Response.Warnings = operation.Warnings
+	}
+
 	// set the response error
 	if operation.ResponseError != nil {
 		operation.Review.Response.Result.Message = operation.ResponseError.Error()
@@ -307,8 +743,33 @@ func (webhook *Webhook) respond(w http.ResponseWriter, operation *Operation) err
 		return fmt.Errorf("%w - unable to send HTTP response", err)
 	}
 
-	webhook.Log.Debug("sending response")
-	webhook.Log.Debugf("%s", response)
+	operation.Log.Debug("sending response")
+
+	if operation.Log.Enabled(logging.DebugLevel) {
+		operation.Log.Debugf("%s", redactReview(operation.Review))
+	}
 
 	return nil
 }
+
+// redactReview returns the json representation of an admission review with sensitive values,
+// such as container environment variables and secrets, redacted.  This is safe to log.
+func redactReview(review *admissionv1.AdmissionReview) []byte {
+	redacted := *review
+
+	if review.Request != nil {
+		request := *review.Request
+		request.Object.Raw = logging.RedactRaw(request.Object.Raw)
+		request.OldObject.Raw = logging.RedactRaw(request.OldObject.Raw)
+		request.Object.Object = nil
+		request.OldObject.Object = nil
+		redacted.Request = &request
+	}
+
+	response, err := json.Marshal(&redacted)
+	if err != nil {
+		return []byte(logging.Redacted)
+	}
+
+	return response
+}